@@ -93,6 +93,90 @@ type AuthOptions struct {
 	ApplicationCredentialID     string `json:"-"`
 	ApplicationCredentialName   string `json:"-"`
 	ApplicationCredentialSecret string `json:"-"`
+
+	// TokenStore, if set, is consulted before creating a token, and updated
+	// with every created token. See TokenStore for details. It is only used
+	// with the Identity V3 API.
+	TokenStore TokenStore `json:"-"`
+
+	// AuthReceipt is the ID of an auth receipt issued by Keystone, to
+	// complete a multi-factor authentication with the remaining methods (for
+	// example with Passcode). See ErrAuthReceiptRequired.
+	AuthReceipt string `json:"-"`
+
+	// PasscodeFunc, if set, is called when Keystone answers the
+	// authentication with an auth receipt requiring the TOTP method. The
+	// authentication is then completed with the returned passcode.
+	PasscodeFunc PasscodeFunc `json:"-"`
+
+	// OIDC, if set, authenticates with an OpenID Connect identity provider
+	// through Keystone federation instead of with the credentials above. The
+	// federated token is then rescoped to the project or domain given above.
+	// It is only used with the Identity V3 API.
+	OIDC *OIDCOptions `json:"-"`
+}
+
+// OIDC grant types, used to obtain a token from an OpenID Connect identity
+// provider.
+const (
+	OIDCGrantPassword          = "password"
+	OIDCGrantClientCredentials = "client_credentials"
+	OIDCGrantAuthorizationCode = "authorization_code"
+)
+
+// OIDCOptions holds the settings of the authentication with an OpenID Connect
+// identity provider (IdP) through Keystone federation. The token obtained from
+// the IdP is exchanged for a Keystone token at
+// /OS-FEDERATION/identity_providers/{IdentityProvider}/protocols/{Protocol}/auth.
+type OIDCOptions struct {
+	// IdentityProvider is the ID of the identity provider in Keystone.
+	IdentityProvider string
+
+	// Protocol is the federation protocol of the identity provider in
+	// Keystone, usually "openid".
+	Protocol string
+
+	// AccessToken is a token already obtained from the IdP. When it is set,
+	// it is sent to Keystone as is, and the IdP is not contacted.
+	AccessToken string
+
+	// GrantType is the OAuth 2.0 grant used to obtain a token from the IdP:
+	// OIDCGrantPassword, OIDCGrantClientCredentials or
+	// OIDCGrantAuthorizationCode.
+	GrantType string
+
+	// DiscoveryEndpoint is the URL of the OpenID Connect discovery document
+	// of the IdP (".well-known/openid-configuration"), used to find its token
+	// endpoint. It is not needed when AccessTokenEndpoint is set.
+	DiscoveryEndpoint string
+
+	// AccessTokenEndpoint is the URL of the token endpoint of the IdP.
+	AccessTokenEndpoint string
+
+	// ClientID and ClientSecret are the credentials of the OAuth 2.0 client.
+	ClientID     string
+	ClientSecret string
+
+	// Username and Password are the credentials of the user, for the
+	// OIDCGrantPassword grant.
+	Username string
+	Password string
+
+	// Code and RedirectURI are the authorization code issued by the IdP and
+	// the redirect URI of the authorization request, for the
+	// OIDCGrantAuthorizationCode grant. CodeVerifier is the PKCE code
+	// verifier of the authorization request, if any.
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+
+	// OpenIDScope is the space-separated list of scopes requested from the
+	// IdP. Defaults to "openid profile".
+	OpenIDScope string
+
+	// AccessTokenType is the token of the IdP response that is sent to
+	// Keystone: "access_token" (the default) or "id_token".
+	AccessTokenType string
 }
 
 // AuthScope allows a created token to be limited to a specific domain or project.
@@ -511,7 +595,11 @@ func (opts AuthOptions) CanReauth() bool {
 }
 
 // ToTokenV3HeadersMap allows AuthOptions to satisfy the AuthOptionsBuilder
-// interface in the v3 tokens package.
+// interface in the v3 tokens package. It returns the auth receipt header when
+// an auth receipt is set.
 func (opts *AuthOptions) ToTokenV3HeadersMap(map[string]any) (map[string]string, error) {
+	if opts.AuthReceipt != "" {
+		return map[string]string{AuthReceiptHeader: opts.AuthReceipt}, nil
+	}
 	return nil, nil
 }
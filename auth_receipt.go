@@ -0,0 +1,77 @@
+package gophercloud
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// AuthReceiptHeader is the header in which Keystone returns auth receipts,
+// and in which it expects them back.
+const AuthReceiptHeader = "Openstack-Auth-Receipt"
+
+// AuthReceipt is issued by Keystone when an authentication succeeded, but the
+// multi-factor authentication rules of the user require more methods than the
+// ones that were used. The authentication can be completed within the
+// lifetime of the receipt, by authenticating with the remaining methods and
+// the ID of the receipt.
+type AuthReceipt struct {
+	// ID is the ID of the receipt, to send back with the remaining methods.
+	ID string
+
+	// Methods are the authentication methods that already succeeded.
+	Methods []string
+
+	// RequiredAuthMethods are the multi-factor authentication rules of the
+	// user: each rule is a set of methods that are sufficient together.
+	RequiredAuthMethods [][]string
+
+	// UserID is the ID of the user that authenticated.
+	UserID string
+
+	// ExpiresAt is the time at which the receipt expires.
+	ExpiresAt time.Time
+}
+
+// RemainingMethods returns, for each rule of RequiredAuthMethods, the methods
+// of the rule that did not succeed yet. Authenticating with all the methods of
+// any of the returned sets completes the authentication.
+func (r AuthReceipt) RemainingMethods() [][]string {
+	remaining := make([][]string, 0, len(r.RequiredAuthMethods))
+	for _, rule := range r.RequiredAuthMethods {
+		var methods []string
+		for _, method := range rule {
+			if !slices.Contains(r.Methods, method) {
+				methods = append(methods, method)
+			}
+		}
+		remaining = append(remaining, methods)
+	}
+	return remaining
+}
+
+// PasscodeFunc returns a TOTP passcode to complete an authentication that
+// Keystone answered with an auth receipt, for example by prompting the user.
+type PasscodeFunc func(ctx context.Context, receipt AuthReceipt) (string, error)
+
+// ErrAuthReceiptRequired is the error returned when Keystone answers an
+// authentication with an auth receipt: the authentication methods succeeded,
+// but more are required by the multi-factor authentication rules of the user.
+// It wraps the 401 ErrUnexpectedResponseCode of the response.
+type ErrAuthReceiptRequired struct {
+	ErrUnexpectedResponseCode
+	Receipt AuthReceipt
+}
+
+func (e ErrAuthReceiptRequired) Error() string {
+	e.DefaultErrString = fmt.Sprintf(
+		"Keystone requires more authentication methods for user %s: the remaining methods are %v",
+		e.Receipt.UserID, e.Receipt.RemainingMethods(),
+	)
+	return e.choseErrString()
+}
+
+func (e ErrAuthReceiptRequired) Unwrap() error {
+	return e.ErrUnexpectedResponseCode
+}
@@ -0,0 +1,220 @@
+package gophercloud
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultBackoffInitialInterval is the delay before the first retry when
+	// Backoff.InitialInterval is not set.
+	DefaultBackoffInitialInterval = 1 * time.Second
+
+	// DefaultBackoffMaxInterval is the upper bound for a computed delay when
+	// Backoff.MaxInterval is not set.
+	DefaultBackoffMaxInterval = 60 * time.Second
+
+	// DefaultBackoffMultiplier is the factor by which the delay grows after
+	// each retry when Backoff.Multiplier is not set.
+	DefaultBackoffMultiplier = 2.0
+
+	// DefaultBackoffJitter is the fraction of each computed delay that is
+	// randomized when Backoff.Jitter is not set.
+	DefaultBackoffJitter = 0.2
+)
+
+/*
+Backoff is a ready-made retry policy for ProviderClient. It waits with an
+exponentially growing, jittered and capped delay between attempts, and it
+honors the Retry-After header sent by the server, in both its delay-seconds
+and HTTP-date forms.
+
+A ProviderClient does not retry rate-limited requests unless it is told to.
+To opt in, plug the policy into the client:
+
+	backoff := &gophercloud.Backoff{}
+	provider.RetryBackoffFunc = backoff.RetryBackoff
+
+To also retry idempotent requests (GET, HEAD and DELETE) that failed with a
+502, 503 or 504 response or with a reset connection, install RetryIdempotent
+as the client's RetryFunc:
+
+	provider.RetryFunc = backoff.RetryIdempotent
+
+The zero value is ready to use. A delay is never longer than the time left
+before the deadline of the request context: if the next attempt could not
+happen in time, the policy gives up immediately and the original error is
+returned.
+*/
+type Backoff struct {
+	// InitialInterval is the delay before the first retry. Defaults to
+	// DefaultBackoffInitialInterval.
+	InitialInterval time.Duration
+
+	// MaxInterval caps the computed delay between two attempts. It does not
+	// cap a delay requested by the server through Retry-After. Defaults to
+	// DefaultBackoffMaxInterval.
+	MaxInterval time.Duration
+
+	// Multiplier is the factor by which the delay grows after each retry.
+	// Defaults to DefaultBackoffMultiplier.
+	Multiplier float64
+
+	// Jitter is the fraction (between 0 and 1) of each computed delay that is
+	// randomized. Defaults to DefaultBackoffJitter. Set it to a negative value
+	// to disable jitter.
+	Jitter float64
+
+	// MaxRetries is the maximum number of retries performed by
+	// RetryIdempotent. Rate-limited responses are bounded by
+	// ProviderClient.MaxBackoffRetries instead. Defaults to
+	// DefaultMaxBackoffRetries.
+	MaxRetries uint
+}
+
+// RetryBackoff satisfies RetryBackoffFunc. It sleeps for the duration
+// requested by the Retry-After header of the response, or for the next
+// exponential delay if the header is absent, and then returns nil so that the
+// request is retried. It returns the original error if the request context
+// would expire before the next attempt, and the context error if the context
+// is canceled while sleeping.
+func (b *Backoff) RetryBackoff(ctx context.Context, respErr *ErrUnexpectedResponseCode, err error, failCount uint) error {
+	giveUp := err
+	if respErr != nil {
+		giveUp = *respErr
+		if delay, ok := parseRetryAfter(respErr.ResponseHeader.Get("Retry-After")); ok {
+			return sleepContext(ctx, delay, giveUp)
+		}
+	}
+	if giveUp == nil {
+		giveUp = errors.New("rate limited")
+	}
+
+	return sleepContext(ctx, b.delay(failCount), giveUp)
+}
+
+// RetryIdempotent satisfies RetryFunc. It retries GET, HEAD and DELETE
+// requests that failed with a 502, 503 or 504 response or because the
+// connection was reset, waiting between attempts like RetryBackoff does. A
+// request with a RawBody that is not an io.Seeker is never retried, because
+// its body cannot be replayed. Every other error is returned unchanged.
+func (b *Backoff) RetryIdempotent(ctx context.Context, method, url string, options *RequestOpts, err error, failCount uint) error {
+	maxRetries := b.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = DefaultMaxBackoffRetries
+	}
+	if failCount > maxRetries || !isIdempotent(method) || !isRetryableError(err) {
+		return err
+	}
+	if options != nil && options.RawBody != nil {
+		if _, ok := options.RawBody.(io.Seeker); !ok {
+			return err
+		}
+	}
+
+	var respErr ErrUnexpectedResponseCode
+	if errors.As(err, &respErr) {
+		if delay, ok := parseRetryAfter(respErr.ResponseHeader.Get("Retry-After")); ok {
+			return sleepContext(ctx, delay, err)
+		}
+	}
+
+	return sleepContext(ctx, b.delay(failCount), err)
+}
+
+// delay computes the jittered exponential delay before the given retry
+// attempt (starting at 1).
+func (b *Backoff) delay(failCount uint) time.Duration {
+	initial := b.InitialInterval
+	if initial <= 0 {
+		initial = DefaultBackoffInitialInterval
+	}
+	maxInterval := b.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = DefaultBackoffMaxInterval
+	}
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = DefaultBackoffMultiplier
+	}
+	jitter := b.Jitter
+	if jitter == 0 {
+		jitter = DefaultBackoffJitter
+	}
+
+	d := float64(initial)
+	for i := uint(1); i < failCount && d < float64(maxInterval); i++ {
+		d *= multiplier
+	}
+	d = min(d, float64(maxInterval))
+
+	if jitter > 0 {
+		d -= d * min(jitter, 1) * rand.Float64()
+	}
+
+	return time.Duration(d)
+}
+
+// parseRetryAfter interprets the value of a Retry-After header, which can
+// either be a number of seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if v, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(v) * time.Second, true
+	}
+	if v, err := http.ParseTime(value); err == nil {
+		return max(time.Until(v), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for the given delay. It returns giveUp without waiting if
+// the context deadline would pass before the delay elapses, and the context
+// error if the context is done while waiting.
+func sleepContext(ctx context.Context, delay time.Duration, giveUp error) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return giveUp
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isRetryableError(err error) bool {
+	var respErr ErrUnexpectedResponseCode
+	if errors.As(err, &respErr) {
+		return slices.Contains([]int{
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}, respErr.Actual)
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
@@ -94,6 +94,24 @@ type EndpointOpts struct {
 	// Availability is not required, and defaults to AvailabilityPublic. Not all
 	// providers or services offer all Availability options.
 	Availability Availability
+
+	// EndpointOverrides [optional] maps service types (or their aliases) to the
+	// URL to use for the service instead of the one from the service catalog,
+	// for example to reach the service through a proxy. The URL has the same
+	// form as the one in the catalog.
+	EndpointOverrides map[string]string
+
+	// Availabilities [optional] maps service types (or their aliases) to the
+	// visibility of the endpoint to be returned for that service. It takes
+	// precedence over Availability.
+	Availabilities map[string]Availability
+
+	// APIVersions [optional] maps service types (or their aliases) to the API
+	// version to use for that service, either a major version (e.g. "3") or a
+	// microversion (e.g. "2.79"). Service client factory functions ignore the
+	// versions whose major version differs from the one they implement, and
+	// set the microversion of the client otherwise.
+	APIVersions map[string]string
 }
 
 /*
@@ -108,14 +126,12 @@ type EndpointLocator func(context.Context, EndpointOpts) (string, error)
 // ApplyDefaults is an internal method to be used by provider implementations.
 //
 // It sets EndpointOpts fields if not already set, including a default type.
-// Currently, EndpointOpts.Availability defaults to the public endpoint.
+// Currently, EndpointOpts.Availability defaults to the public endpoint. An
+// availability set for the service type in Availabilities takes precedence.
 func (eo *EndpointOpts) ApplyDefaults(t string) {
 	if eo.Type == "" {
 		eo.Type = t
 	}
-	if eo.Availability == "" {
-		eo.Availability = AvailabilityPublic
-	}
 	if len(eo.Aliases) == 0 {
 		if aliases, ok := ServiceTypeAliases[eo.Type]; ok {
 			// happy path: user requested a service type by its official name
@@ -134,8 +150,40 @@ func (eo *EndpointOpts) ApplyDefaults(t string) {
 			}
 		}
 	}
+	if availability, ok := lookupService(eo.Availabilities, eo.Types()); ok {
+		eo.Availability = availability
+	}
+	if eo.Availability == "" {
+		eo.Availability = AvailabilityPublic
+	}
 }
 
 func (eo *EndpointOpts) Types() []string {
 	return append([]string{eo.Type}, eo.Aliases...)
 }
+
+// EndpointOverride returns the URL set in EndpointOverrides for the service
+// type of the options or one of its aliases, or an empty string.
+func (eo *EndpointOpts) EndpointOverride() string {
+	override, _ := lookupService(eo.EndpointOverrides, eo.Types())
+	return override
+}
+
+// APIVersion returns the API version set in APIVersions for the service type
+// of the options or one of its aliases, or an empty string.
+func (eo *EndpointOpts) APIVersion() string {
+	version, _ := lookupService(eo.APIVersions, eo.Types())
+	return version
+}
+
+// lookupService returns the value of the first of the given service types
+// found in m.
+func lookupService[T any](m map[string]T, types []string) (T, bool) {
+	for _, t := range types {
+		if v, ok := m[t]; ok {
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
@@ -76,8 +76,36 @@ func (e ErrMissingAnyoneOfEnvironmentVariables) Error() string {
 	return e.choseErrString()
 }
 
+var (
+	// ErrNotFound matches, with errors.Is, an ErrUnexpectedResponseCode reporting a 404 response.
+	ErrNotFound = errors.New("resource not found")
+
+	// ErrConflict matches, with errors.Is, an ErrUnexpectedResponseCode reporting a 409 response.
+	ErrConflict = errors.New("conflict")
+
+	// ErrForbidden matches, with errors.Is, an ErrUnexpectedResponseCode reporting a 403 response.
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrQuotaExceeded matches, with errors.Is, an ErrUnexpectedResponseCode reporting that a
+	// quota was exhausted. Depending on the service, this is reported with a 403, 409 or 413
+	// response, which is recognized from the fault in the response body.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// ErrOverLimit matches, with errors.Is, an ErrUnexpectedResponseCode reporting that the
+	// request was rate limited (a 429 or 498 response).
+	ErrOverLimit = errors.New("rate limit exceeded")
+)
+
 // ErrUnexpectedResponseCode is returned by the Request method when a response code other than
 // those listed in OkCodes is encountered.
+//
+// It can be matched against ErrNotFound, ErrConflict, ErrForbidden, ErrQuotaExceeded and
+// ErrOverLimit with errors.Is:
+//
+//	_, err := servers.Get(ctx, client, id).Extract()
+//	if errors.Is(err, gophercloud.ErrNotFound) {
+//		handleNotFound()
+//	}
 type ErrUnexpectedResponseCode struct {
 	BaseError
 	URL            string
@@ -89,9 +117,13 @@ type ErrUnexpectedResponseCode struct {
 }
 
 func (e ErrUnexpectedResponseCode) Error() string {
+	var requestID string
+	if id := e.RequestID(); id != "" {
+		requestID = fmt.Sprintf(" (request ID %s)", id)
+	}
 	e.DefaultErrString = fmt.Sprintf(
-		"Expected HTTP response code %v when accessing [%s %s], but got %d instead: %s",
-		e.Expected, e.Method, e.URL, e.Actual, bytes.TrimSpace(e.Body),
+		"Expected HTTP response code %v when accessing [%s %s], but got %d instead%s: %s",
+		e.Expected, e.Method, e.URL, e.Actual, requestID, bytes.TrimSpace(e.Body),
 	)
 	return e.choseErrString()
 }
@@ -101,6 +133,46 @@ func (e ErrUnexpectedResponseCode) GetStatusCode() int {
 	return e.Actual
 }
 
+// RequestID returns the ID that the service assigned to the failed request, as reported in the
+// X-OpenStack-Request-ID (or an equivalent) response header. It is empty if the service did not
+// report one.
+func (e ErrUnexpectedResponseCode) RequestID() string {
+	return requestIDFromHeader(e.ResponseHeader)
+}
+
+// RequestIDs returns all the distinct request IDs reported in the response headers.
+func (e ErrUnexpectedResponseCode) RequestIDs() []string {
+	return requestIDsFromHeader(e.ResponseHeader)
+}
+
+// Fault decodes the response body with ParseFault. The second return value is
+// false if the body is not in the error format of any known service.
+func (e ErrUnexpectedResponseCode) Fault() (Fault, bool) {
+	return ParseFault(e.Body)
+}
+
+// Is reports whether the error matches one of the sentinel errors ErrNotFound,
+// ErrConflict, ErrForbidden, ErrQuotaExceeded or ErrOverLimit.
+func (e ErrUnexpectedResponseCode) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Actual == http.StatusNotFound
+	case ErrConflict:
+		return e.Actual == http.StatusConflict
+	case ErrForbidden:
+		return e.Actual == http.StatusForbidden
+	case ErrOverLimit:
+		return e.Actual == http.StatusTooManyRequests || e.Actual == 498
+	case ErrQuotaExceeded:
+		switch e.Actual {
+		case http.StatusForbidden, http.StatusConflict, http.StatusRequestEntityTooLarge:
+			f, ok := e.Fault()
+			return ok && isQuotaFault(f)
+		}
+	}
+	return false
+}
+
 // ResponseCodeIs returns true if this error is or contains an ErrUnexpectedResponseCode reporting
 // that the request failed with the given response code. For example, this checks if a request
 // failed because of a 404 error:
@@ -153,6 +225,11 @@ func (e ErrErrorAfterReauthentication) Error() string {
 	return e.choseErrString()
 }
 
+// Unwrap returns the error that occurred after reauthentication.
+func (e ErrErrorAfterReauthentication) Unwrap() error {
+	return e.ErrOriginal
+}
+
 // ErrServiceNotFound is returned when no service in a service catalog matches
 // the provided EndpointOpts. This is generally returned by provider service
 // factory methods like "NewComputeV2()" and can mean that a service is not
@@ -0,0 +1,191 @@
+package gophercloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Fault is the structured content of the body of an OpenStack error
+// response. It is obtained with ErrUnexpectedResponseCode.Fault.
+type Fault struct {
+	// Type identifies the kind of fault, e.g. "itemNotFound" (Nova, Cinder,
+	// Manila), "PortNotFound" (Neutron), "Client" (Ironic, Octavia) or
+	// "Not Found" (Keystone).
+	Type string
+
+	// Code is the error code reported in the body. Most services report the
+	// HTTP status code, but some (e.g. Placement) report a service-specific
+	// code instead.
+	Code string
+
+	// Message is the human-readable description of the error.
+	Message string
+
+	// Details contains additional information about the error, if the
+	// service provided any.
+	Details string
+}
+
+// ParseFault decodes the body of an OpenStack error response. It recognizes
+// the error envelopes of the following services:
+//
+//	Nova, Cinder, Manila:  {"itemNotFound": {"message": "...", "code": 404}}
+//	Neutron:               {"NeutronError": {"type": "...", "message": "...", "detail": "..."}}
+//	Ironic:                {"error_message": "{\"faultstring\": \"...\", \"faultcode\": \"...\"}"}
+//	Keystone, Heat:        {"error": {"code": 404, "title": "...", "message": "..."}}
+//	Octavia:               {"faultcode": "...", "faultstring": "...", "debuginfo": "..."}
+//	Placement (API-SIG):   {"errors": [{"status": 404, "title": "...", "detail": "...", "code": "..."}]}
+//	Designate, Barbican:   {"code": 404, "type": "...", "message": "..."}
+//
+// The second return value is false if the body is not in any of these
+// formats.
+func ParseFault(body []byte) (Fault, bool) {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return Fault{}, false
+	}
+
+	if raw, ok := envelope["NeutronError"]; ok {
+		var s struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+			Detail  string `json:"detail"`
+		}
+		if json.Unmarshal(raw, &s) == nil {
+			return Fault{Type: s.Type, Message: s.Message, Details: s.Detail}, true
+		}
+	}
+
+	if raw, ok := envelope["error_message"]; ok {
+		// Ironic double-encodes the error as a JSON string
+		var inner string
+		if json.Unmarshal(raw, &inner) == nil {
+			raw = json.RawMessage(inner)
+		}
+		if f, ok := parseWSMEFault(raw); ok {
+			return f, true
+		}
+		if inner != "" {
+			return Fault{Message: inner}, true
+		}
+	}
+
+	if raw, ok := envelope["errors"]; ok {
+		var s []struct {
+			Status json.RawMessage `json:"status"`
+			Title  string          `json:"title"`
+			Detail string          `json:"detail"`
+			Code   string          `json:"code"`
+		}
+		if json.Unmarshal(raw, &s) == nil && len(s) > 0 {
+			f := Fault{Type: s[0].Title, Code: s[0].Code, Message: s[0].Detail}
+			if f.Code == "" {
+				f.Code = rawString(s[0].Status)
+			}
+			if f.Message == "" {
+				f.Message = s[0].Title
+			}
+			return f, true
+		}
+	}
+
+	if raw, ok := envelope["error"]; ok {
+		var s struct {
+			Code      json.RawMessage `json:"code"`
+			Title     string          `json:"title"`
+			Type      string          `json:"type"`
+			Message   string          `json:"message"`
+			Traceback string          `json:"traceback"`
+		}
+		if json.Unmarshal(raw, &s) == nil && s.Message != "" {
+			f := Fault{Type: s.Type, Code: rawString(s.Code), Message: s.Message, Details: s.Traceback}
+			if f.Type == "" {
+				f.Type = s.Title
+			}
+			if f.Code == "" {
+				f.Code = rawString(envelope["code"])
+			}
+			return f, true
+		}
+	}
+
+	if _, ok := envelope["faultstring"]; ok {
+		if f, ok := parseWSMEFault(body); ok {
+			return f, true
+		}
+	}
+
+	if _, ok := envelope["message"]; ok || envelope["description"] != nil {
+		var s struct {
+			Code        json.RawMessage `json:"code"`
+			Type        string          `json:"type"`
+			Title       string          `json:"title"`
+			Message     string          `json:"message"`
+			Description string          `json:"description"`
+		}
+		if json.Unmarshal(body, &s) == nil {
+			f := Fault{Type: s.Type, Code: rawString(s.Code), Message: s.Message}
+			if f.Type == "" {
+				f.Type = s.Title
+			}
+			if f.Message == "" {
+				f.Message = s.Description
+			}
+			return f, true
+		}
+	}
+
+	// Nova, Cinder and Manila wrap the error in an object named after the
+	// fault type.
+	if len(envelope) == 1 {
+		for k, raw := range envelope {
+			var s struct {
+				Code    json.RawMessage `json:"code"`
+				Message string          `json:"message"`
+				Details string          `json:"details"`
+			}
+			if json.Unmarshal(raw, &s) == nil && s.Message != "" {
+				return Fault{Type: k, Code: rawString(s.Code), Message: s.Message, Details: s.Details}, true
+			}
+		}
+	}
+
+	return Fault{}, false
+}
+
+// parseWSMEFault parses the error format of services built with WSME, such
+// as Ironic and Octavia.
+func parseWSMEFault(raw []byte) (Fault, bool) {
+	var s struct {
+		FaultCode   string          `json:"faultcode"`
+		FaultString string          `json:"faultstring"`
+		DebugInfo   json.RawMessage `json:"debuginfo"`
+	}
+	if json.Unmarshal(raw, &s) != nil || s.FaultString == "" {
+		return Fault{}, false
+	}
+	return Fault{Type: s.FaultCode, Message: s.FaultString, Details: rawString(s.DebugInfo)}, true
+}
+
+// rawString renders a JSON scalar (string, number or null) as a string.
+func rawString(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// isQuotaFault reports whether the fault describes an exhausted quota.
+func isQuotaFault(f Fault) bool {
+	t := strings.ToLower(f.Type)
+	return strings.Contains(t, "quota") ||
+		strings.Contains(t, "overlimit") ||
+		strings.Contains(t, "limitexceeded") ||
+		strings.Contains(strings.ToLower(f.Message), "quota")
+}
@@ -0,0 +1,128 @@
+// Package redact masks the secrets of OpenStack API requests and responses,
+// so that they can be logged or recorded.
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// sensitiveHeaders lists the (canonicalized) headers whose values are
+// secret.
+var sensitiveHeaders = map[string]bool{
+	"X-Auth-Token":                    true,
+	"X-Subject-Token":                 true,
+	"X-Service-Token":                 true,
+	"Openstack-Auth-Receipt":          true,
+	"Authorization":                   true,
+	"Set-Cookie":                      true,
+	"Cookie":                          true,
+	"X-Account-Meta-Temp-Url-Key":     true,
+	"X-Account-Meta-Temp-Url-Key-2":   true,
+	"X-Container-Meta-Temp-Url-Key":   true,
+	"X-Container-Meta-Temp-Url-Key-2": true,
+}
+
+// sensitiveFields lists the JSON object keys whose values are secret. This
+// covers passwords, TOTP passcodes, application credential secrets, EC2
+// style secrets, Barbican secret payloads and the tokens of OpenID Connect
+// identity providers.
+var sensitiveFields = map[string]bool{
+	"password":      true,
+	"passcode":      true,
+	"secret":        true,
+	"payload":       true,
+	"adminPass":     true,
+	"admin_pass":    true,
+	"apiKey":        true,
+	"access_key":    true,
+	"access_token":  true,
+	"id_token":      true,
+	"refresh_token": true,
+}
+
+// sensitiveQueryParameters lists the query parameters whose values are
+// secret.
+var sensitiveQueryParameters = []string{"temp_url_sig"}
+
+// Header returns a copy of h where the values of the sensitive headers are
+// replaced with mask.
+func Header(h http.Header, mask string) http.Header {
+	redacted := make(http.Header, len(h))
+	for k, v := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] {
+			redacted[k] = []string{mask}
+		} else {
+			redacted[k] = v
+		}
+	}
+	return redacted
+}
+
+// URL returns u, or a copy of u where the values of the sensitive query
+// parameters are replaced with mask.
+func URL(u *url.URL, mask string) *url.URL {
+	q := u.Query()
+	changed := false
+	for _, p := range sensitiveQueryParameters {
+		if q.Has(p) {
+			q.Set(p, mask)
+			changed = true
+		}
+	}
+	if !changed {
+		return u
+	}
+
+	redacted := *u
+	redacted.RawQuery = q.Encode()
+	return &redacted
+}
+
+// Body returns a request or response body of the given URL where the values
+// of the sensitive fields of JSON bodies are replaced with mask. Barbican
+// secret payloads are replaced entirely, and other bodies are returned
+// unchanged.
+func Body(u *url.URL, body []byte, mask string) []byte {
+	if strings.HasSuffix(strings.TrimSuffix(u.Path, "/"), "/payload") {
+		return []byte(mask)
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	rendered, err := json.Marshal(redactJSON(v, "", mask))
+	if err != nil {
+		return body
+	}
+	return rendered
+}
+
+func redactJSON(v any, parent, mask string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			_, isObject := val.(map[string]any)
+			switch {
+			case sensitiveFields[k] && !isObject:
+				// e.g. the "password" authentication method object is not
+				// secret by itself, only the password it contains
+				v[k] = mask
+			case parent == "token" && k == "id":
+				// token ID in a Keystone token authentication request, or in
+				// an Identity v2 token
+				v[k] = mask
+			default:
+				v[k] = redactJSON(val, k, mask)
+			}
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = redactJSON(val, parent, mask)
+		}
+	}
+	return v
+}
@@ -0,0 +1,2 @@
+// redact unit tests
+package testing
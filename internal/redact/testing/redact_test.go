@@ -0,0 +1,51 @@
+package testing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/internal/redact"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+func TestHeader(t *testing.T) {
+	h := http.Header{
+		"X-Auth-Token": {"secret-token"},
+		"Cookie":       {"session=secret"},
+		"Content-Type": {"application/json"},
+	}
+	th.AssertDeepEquals(t, http.Header{
+		"X-Auth-Token": {"***"},
+		"Cookie":       {"***"},
+		"Content-Type": {"application/json"},
+	}, redact.Header(h, "***"))
+
+	// the original header is unchanged
+	th.AssertEquals(t, "secret-token", h.Get("X-Auth-Token"))
+}
+
+func TestURL(t *testing.T) {
+	u, err := url.Parse("https://swift.example.com/v1/AUTH_a/c/o?temp_url_sig=abc&temp_url_expires=1")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "https://swift.example.com/v1/AUTH_a/c/o?temp_url_expires=1&temp_url_sig=%2A%2A%2A", redact.URL(u, "***").String())
+
+	u, err = url.Parse("https://compute.example.com/servers?name=foo")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, u, redact.URL(u, "***"))
+}
+
+func TestBody(t *testing.T) {
+	u, err := url.Parse("https://identity.example.com/v3/auth/tokens")
+	th.AssertNoErr(t, err)
+
+	body := redact.Body(u, []byte(`{"auth": {"identity": {"methods": ["password", "token"], "password": {"user": {"name": "alice", "password": "s3cr3t"}}, "token": {"id": "abc"}}}}`), "***")
+	th.AssertJSONEquals(t, `{"auth": {"identity": {"methods": ["password", "token"], "password": {"user": {"name": "alice", "password": "***"}}, "token": {"id": "***"}}}}`, json.RawMessage(body))
+
+	th.AssertEquals(t, "not json", string(redact.Body(u, []byte("not json"), "***")))
+
+	u, err = url.Parse("https://key-manager.example.com/v1/secrets/1/payload")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "***", string(redact.Body(u, []byte("plaintext"), "***")))
+}
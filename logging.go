@@ -0,0 +1,107 @@
+package gophercloud
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/internal/redact"
+)
+
+// redacted replaces secrets in logged requests and responses.
+const redacted = "***"
+
+// maxLoggedBodySize is the number of bytes of a request or response body that
+// are logged at most.
+const maxLoggedBodySize = 8192
+
+// logRequest logs a single HTTP round trip performed by doRequest. The
+// request body is the rendered JSONBody, if any. When the logger is enabled
+// at debug level and readBody is true, the response body is buffered so that
+// it can be logged, and replaced with an in-memory reader.
+func (client *ProviderClient) logRequest(ctx context.Context, req *http.Request, reqBody []byte, resp *http.Response, err error, duration time.Duration, retries uint, readBody bool) error {
+	logger := client.Logger
+	if logger == nil {
+		return nil
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", redactURL(req.URL)),
+		slog.Duration("duration", duration),
+	}
+	if mv := microversionFromHeader(req.Header); mv != "" {
+		attrs = append(attrs, slog.String("microversion", mv))
+	}
+	if retries > 0 {
+		attrs = append(attrs, slog.Uint64("retries", uint64(retries)))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		logger.LogAttrs(ctx, slog.LevelWarn, "OpenStack request failed", attrs...)
+		return nil
+	}
+
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	if id := requestIDFromHeader(resp.Header); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+
+	if logger.Enabled(ctx, slog.LevelDebug) {
+		attrs = append(attrs, slog.Any("request_headers", redactHeader(req.Header)))
+		if reqBody != nil {
+			attrs = append(attrs, slog.String("request_body", redactBody(req.URL, reqBody)))
+		}
+		attrs = append(attrs, slog.Any("response_headers", redactHeader(resp.Header)))
+		if readBody && resp.Body != nil {
+			respBody, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return err
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(respBody))
+			attrs = append(attrs, slog.String("response_body", redactBody(req.URL, respBody)))
+		}
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "OpenStack request", attrs...)
+	return nil
+}
+
+// microversionFromHeader returns the microversion requested through the
+// request headers, if any.
+func microversionFromHeader(h http.Header) string {
+	if v := h.Get("OpenStack-API-Version"); v != "" {
+		return v
+	}
+	return h.Get("X-OpenStack-Nova-API-Version")
+}
+
+func redactURL(u *url.URL) string {
+	return redact.URL(u, redacted).Redacted()
+}
+
+func redactHeader(h http.Header) map[string]string {
+	m := make(map[string]string, len(h))
+	for k, v := range redact.Header(h, redacted) {
+		m[k] = strings.Join(v, ", ")
+	}
+	return m
+}
+
+// redactBody renders a request or response body for logging. Values of
+// sensitive fields in JSON bodies are redacted, and Barbican secret payloads
+// are never logged.
+func redactBody(u *url.URL, body []byte) string {
+	body = redact.Body(u, body, redacted)
+	if len(body) > maxLoggedBodySize {
+		return string(body[:maxLoggedBodySize]) + "..."
+	}
+	return string(body)
+}
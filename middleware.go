@@ -0,0 +1,68 @@
+package gophercloud
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestInfo describes a single HTTP request attempt issued by a
+// ProviderClient. It is handed to every Middleware alongside the request.
+type RequestInfo struct {
+	// ServiceType is the type of the ServiceClient that issued the request
+	// (e.g. "compute", "network"). It is empty for requests issued directly
+	// through ProviderClient.Request.
+	ServiceType string
+
+	// Microversion is the microversion requested by the ServiceClient, if any.
+	Microversion string
+
+	// Retries is the number of times this request was retried before this
+	// attempt, either by RetryBackoffFunc or by RetryFunc.
+	Retries uint
+
+	// Reauthenticated is true if this attempt is a replay of a request that
+	// failed with a 401 response and triggered a reauthentication.
+	Reauthenticated bool
+
+	// Reauthentication is true if this request is part of a
+	// reauthentication, i.e. it was issued by ReauthFunc through a throwaway
+	// copy of the ProviderClient.
+	Reauthentication bool
+
+	// RateLimitWait is the time this attempt waited for the rate limiters
+	// of the ProviderClient.
+	RateLimitWait time.Duration
+}
+
+// RoundTripFunc performs a single HTTP request attempt.
+type RoundTripFunc func(req *http.Request, info RequestInfo) (*http.Response, error)
+
+// Middleware wraps the function that performs HTTP request attempts. It can
+// inspect or modify the request before passing it on to next, and inspect the
+// response or error afterwards. This is useful to create tracing spans or
+// record metrics, for example:
+//
+//	provider.Middlewares = append(provider.Middlewares, func(next gophercloud.RoundTripFunc) gophercloud.RoundTripFunc {
+//		return func(req *http.Request, info gophercloud.RequestInfo) (*http.Response, error) {
+//			start := time.Now()
+//			resp, err := next(req, info)
+//			observeRequest(info.ServiceType, req.Method, resp, err, time.Since(start))
+//			return resp, err
+//		}
+//	})
+//
+// A Middleware is called for every attempt, including the ones issued
+// internally for backoff retries and reauthentication.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// roundTrip sends the request through the client's middlewares, and finally
+// through its HTTPClient.
+func (client *ProviderClient) roundTrip(req *http.Request, info RequestInfo) (*http.Response, error) {
+	next := RoundTripFunc(func(req *http.Request, _ RequestInfo) (*http.Response, error) {
+		return client.HTTPClient.Do(req)
+	})
+	for i := len(client.Middlewares) - 1; i >= 0; i-- {
+		next = client.Middlewares[i](next)
+	}
+	return next(req, info)
+}
@@ -6,20 +6,49 @@ import (
 	"github.com/gophercloud/gophercloud/v2"
 )
 
+// failedProvisionStates are the provision states in which a node ends up
+// when a provisioning operation fails.
+var failedProvisionStates = []string{
+	string(DeployFail),
+	string(CleanFail),
+	string(InspectFail),
+	string(AdoptFail),
+	string(RescueFail),
+	string(UnrescueFail),
+	string(ServiceFail),
+	string(Error),
+}
+
 // WaitForProvisionState will continually poll a node until it successfully
-// transitions to a specified state. It will do this for at most the number
-// of seconds specified.
+// transitions to a specified state. It fails with an ErrWaitFailed if the node
+// goes into a failed state, unless that state is the expected one.
 func WaitForProvisionState(ctx context.Context, c *gophercloud.ServiceClient, id string, state ProvisionState) error {
-	return gophercloud.WaitFor(ctx, func(ctx context.Context) (bool, error) {
+	waiter := gophercloud.Waiter{
+		Multiplier: 1,
+		Jitter:     -1,
+		Target:     []string{string(state)},
+		Failure:    failedProvisionStates,
+	}
+	return waiter.WaitForState(ctx, provisionStateRefreshFunc(c, id))
+}
+
+// WaitForDeleted will continually poll a node until it is deleted.
+func WaitForDeleted(ctx context.Context, c *gophercloud.ServiceClient, id string) error {
+	waiter := gophercloud.Waiter{
+		Multiplier: 1,
+		Jitter:     -1,
+		Failure:    []string{string(Error)},
+		Deleted:    true,
+	}
+	return waiter.WaitForState(ctx, provisionStateRefreshFunc(c, id))
+}
+
+func provisionStateRefreshFunc(c *gophercloud.ServiceClient, id string) gophercloud.StateRefreshFunc {
+	return func(ctx context.Context) (string, error) {
 		current, err := Get(ctx, c, id).Extract()
 		if err != nil {
-			return false, err
-		}
-
-		if current.ProvisionState == string(state) {
-			return true, nil
+			return "", err
 		}
-
-		return false, nil
-	})
+		return current.ProvisionState, nil
+	}
 }
@@ -6,18 +6,37 @@ import (
 	"github.com/gophercloud/gophercloud/v2"
 )
 
-// WaitForStatus will continually poll the resource, checking for a particular status.
+// WaitForStatus will continually poll the resource, checking for a particular
+// status. It fails with an ErrWaitFailed if the volume goes into an error
+// status.
 func WaitForStatus(ctx context.Context, c *gophercloud.ServiceClient, id, status string) error {
-	return gophercloud.WaitFor(ctx, func(ctx context.Context) (bool, error) {
+	waiter := gophercloud.Waiter{
+		Multiplier: 1,
+		Jitter:     -1,
+		Target:     []string{status},
+		Failure:    []string{"error", "error_restoring", "error_extending", "error_managing"},
+	}
+	return waiter.WaitForState(ctx, statusRefreshFunc(c, id))
+}
+
+// WaitForDeleted will continually poll the resource until it is deleted. It
+// fails with an ErrWaitFailed if the deletion of the volume fails.
+func WaitForDeleted(ctx context.Context, c *gophercloud.ServiceClient, id string) error {
+	waiter := gophercloud.Waiter{
+		Multiplier: 1,
+		Jitter:     -1,
+		Failure:    []string{"error", "error_deleting"},
+		Deleted:    true,
+	}
+	return waiter.WaitForState(ctx, statusRefreshFunc(c, id))
+}
+
+func statusRefreshFunc(c *gophercloud.ServiceClient, id string) gophercloud.StateRefreshFunc {
+	return func(ctx context.Context) (string, error) {
 		current, err := Get(ctx, c, id).Extract()
 		if err != nil {
-			return false, err
+			return "", err
 		}
-
-		if current.Status == status {
-			return true, nil
-		}
-
-		return false, nil
-	})
+		return current.Status, nil
+	}
 }
@@ -4,6 +4,13 @@ OpenStack Block Storage service. A volume is a detachable block storage
 device, akin to a USB hard drive. It can only be attached to one instance at
 a time.
 
+Example of Finding a Volume by Name or ID
+
+	volume, err := volumes.Find(context.TODO(), client, "data")
+	if err != nil {
+		panic(err)
+	}
+
 Example of creating Volume B on a Different Host than Volume A
 
 	schedulerHintOpts := volumes.SchedulerHintCreateOpts{
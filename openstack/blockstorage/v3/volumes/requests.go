@@ -209,6 +209,17 @@ func Get(ctx context.Context, client *gophercloud.ServiceClient, id string) (r G
 	return
 }
 
+// Find returns the volume with the given ID or name. When no volume has the
+// ID, the name must match a single volume; gophercloud.ErrResourceNotFound or
+// gophercloud.ErrMultipleResourcesFound is returned otherwise.
+func Find(ctx context.Context, client *gophercloud.ServiceClient, nameOrID string) (*Volume, error) {
+	return pagination.FindByIDOrName(ctx, func(ctx context.Context) (*Volume, error) {
+		return Get(ctx, client, nameOrID).Extract()
+	}, List(client, ListOpts{Name: nameOrID}), ExtractVolumes, "volume", nameOrID, func(v Volume) bool {
+		return v.Name == nameOrID
+	})
+}
+
 // ListOptsBuilder allows extensions to add additional parameters to the List
 // request.
 type ListOptsBuilder interface {
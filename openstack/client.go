@@ -4,13 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/gophercloud/gophercloud/v2"
 	tokens2 "github.com/gophercloud/gophercloud/v2/openstack/identity/v2/tokens"
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/ec2tokens"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/k2k"
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/oauth1"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/oidc"
 	tokens3 "github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
 	"github.com/gophercloud/gophercloud/v2/openstack/utils"
 )
@@ -109,6 +114,39 @@ func Authenticate(ctx context.Context, client *gophercloud.ProviderClient, optio
 	}
 }
 
+// AuthenticatedClientK2K authenticates with a remote cloud through Keystone to
+// Keystone federation, and returns a Provider Client instance for the remote
+// cloud. The Identity v3 endpoint of the remote cloud is derived from the
+// service provider registered in the local Keystone.
+//
+// The HTTP client of the ProviderClient of the local Keystone is used for the
+// remote cloud too.
+func AuthenticatedClientK2K(ctx context.Context, options *k2k.AuthOptions) (*gophercloud.ProviderClient, error) {
+	if options.IdentityClient == nil {
+		return nil, gophercloud.ErrMissingInput{Argument: "IdentityClient"}
+	}
+	if options.ServiceProvider == "" {
+		return nil, gophercloud.ErrMissingInput{Argument: "ServiceProvider"}
+	}
+
+	sp, err := k2k.GetServiceProvider(ctx, options.IdentityClient, options.ServiceProvider).Extract()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := NewClient(sp.IdentityEndpoint())
+	if err != nil {
+		return nil, err
+	}
+	client.HTTPClient = options.IdentityClient.HTTPClient
+
+	err = AuthenticateV3(ctx, client, options, gophercloud.EndpointOpts{})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
 // AuthenticateV2 explicitly authenticates against the identity v2 endpoint.
 func AuthenticateV2(ctx context.Context, client *gophercloud.ProviderClient, options tokens2.AuthOptionsBuilder, eo gophercloud.EndpointOpts) error {
 	return v2auth(ctx, client, "", options, eo)
@@ -175,6 +213,21 @@ func AuthenticateV3(ctx context.Context, client *gophercloud.ProviderClient, opt
 }
 
 func v3auth(ctx context.Context, client *gophercloud.ProviderClient, endpoint string, opts tokens3.AuthOptionsBuilder, eo gophercloud.EndpointOpts) error {
+	// the token store and the reauthentication use the original options
+	reauthOpts := opts
+	var store gophercloud.TokenStore
+	var storeKey gophercloud.TokenStoreKey
+	if ao, ok := opts.(*gophercloud.AuthOptions); ok {
+		if ao.TokenStore != nil {
+			if key, identified := tokenStoreKey(endpoint, ao, eo); identified {
+				store, storeKey = ao.TokenStore, key
+			}
+		}
+		if ao.OIDC != nil {
+			opts = oidcAuthOptions(ao)
+		}
+	}
+
 	// Override the generated service endpoint with the one returned by the version endpoint.
 	v3Client, err := NewIdentityV3(ctx, client, eo)
 	if err != nil {
@@ -222,13 +275,29 @@ func v3auth(ctx context.Context, client *gophercloud.ProviderClient, endpoint st
 		}
 	} else {
 		var result tokens3.CreateResult
-		switch opts.(type) {
-		case *ec2tokens.AuthOptions:
-			result = ec2tokens.Create(ctx, v3Client, opts)
-		case *oauth1.AuthOptions:
-			result = oauth1.Create(ctx, v3Client, opts)
-		default:
-			result = tokens3.Create(ctx, v3Client, opts)
+		var stored bool
+		// when reauthenticating, the current token was rejected: create a new one
+		if store != nil && !client.IsThrowaway() {
+			result, stored = loadStoredToken(ctx, client, store, storeKey)
+		}
+
+		if !stored {
+			switch o := opts.(type) {
+			case *ec2tokens.AuthOptions:
+				result = ec2tokens.Create(ctx, v3Client, o)
+			case *oauth1.AuthOptions:
+				result = oauth1.Create(ctx, v3Client, o)
+			case *oidc.AuthOptions:
+				result = oidc.Create(ctx, v3Client, o)
+			case *k2k.AuthOptions:
+				result = k2k.Create(ctx, v3Client, o)
+			default:
+				result = tokens3.Create(ctx, v3Client, opts)
+				result = completeAuthReceipt(ctx, v3Client, opts, result)
+			}
+			if store != nil && result.Err == nil {
+				saveStoredToken(ctx, client, store, storeKey, result)
+			}
 		}
 
 		err = client.SetTokenAndAuthResult(result)
@@ -254,7 +323,7 @@ func v3auth(ctx context.Context, client *gophercloud.ProviderClient, endpoint st
 			return err
 		}
 		var tao tokens3.AuthOptionsBuilder
-		switch ot := opts.(type) {
+		switch ot := reauthOpts.(type) {
 		case *gophercloud.AuthOptions:
 			o := *ot
 			o.AllowReauth = false
@@ -271,8 +340,16 @@ func v3auth(ctx context.Context, client *gophercloud.ProviderClient, endpoint st
 			o := *ot
 			o.AllowReauth = false
 			tao = &o
+		case *oidc.AuthOptions:
+			o := *ot
+			o.AllowReauth = false
+			tao = &o
+		case *k2k.AuthOptions:
+			o := *ot
+			o.AllowReauth = false
+			tao = &o
 		default:
-			tao = opts
+			tao = reauthOpts
 		}
 		client.ReauthFunc = func(ctx context.Context) error {
 			err := v3auth(ctx, &tac, endpoint, tao, eo)
@@ -290,6 +367,73 @@ func v3auth(ctx context.Context, client *gophercloud.ProviderClient, endpoint st
 	return nil
 }
 
+// completeAuthReceipt completes an authentication that Keystone answered
+// with an auth receipt requiring the TOTP method, with the passcode returned
+// by the PasscodeFunc of the options. Other results are returned unchanged.
+func completeAuthReceipt(ctx context.Context, client *gophercloud.ServiceClient, opts tokens3.AuthOptionsBuilder, result tokens3.CreateResult) tokens3.CreateResult {
+	var receiptErr gophercloud.ErrAuthReceiptRequired
+	if !errors.As(result.Err, &receiptErr) {
+		return result
+	}
+	receipt := receiptErr.Receipt
+	if !slices.ContainsFunc(receipt.RemainingMethods(), func(methods []string) bool {
+		return slices.Equal(methods, []string{"totp"})
+	}) {
+		return result
+	}
+
+	var passcodeFunc gophercloud.PasscodeFunc
+	switch o := opts.(type) {
+	case *gophercloud.AuthOptions:
+		passcodeFunc = o.PasscodeFunc
+	case *tokens3.AuthOptions:
+		passcodeFunc = o.PasscodeFunc
+	}
+	if passcodeFunc == nil {
+		return result
+	}
+
+	passcode, err := passcodeFunc(ctx, receipt)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// the methods that succeeded are carried by the receipt
+	var next tokens3.AuthOptionsBuilder
+	switch o := opts.(type) {
+	case *gophercloud.AuthOptions:
+		n := *o
+		n.Password, n.Passcode, n.AuthReceipt = "", passcode, receipt.ID
+		next = &n
+	case *tokens3.AuthOptions:
+		n := *o
+		n.Password, n.Passcode, n.AuthReceipt = "", passcode, receipt.ID
+		next = &n
+	}
+	return tokens3.Create(ctx, client, next)
+}
+
+// oidcAuthOptions converts AuthOptions with OIDC settings to the options of
+// the federated authentication, scoped like the AuthOptions.
+func oidcAuthOptions(ao *gophercloud.AuthOptions) *oidc.AuthOptions {
+	var scope tokens3.Scope
+	switch {
+	case ao.Scope != nil:
+		scope = tokens3.Scope(*ao.Scope)
+	case ao.TenantID != "":
+		scope = tokens3.Scope{ProjectID: ao.TenantID}
+	case ao.TenantName != "":
+		scope = tokens3.Scope{ProjectName: ao.TenantName, DomainID: ao.DomainID, DomainName: ao.DomainName}
+	}
+
+	return &oidc.AuthOptions{
+		OIDCOptions: *ao.OIDC,
+		Scope:       scope,
+		AllowReauth: ao.AllowReauth,
+	}
+}
+
 // NewIdentityV2 creates a ServiceClient that may be used to interact with the
 // v2 identity service.
 func NewIdentityV2(ctx context.Context, client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
@@ -298,7 +442,7 @@ func NewIdentityV2(ctx context.Context, client *gophercloud.ProviderClient, eo g
 	var err error
 	if !reflect.DeepEqual(eo, gophercloud.EndpointOpts{}) {
 		eo.ApplyDefaults(clientType)
-		endpoint, err = client.EndpointLocator(ctx, eo)
+		endpoint, err = locateEndpoint(ctx, client, eo)
 		if err != nil {
 			return nil, err
 		}
@@ -319,7 +463,7 @@ func NewIdentityV3(ctx context.Context, client *gophercloud.ProviderClient, eo g
 	var err error
 	if !reflect.DeepEqual(eo, gophercloud.EndpointOpts{}) {
 		eo.ApplyDefaults(clientType)
-		endpoint, err = client.EndpointLocator(ctx, eo)
+		endpoint, err = locateEndpoint(ctx, client, eo)
 		if err != nil {
 			return nil, err
 		}
@@ -355,7 +499,7 @@ func initClientOpts(ctx context.Context, client *gophercloud.ProviderClient, eo
 	}
 	eo.Version = version
 
-	url, err := client.EndpointLocator(ctx, eo)
+	url, err := locateEndpoint(ctx, client, eo)
 	if err != nil {
 		return sc, err
 	}
@@ -363,9 +507,151 @@ func initClientOpts(ctx context.Context, client *gophercloud.ProviderClient, eo
 	sc.ProviderClient = client
 	sc.Endpoint = url
 	sc.Type = clientType
+	sc.Microversion = apiMicroversion(eo.APIVersion(), version)
+	return sc, nil
+}
+
+// locateEndpoint returns the endpoint override of the service if one is set,
+// and locates the endpoint of the service in the catalog otherwise.
+func locateEndpoint(ctx context.Context, client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (string, error) {
+	if override := eo.EndpointOverride(); override != "" {
+		return gophercloud.NormalizeURL(override), nil
+	}
+	return client.EndpointLocator(ctx, eo)
+}
+
+// apiMicroversion returns the microversion of an API version such as "2.79"
+// if its major version is the given one, or an empty string if the API
+// version is a major version or is for another major version.
+func apiMicroversion(apiVersion string, version int) string {
+	major, minor, _ := strings.Cut(strings.TrimPrefix(apiVersion, "v"), ".")
+	if major != strconv.Itoa(version) || minor == "" || minor == "0" {
+		return ""
+	}
+	return major + "." + minor
+}
+
+// resourceBasePaths are the paths of the versioned APIs of the services
+// whose catalog endpoints are usually unversioned, by major version. They
+// match the ResourceBase set by the dedicated constructors.
+var resourceBasePaths = map[string]map[int]string{
+	"baremetal":     {1: "v1/"},
+	"dns":           {2: "v2/"},
+	"identity":      {2: "v2.0/", 3: "v3/"},
+	"image":         {2: "v2/"},
+	"key-manager":   {1: "v1/"},
+	"load-balancer": {2: "v2.0/"},
+	"network":       {2: "v2.0/"},
+}
+
+/*
+NewServiceClient creates a ServiceClient for any service type, including the
+services that have no dedicated constructor in this package.
+
+The service type may be the official name of the service type or one of its
+aliases, as defined by the OpenStack Service Types Authority: the endpoint is
+looked up in the catalog under all of them. The major version of the API is
+the Version of the EndpointOpts, or the major version of the API version set
+for the service in APIVersions, or else the current version reported by the
+version discovery document of the service. The microversion of the client is
+set from APIVersions, like the dedicated constructors do.
+
+When the endpoint of the service is unversioned, the ResourceBase of the
+client points to the versioned API: the path used by the dedicated
+constructor for the services of this package, the endpoint itself for the
+other services of this package, and the path of the major version (e.g.
+"v1/") for the services unknown to this package.
+
+Example to create a client of a service without a dedicated constructor
+
+	client, err := openstack.NewServiceClient(context.TODO(), provider, "accelerator", gophercloud.EndpointOpts{
+		Region: "RegionOne",
+	})
+*/
+func NewServiceClient(ctx context.Context, client *gophercloud.ProviderClient, serviceType string, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
+	eo.ApplyDefaults(serviceType)
+	if eo.Version == 0 {
+		if major, _, err := utils.ParseVersion(eo.APIVersion()); err == nil {
+			eo.Version = major
+		}
+	}
+
+	url, err := locateEndpoint(ctx, client, eo)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := discoverVersion(ctx, client, eo.Type, url, eo.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &gophercloud.ServiceClient{
+		ProviderClient: client,
+		Endpoint:       url,
+		Type:           eo.Type,
+		Microversion:   apiMicroversion(eo.APIVersion(), version),
+	}
+	if base, err := utils.BaseEndpoint(url); err == nil && gophercloud.NormalizeURL(base) == url {
+		sc.ResourceBase = versionedResourceBase(eo.Type, url, version)
+	}
 	return sc, nil
 }
 
+// discoverVersion returns the major version of the API of a service: the
+// requested version if the service supports it, or the current version of
+// the service if none is requested. The requested version is trusted when
+// the service has no version discovery document (a 404 response, or no
+// versions). The other errors of the discovery are returned.
+func discoverVersion(ctx context.Context, client *gophercloud.ProviderClient, serviceType, endpoint string, version int) (int, error) {
+	versionedEndpoint, err := utils.BaseVersionedEndpoint(endpoint)
+	if err != nil {
+		return 0, err
+	}
+
+	supportedVersions, err := utils.GetServiceVersions(ctx, client, versionedEndpoint, false)
+	if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return 0, err
+	}
+	if len(supportedVersions) == 0 {
+		return version, nil
+	}
+
+	if version != 0 {
+		for _, supportedVersion := range supportedVersions {
+			if supportedVersion.Major == version {
+				return version, nil
+			}
+		}
+		return 0, fmt.Errorf("the %s service at %s does not support version %d", serviceType, endpoint, version)
+	}
+
+	// the versions are sorted from the most recent one
+	for _, supportedVersion := range supportedVersions {
+		if supportedVersion.Status == utils.StatusCurrent {
+			return supportedVersion.Major, nil
+		}
+	}
+	return supportedVersions[0].Major, nil
+}
+
+// versionedResourceBase returns the ResourceBase of a client of a service
+// with an unversioned endpoint.
+func versionedResourceBase(serviceType, endpoint string, version int) string {
+	if path, ok := resourceBasePaths[serviceType][version]; ok {
+		return endpoint + path
+	}
+	if _, ok := resourceBasePaths[serviceType]; ok || version == 0 {
+		return ""
+	}
+	if _, ok := gophercloud.ServiceTypeAliases[serviceType]; ok {
+		// like placement, the other services of this package serve their
+		// API at the root of their endpoint
+		return ""
+	}
+	return endpoint + "v" + strconv.Itoa(version) + "/"
+}
+
 // NewBareMetalV1 creates a ServiceClient that may be used with the v1
 // bare metal package.
 func NewBareMetalV1(ctx context.Context, client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
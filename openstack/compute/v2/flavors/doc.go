@@ -26,6 +26,13 @@ Example to List Flavors
 		fmt.Printf("%+v\n", flavor)
 	}
 
+Example to Find a Flavor by Name or ID
+
+	flavor, err := flavors.Find(context.TODO(), computeClient, "m1.small")
+	if err != nil {
+		panic(err)
+	}
+
 Example to Create a Flavor
 
 	createOpts := flavors.CreateOpts{
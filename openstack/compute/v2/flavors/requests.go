@@ -195,6 +195,19 @@ func Get(ctx context.Context, client *gophercloud.ServiceClient, id string) (r G
 	return
 }
 
+// Find returns the flavor with the given ID or name. When no flavor has the
+// ID, the name must match a single flavor; gophercloud.ErrResourceNotFound or
+// gophercloud.ErrMultipleResourcesFound is returned otherwise.
+func Find(ctx context.Context, client *gophercloud.ServiceClient, nameOrID string) (*Flavor, error) {
+	// the flavors cannot be filtered by name, and only the administrators
+	// see the private flavors of other projects
+	return pagination.FindByIDOrName(ctx, func(ctx context.Context) (*Flavor, error) {
+		return Get(ctx, client, nameOrID).Extract()
+	}, ListDetail(client, ListOpts{AccessType: AllAccess}), ExtractFlavors, "flavor", nameOrID, func(f Flavor) bool {
+		return f.Name == nameOrID
+	})
+}
+
 // Delete deletes the specified flavor ID.
 func Delete(ctx context.Context, client *gophercloud.ServiceClient, id string) (r DeleteResult) {
 	resp, err := client.Delete(ctx, deleteURL(client, id), nil)
@@ -465,3 +465,26 @@ func TestFlavorExtraSpecDelete(t *testing.T) {
 	res := flavors.DeleteExtraSpec(context.TODO(), client.ServiceClient(fakeServer), "1", "hw:cpu_policy")
 	th.AssertNoErr(t, res.Err)
 }
+
+func TestFindByName(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	fakeServer.Mux.HandleFunc("/flavors/m1.private", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.WriteHeader(http.StatusNotFound)
+	})
+	fakeServer.Mux.HandleFunc("/flavors/detail", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", client.TokenID)
+		// the private flavors are listed too
+		th.TestFormValues(t, r, map[string]string{"is_public": "None"})
+
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"flavors": [{"id": "1", "name": "m1.tiny"}, {"id": "2", "name": "m1.private", "os-flavor-access:is_public": false}]}`)
+	})
+
+	actual, err := flavors.Find(context.TODO(), client.ServiceClient(fakeServer), "m1.private")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "2", actual.ID)
+	th.AssertEquals(t, false, actual.IsPublic)
+}
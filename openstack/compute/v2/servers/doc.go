@@ -25,6 +25,13 @@ Example to List Servers
 		fmt.Printf("%+v\n", server)
 	}
 
+Example to Find a Server by Name or ID
+
+	server, err := servers.Find(context.TODO(), computeClient, "web-1")
+	if err != nil {
+		panic(err)
+	}
+
 Example to List Detail Servers
 
 	listOpts := servers.ListOpts{
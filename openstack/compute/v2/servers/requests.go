@@ -635,6 +635,18 @@ func Get(ctx context.Context, client *gophercloud.ServiceClient, id string) (r G
 	return
 }
 
+// Find returns the server with the given ID or name. When no server has the
+// ID, the name must match a single server; gophercloud.ErrResourceNotFound or
+// gophercloud.ErrMultipleResourcesFound is returned otherwise.
+func Find(ctx context.Context, client *gophercloud.ServiceClient, nameOrID string) (*Server, error) {
+	// the name filter of the servers is a regular expression
+	return pagination.FindByIDOrName(ctx, func(ctx context.Context) (*Server, error) {
+		return Get(ctx, client, nameOrID).Extract()
+	}, List(client, ListOpts{Name: "^" + regexp.QuoteMeta(nameOrID) + "$"}), ExtractServers, "server", nameOrID, func(s Server) bool {
+		return s.Name == nameOrID
+	})
+}
+
 // UpdateOptsBuilder allows extensions to add additional attributes to the
 // Update request.
 type UpdateOptsBuilder interface {
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"testing"
 
+	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/internal/ptr"
 	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
 	"github.com/gophercloud/gophercloud/v2/pagination"
@@ -1197,3 +1200,60 @@ func TestUpdateServerHostname(t *testing.T) {
 
 	th.CheckDeepEquals(t, ServerDerp, *actual)
 }
+
+func TestWaitForStatus(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	HandleServerGetSuccessfully(t, fakeServer)
+
+	err := servers.WaitForStatus(context.TODO(), client.ServiceClient(fakeServer), "1234asdf", "ACTIVE")
+	th.AssertNoErr(t, err)
+}
+
+func TestWaitForStatusFailure(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	fakeServer.Mux.HandleFunc("/servers/1234asdf", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		fmt.Fprint(w, `{"server": {"id": "1234asdf", "status": "ERROR"}}`)
+	})
+
+	err := servers.WaitForStatus(context.TODO(), client.ServiceClient(fakeServer), "1234asdf", "ACTIVE")
+	var failed gophercloud.ErrWaitFailed
+	th.AssertEquals(t, true, errors.As(err, &failed))
+	th.AssertEquals(t, "ERROR", failed.State)
+}
+
+func TestWaitForDeleted(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	fakeServer.Mux.HandleFunc("/servers/1234asdf", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	err := servers.WaitForDeleted(context.TODO(), client.ServiceClient(fakeServer), "1234asdf")
+	th.AssertNoErr(t, err)
+}
+
+func TestFindByName(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	fakeServer.Mux.HandleFunc("/servers/web.1", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.WriteHeader(http.StatusNotFound)
+	})
+	fakeServer.Mux.HandleFunc("/servers/detail", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", client.TokenID)
+		// the name filter is a regular expression matching only the name
+		th.TestFormValues(t, r, map[string]string{"name": "^web\\.1$"})
+
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{"servers": [{"id": "9e5476bd-a4ec-4653-93d6-72c93aa682ba", "name": "web.1"}]}`)
+	})
+
+	actual, err := servers.Find(context.TODO(), client.ServiceClient(fakeServer), "web.1")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "9e5476bd-a4ec-4653-93d6-72c93aa682ba", actual.ID)
+}
@@ -7,18 +7,36 @@ import (
 )
 
 // WaitForStatus will continually poll a server until it successfully
-// transitions to a specified status.
+// transitions to a specified status. It fails with an ErrWaitFailed if the
+// server goes into the ERROR status.
 func WaitForStatus(ctx context.Context, c *gophercloud.ServiceClient, id, status string) error {
-	return gophercloud.WaitFor(ctx, func(ctx context.Context) (bool, error) {
+	waiter := gophercloud.Waiter{
+		Multiplier: 1,
+		Jitter:     -1,
+		Target:     []string{status},
+		Failure:    []string{"ERROR"},
+	}
+	return waiter.WaitForState(ctx, statusRefreshFunc(c, id))
+}
+
+// WaitForDeleted will continually poll a server until it is deleted. It fails
+// with an ErrWaitFailed if the server goes into the ERROR status.
+func WaitForDeleted(ctx context.Context, c *gophercloud.ServiceClient, id string) error {
+	waiter := gophercloud.Waiter{
+		Multiplier: 1,
+		Jitter:     -1,
+		Failure:    []string{"ERROR"},
+		Deleted:    true,
+	}
+	return waiter.WaitForState(ctx, statusRefreshFunc(c, id))
+}
+
+func statusRefreshFunc(c *gophercloud.ServiceClient, id string) gophercloud.StateRefreshFunc {
+	return func(ctx context.Context) (string, error) {
 		current, err := Get(ctx, c, id).Extract()
 		if err != nil {
-			return false, err
+			return "", err
 		}
-
-		if current.Status == status {
-			return true, nil
-		}
-
-		return false, nil
-	})
+		return current.Status, nil
+	}
 }
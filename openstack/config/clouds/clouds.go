@@ -26,6 +26,7 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"strings"
 
 	"github.com/gophercloud/gophercloud/v2"
 	"gopkg.in/yaml.v2"
@@ -44,12 +45,27 @@ import (
 //     the operating system (on Linux: `${XDG_CONFIG_HOME:-$HOME/.config}/openstack/`)
 //     3. on Linux, `/etc/openstack/`
 //
-// Once `clouds.yaml` is found in a search location, the same location is used to search for `secure.yaml`.
+// Once `clouds.yaml` is found in a search location, the same location is used
+// to search for `secure.yaml` and `public-clouds.yaml` (or `clouds-public.yaml`).
+// When `clouds.yaml` is passed with WithCloudsYAML, the file system is not
+// searched at all.
+//
+// When the cloud entry references a vendor profile with the `profile` key (or
+// the legacy `cloud` key), the profile is looked up in `public-clouds.yaml`,
+// then in the built-in registry of vendor profiles (see RegisterProfile). The
+// settings of the profile are overridden by the ones of `clouds.yaml`, which
+// are overridden by the ones of `secure.yaml`, which are overridden by the
+// functional options. Like in openstacksdk, a profile that cannot be found is
+// ignored.
 //
 // Like in python-openstackclient, relative paths in the `clouds.yaml` section
 // `cacert` are interpreted as relative the the current directory, and not to
 // the `clouds.yaml` location.
 //
+// The per-service keys `<service>_endpoint_override`, `<service>_interface`
+// and `<service>_api_version` are returned in the EndpointOpts, and are
+// applied by the service client factory functions of the openstack package.
+//
 // Search locations, as well as individual `clouds.yaml` properties, can be
 // overwritten with functional options.
 func Parse(opts ...ParseOption) (gophercloud.AuthOptions, gophercloud.EndpointOpts, *tls.Config, error) {
@@ -77,15 +93,11 @@ func Parse(opts ...ParseOption) (gophercloud.AuthOptions, gophercloud.EndpointOp
 	// if no override has been set, because it is fallible.
 	if options.cloudsyamlReader == nil {
 		if len(options.locations) < 1 {
-			cwd, err := os.Getwd()
-			if err != nil {
-				return gophercloud.AuthOptions{}, gophercloud.EndpointOpts{}, nil, fmt.Errorf("failed to get the current working directory: %w", err)
-			}
-			userConfig, err := os.UserConfigDir()
+			locations, err := defaultLocations()
 			if err != nil {
-				return gophercloud.AuthOptions{}, gophercloud.EndpointOpts{}, nil, fmt.Errorf("failed to get the user config directory: %w", err)
+				return gophercloud.AuthOptions{}, gophercloud.EndpointOpts{}, nil, err
 			}
-			options.locations = []string{path.Join(cwd, "clouds.yaml"), path.Join(userConfig, "openstack", "clouds.yaml"), path.Join("/etc", "openstack", "clouds.yaml")}
+			options.locations = locations
 		}
 
 		for _, cloudsPath := range options.locations {
@@ -104,6 +116,17 @@ func Parse(opts ...ParseOption) (gophercloud.AuthOptions, gophercloud.EndpointOp
 					options.secureyamlReader = secureF
 				}
 			}
+
+			if options.publicCloudsyamlReader == nil {
+				for _, name := range publicCloudsFiles {
+					publicF, err := os.Open(path.Join(path.Dir(cloudsPath), name))
+					if err == nil {
+						defer publicF.Close()
+						options.publicCloudsyamlReader = publicF
+						break
+					}
+				}
+			}
 			break
 		}
 		if options.cloudsyamlReader == nil {
@@ -141,6 +164,11 @@ func Parse(opts ...ParseOption) (gophercloud.AuthOptions, gophercloud.EndpointOp
 		}
 	}
 
+	cloud, err := applyProfile(cloud, options)
+	if err != nil {
+		return gophercloud.AuthOptions{}, gophercloud.EndpointOpts{}, nil, err
+	}
+
 	tlsConfig, err := computeTLSConfig(cloud, options)
 	if err != nil {
 		return gophercloud.AuthOptions{}, gophercloud.EndpointOpts{}, nil, fmt.Errorf("unable to compute TLS configuration: %w", err)
@@ -148,6 +176,14 @@ func Parse(opts ...ParseOption) (gophercloud.AuthOptions, gophercloud.EndpointOp
 
 	endpointType := coalesce(options.endpointType, cloud.EndpointType, cloud.Interface)
 
+	endpointOverrides, availabilities, apiVersions := computeServiceOptions(cloud)
+	for serviceType, endpoint := range options.endpointOverrides {
+		if endpointOverrides == nil {
+			endpointOverrides = make(map[string]string)
+		}
+		endpointOverrides[serviceType] = endpoint
+	}
+
 	var scope *gophercloud.AuthScope
 	if trustID := cloud.AuthInfo.TrustID; trustID != "" {
 		scope = &gophercloud.AuthScope{
@@ -155,11 +191,14 @@ func Parse(opts ...ParseOption) (gophercloud.AuthOptions, gophercloud.EndpointOp
 		}
 	}
 
+	username := coalesce(options.username, cloud.AuthInfo.Username)
+	password := coalesce(options.password, cloud.AuthInfo.Password)
+
 	return gophercloud.AuthOptions{
 			IdentityEndpoint:            coalesce(options.authURL, cloud.AuthInfo.AuthURL),
-			Username:                    coalesce(options.username, cloud.AuthInfo.Username),
+			Username:                    username,
 			UserID:                      coalesce(options.userID, cloud.AuthInfo.UserID),
-			Password:                    coalesce(options.password, cloud.AuthInfo.Password),
+			Password:                    password,
 			DomainID:                    coalesce(options.domainID, cloud.AuthInfo.UserDomainID, cloud.AuthInfo.ProjectDomainID, cloud.AuthInfo.DomainID),
 			DomainName:                  coalesce(options.domainName, cloud.AuthInfo.UserDomainName, cloud.AuthInfo.ProjectDomainName, cloud.AuthInfo.DomainName),
 			TenantID:                    coalesce(options.projectID, cloud.AuthInfo.ProjectID),
@@ -169,14 +208,70 @@ func Parse(opts ...ParseOption) (gophercloud.AuthOptions, gophercloud.EndpointOp
 			ApplicationCredentialID:     coalesce(options.applicationCredentialID, cloud.AuthInfo.ApplicationCredentialID),
 			ApplicationCredentialName:   coalesce(options.applicationCredentialName, cloud.AuthInfo.ApplicationCredentialName),
 			ApplicationCredentialSecret: coalesce(options.applicationCredentialSecret, cloud.AuthInfo.ApplicationCredentialSecret),
+			OIDC:                        computeOIDCOptions(cloud, username, password),
 		}, gophercloud.EndpointOpts{
-			Region:       coalesce(options.region, cloud.RegionName),
-			Availability: computeAvailability(endpointType),
+			Region:            coalesce(options.region, cloud.RegionName),
+			Availability:      computeAvailability(endpointType),
+			EndpointOverrides: endpointOverrides,
+			Availabilities:    availabilities,
+			APIVersions:       apiVersions,
 		},
 		tlsConfig,
 		nil
 }
 
+// defaultLocations returns the default search locations for clouds.yaml.
+func defaultLocations() ([]string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the current working directory: %w", err)
+	}
+	userConfig, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the user config directory: %w", err)
+	}
+	return []string{path.Join(cwd, "clouds.yaml"), path.Join(userConfig, "openstack", "clouds.yaml"), path.Join("/etc", "openstack", "clouds.yaml")}, nil
+}
+
+// computeOIDCOptions returns the settings of the OpenID Connect
+// authentication types, or nil for the other authentication types.
+func computeOIDCOptions(cloud Cloud, username, password string) *gophercloud.OIDCOptions {
+	var grantType string
+	switch cloud.AuthType {
+	case AuthV3OIDCPassword:
+		grantType = gophercloud.OIDCGrantPassword
+	case AuthV3OIDCClientCredentials:
+		grantType = gophercloud.OIDCGrantClientCredentials
+	case AuthV3OIDCAuthCode:
+		grantType = gophercloud.OIDCGrantAuthorizationCode
+	case AuthV3OIDCAccessToken:
+	default:
+		return nil
+	}
+
+	oidc := &gophercloud.OIDCOptions{
+		IdentityProvider:    cloud.AuthInfo.IdentityProvider,
+		Protocol:            cloud.AuthInfo.Protocol,
+		GrantType:           grantType,
+		DiscoveryEndpoint:   cloud.AuthInfo.DiscoveryEndpoint,
+		AccessTokenEndpoint: cloud.AuthInfo.AccessTokenEndpoint,
+		ClientID:            cloud.AuthInfo.ClientID,
+		ClientSecret:        cloud.AuthInfo.ClientSecret,
+		Code:                cloud.AuthInfo.Code,
+		RedirectURI:         cloud.AuthInfo.RedirectURI,
+		OpenIDScope:         cloud.AuthInfo.OpenIDScope,
+		AccessTokenType:     cloud.AuthInfo.AccessTokenType,
+	}
+	switch cloud.AuthType {
+	case AuthV3OIDCPassword:
+		oidc.Username = username
+		oidc.Password = password
+	case AuthV3OIDCAccessToken:
+		oidc.AccessToken = cloud.AuthInfo.AccessToken
+	}
+	return oidc
+}
+
 // computeAvailability is a helper method to determine the endpoint type
 // requested by the user.
 func computeAvailability(endpointType string) gophercloud.Availability {
@@ -189,6 +284,50 @@ func computeAvailability(endpointType string) gophercloud.Availability {
 	return gophercloud.AvailabilityPublic
 }
 
+// computeServiceOptions collects the per-service settings of the cloud entry:
+// the endpoint overrides, the interfaces and the API versions, keyed by
+// service type. The service types of the keys are written with underscores
+// (e.g. "block_storage_endpoint_override").
+func computeServiceOptions(cloud Cloud) (endpointOverrides map[string]string, availabilities map[string]gophercloud.Availability, apiVersions map[string]string) {
+	set := func(m map[string]string, serviceType, value string) map[string]string {
+		if m == nil {
+			m = make(map[string]string)
+		}
+		m[serviceType] = value
+		return m
+	}
+
+	if cloud.IdentityAPIVersion != "" {
+		apiVersions = set(apiVersions, "identity", cloud.IdentityAPIVersion)
+	}
+	if cloud.VolumeAPIVersion != "" {
+		apiVersions = set(apiVersions, "volume", cloud.VolumeAPIVersion)
+	}
+
+	for key, value := range cloud.ServiceOptions {
+		if value == "" {
+			continue
+		}
+		if service, ok := strings.CutSuffix(key, "_endpoint_override"); ok {
+			endpointOverrides = set(endpointOverrides, serviceType(service), string(value))
+		} else if service, ok := strings.CutSuffix(key, "_interface"); ok {
+			if availabilities == nil {
+				availabilities = make(map[string]gophercloud.Availability)
+			}
+			availabilities[serviceType(service)] = computeAvailability(string(value))
+		} else if service, ok := strings.CutSuffix(key, "_api_version"); ok {
+			apiVersions = set(apiVersions, serviceType(service), string(value))
+		}
+	}
+	return endpointOverrides, availabilities, apiVersions
+}
+
+// serviceType converts the service type of a clouds.yaml key to its
+// canonical form, e.g. "block_storage" to "block-storage".
+func serviceType(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
 // coalesce returns the first argument that is not the zero value for its type,
 // or the zero value for its type.
 func coalesce[T comparable](items ...T) T {
@@ -232,6 +371,19 @@ func mergeClouds(override, cloud Cloud) (Cloud, error) {
 	if err != nil {
 		return Cloud{}, err
 	}
+
+	// ServiceOptions are not part of the JSON representation
+	if len(cloud.ServiceOptions)+len(override.ServiceOptions) > 0 {
+		mergedCloud.ServiceOptions = make(map[string]ServiceOption)
+		for k, v := range cloud.ServiceOptions {
+			mergedCloud.ServiceOptions[k] = v
+		}
+		for k, v := range override.ServiceOptions {
+			if v != "" {
+				mergedCloud.ServiceOptions[k] = v
+			}
+		}
+	}
 	return mergedCloud, nil
 }
 
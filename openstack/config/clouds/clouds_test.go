@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/openstack/config/clouds"
 )
 
@@ -223,3 +225,303 @@ func TestParse(t *testing.T) {
 		}
 	})
 }
+
+func TestParseServiceOptions(t *testing.T) {
+	const cloudsYAML = `clouds:
+  gophercloud-test:
+    auth:
+      auth_url: https://example.com:13000
+    interface: internal
+    identity_api_version: 3
+    volume_api_version: 3
+    compute_api_version: 2.10
+    compute_endpoint_override: https://compute.example.com/v2.1
+    block_storage_interface: public
+    networks:
+    - name: public
+      routes_externally: true`
+	const secureYAML = `clouds:
+  gophercloud-test:
+    network_endpoint_override: https://network.example.com`
+
+	t.Run("parses the per-service settings", func(t *testing.T) {
+		_, eo, _, err := clouds.Parse(
+			clouds.WithCloudsYAML(strings.NewReader(cloudsYAML)),
+			clouds.WithCloudName("gophercloud-test"),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := eo.EndpointOverrides; !reflect.DeepEqual(got, map[string]string{"compute": "https://compute.example.com/v2.1"}) {
+			t.Errorf("unexpected endpoint overrides: %v", got)
+		}
+		if got := eo.Availabilities; !reflect.DeepEqual(got, map[string]gophercloud.Availability{"block-storage": gophercloud.AvailabilityPublic}) {
+			t.Errorf("unexpected availabilities: %v", got)
+		}
+		if got := eo.APIVersions; !reflect.DeepEqual(got, map[string]string{"identity": "3", "volume": "3", "compute": "2.10"}) {
+			t.Errorf("unexpected API versions: %v", got)
+		}
+		if got := eo.Availability; got != gophercloud.AvailabilityInternal {
+			t.Errorf("unexpected availability: %q", got)
+		}
+	})
+
+	t.Run("merges the per-service settings of secure.yaml", func(t *testing.T) {
+		_, eo, _, err := clouds.Parse(
+			clouds.WithCloudsYAML(strings.NewReader(cloudsYAML)),
+			clouds.WithSecureYAML(strings.NewReader(secureYAML)),
+			clouds.WithCloudName("gophercloud-test"),
+			clouds.WithEndpointOverride("image", "https://image.example.com"),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := map[string]string{
+			"compute": "https://compute.example.com/v2.1",
+			"network": "https://network.example.com",
+			"image":   "https://image.example.com",
+		}
+		if got := eo.EndpointOverrides; !reflect.DeepEqual(got, expected) {
+			t.Errorf("unexpected endpoint overrides: %v", got)
+		}
+	})
+}
+
+func TestParseProfile(t *testing.T) {
+	const publicCloudsYAML = `public-clouds:
+  example:
+    auth:
+      auth_url: https://identity.example.com/v3
+      user_domain_name: example-domain
+    region_name: profile-region
+    interface: internal
+    compute_api_version: 2.79`
+
+	t.Run("merges the profile under the cloud entry", func(t *testing.T) {
+		const cloudsYAML = `clouds:
+  gophercloud-test:
+    profile: example
+    auth:
+      username: clouds-username
+      password: clouds-password
+    region_name: clouds-region`
+		const secureYAML = `clouds:
+  gophercloud-test:
+    auth:
+      password: secure-password`
+
+		ao, eo, _, err := clouds.Parse(
+			clouds.WithCloudsYAML(strings.NewReader(cloudsYAML)),
+			clouds.WithSecureYAML(strings.NewReader(secureYAML)),
+			clouds.WithPublicCloudsYAML(strings.NewReader(publicCloudsYAML)),
+			clouds.WithCloudName("gophercloud-test"),
+			clouds.WithUsername("option-username"),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := ao.IdentityEndpoint; got != "https://identity.example.com/v3" {
+			t.Errorf("unexpected identity endpoint: %q", got)
+		}
+		if got := ao.DomainName; got != "example-domain" {
+			t.Errorf("unexpected domain name: %q", got)
+		}
+		if got := ao.Username; got != "option-username" {
+			t.Errorf("unexpected username: %q", got)
+		}
+		if got := ao.Password; got != "secure-password" {
+			t.Errorf("unexpected password: %q", got)
+		}
+		if got := eo.Region; got != "clouds-region" {
+			t.Errorf("unexpected region: %q", got)
+		}
+		if got := eo.Availability; got != gophercloud.AvailabilityInternal {
+			t.Errorf("unexpected availability: %q", got)
+		}
+		if got := eo.APIVersions["compute"]; got != "2.79" {
+			t.Errorf("unexpected compute API version: %q", got)
+		}
+	})
+
+	t.Run("supports the legacy cloud key and the built-in profiles", func(t *testing.T) {
+		clouds.RegisterProfile("gophercloud-vendor", clouds.Cloud{
+			AuthInfo: &clouds.AuthInfo{
+				AuthURL: "https://identity.vendor.example.com",
+			},
+		})
+
+		const cloudsYAML = `clouds:
+  gophercloud-test:
+    cloud: gophercloud-vendor
+    auth:
+      username: clouds-username`
+
+		ao, _, _, err := clouds.Parse(
+			clouds.WithCloudsYAML(strings.NewReader(cloudsYAML)),
+			clouds.WithPublicCloudsYAML(strings.NewReader(publicCloudsYAML)),
+			clouds.WithCloudName("gophercloud-test"),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := ao.IdentityEndpoint; got != "https://identity.vendor.example.com" {
+			t.Errorf("unexpected identity endpoint: %q", got)
+		}
+		if got := ao.Username; got != "clouds-username" {
+			t.Errorf("unexpected username: %q", got)
+		}
+	})
+
+	t.Run("finds public-clouds.yaml next to clouds.yaml", func(t *testing.T) {
+		const cloudsYAML = `clouds:
+  gophercloud-test:
+    profile: example`
+
+		tmpDir := t.TempDir()
+		cloudsPath := path.Join(tmpDir, "clouds.yaml")
+		if err := os.WriteFile(cloudsPath, []byte(cloudsYAML), 0644); err != nil {
+			t.Fatalf("unable to create a mock clouds.yaml file: %v", err)
+		}
+		if err := os.WriteFile(path.Join(tmpDir, "clouds-public.yaml"), []byte(publicCloudsYAML), 0644); err != nil {
+			t.Fatalf("unable to create a mock clouds-public.yaml file: %v", err)
+		}
+
+		ao, _, _, err := clouds.Parse(
+			clouds.WithCloudName("gophercloud-test"),
+			clouds.WithLocations(cloudsPath),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := ao.IdentityEndpoint; got != "https://identity.example.com/v3" {
+			t.Errorf("unexpected identity endpoint: %q", got)
+		}
+	})
+
+	t.Run("does not search public-clouds.yaml for a clouds.yaml reader", func(t *testing.T) {
+		const cloudsYAML = `clouds:
+  gophercloud-test:
+    profile: example
+    auth:
+      auth_url: https://example.com:13000`
+
+		tmpDir := t.TempDir()
+		if err := os.WriteFile(path.Join(tmpDir, "public-clouds.yaml"), []byte(publicCloudsYAML), 0644); err != nil {
+			t.Fatalf("unable to create a mock public-clouds.yaml file: %v", err)
+		}
+		t.Setenv("OS_CLIENT_CONFIG_FILE", path.Join(tmpDir, "clouds.yaml"))
+
+		ao, eo, _, err := clouds.Parse(
+			clouds.WithCloudsYAML(strings.NewReader(cloudsYAML)),
+			clouds.WithCloudName("gophercloud-test"),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := ao.IdentityEndpoint; got != "https://example.com:13000" {
+			t.Errorf("unexpected identity endpoint: %q", got)
+		}
+		if got := eo.Region; got != "" {
+			t.Errorf("unexpected region: %q", got)
+		}
+	})
+
+	t.Run("ignores unknown profiles", func(t *testing.T) {
+		const cloudsYAML = `clouds:
+  gophercloud-test:
+    profile: unknown
+    auth:
+      auth_url: https://example.com:13000`
+
+		ao, _, _, err := clouds.Parse(
+			clouds.WithCloudsYAML(strings.NewReader(cloudsYAML)),
+			clouds.WithPublicCloudsYAML(strings.NewReader(publicCloudsYAML)),
+			clouds.WithCloudName("gophercloud-test"),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := ao.IdentityEndpoint; got != "https://example.com:13000" {
+			t.Errorf("unexpected identity endpoint: %q", got)
+		}
+	})
+}
+
+func TestParseOIDC(t *testing.T) {
+	const cloudsYAML = `clouds:
+  password:
+    auth_type: v3oidcpassword
+    auth:
+      auth_url: https://example.com:13000
+      identity_provider: myidp
+      protocol: openid
+      discovery_endpoint: https://idp.example.com/.well-known/openid-configuration
+      client_id: openstack
+      client_secret: client-secret
+      username: alice
+      password: secret
+      project_name: demo
+      project_domain_name: federated
+  accesstoken:
+    auth_type: v3oidcaccesstoken
+    auth:
+      auth_url: https://example.com:13000
+      identity_provider: myidp
+      protocol: openid
+      access_token: the-access-token
+  plain:
+    auth:
+      auth_url: https://example.com:13000
+      username: alice
+      password: secret`
+
+	parse := func(cloudName string) gophercloud.AuthOptions {
+		ao, _, _, err := clouds.Parse(
+			clouds.WithCloudsYAML(strings.NewReader(cloudsYAML)),
+			clouds.WithCloudName(cloudName),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return ao
+	}
+
+	ao := parse("password")
+	expected := &gophercloud.OIDCOptions{
+		IdentityProvider:  "myidp",
+		Protocol:          "openid",
+		GrantType:         gophercloud.OIDCGrantPassword,
+		DiscoveryEndpoint: "https://idp.example.com/.well-known/openid-configuration",
+		ClientID:          "openstack",
+		ClientSecret:      "client-secret",
+		Username:          "alice",
+		Password:          "secret",
+	}
+	if !reflect.DeepEqual(ao.OIDC, expected) {
+		t.Errorf("unexpected OIDC options: %+v", ao.OIDC)
+	}
+	if ao.TenantName != "demo" || ao.DomainName != "federated" {
+		t.Errorf("unexpected scope: %q in %q", ao.TenantName, ao.DomainName)
+	}
+
+	ao = parse("accesstoken")
+	expected = &gophercloud.OIDCOptions{
+		IdentityProvider: "myidp",
+		Protocol:         "openid",
+		AccessToken:      "the-access-token",
+	}
+	if !reflect.DeepEqual(ao.OIDC, expected) {
+		t.Errorf("unexpected OIDC options: %+v", ao.OIDC)
+	}
+
+	if ao := parse("plain"); ao.OIDC != nil {
+		t.Errorf("unexpected OIDC options: %+v", ao.OIDC)
+	}
+}
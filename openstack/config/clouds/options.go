@@ -7,10 +7,11 @@ import (
 )
 
 type cloudOpts struct {
-	cloudName        string
-	locations        []string
-	cloudsyamlReader io.Reader
-	secureyamlReader io.Reader
+	cloudName              string
+	locations              []string
+	cloudsyamlReader       io.Reader
+	secureyamlReader       io.Reader
+	publicCloudsyamlReader io.Reader
 
 	applicationCredentialID     string
 	applicationCredentialName   string
@@ -18,6 +19,7 @@ type cloudOpts struct {
 	authURL                     string
 	domainID                    string
 	domainName                  string
+	endpointOverrides           map[string]string
 	endpointType                string
 	password                    string
 	projectID                   string
@@ -55,8 +57,9 @@ func WithLocations(locations ...string) ParseOption {
 
 // WithCloudsYAML is a functional option that lets you pass a clouds.yaml file
 // as an io.Reader interface. When this option is passed, FromCloudsYaml will
-// not attempt to fetch any file from the file system. To add a secure.yaml,
-// use in conjunction with WithSecureYAML.
+// not attempt to fetch any file from the file system. To add a secure.yaml
+// or a public-clouds.yaml, use in conjunction with WithSecureYAML or
+// WithPublicCloudsYAML.
 func WithCloudsYAML(clouds io.Reader) ParseOption {
 	return func(co *cloudOpts) {
 		co.cloudsyamlReader = clouds
@@ -72,6 +75,15 @@ func WithSecureYAML(secure io.Reader) ParseOption {
 	}
 }
 
+// WithPublicCloudsYAML is a functional option that lets you pass a
+// public-clouds.yaml file as an io.Reader interface. When this option is
+// passed, Parse will not search the file system for the vendor profiles.
+func WithPublicCloudsYAML(publicClouds io.Reader) ParseOption {
+	return func(co *cloudOpts) {
+		co.publicCloudsyamlReader = publicClouds
+	}
+}
+
 func WithApplicationCredentialID(applicationCredentialID string) ParseOption {
 	return func(co *cloudOpts) {
 		co.applicationCredentialID = applicationCredentialID
@@ -108,6 +120,17 @@ func WithDomainName(domainName string) ParseOption {
 	}
 }
 
+// WithEndpointOverride allows to override the endpoint of a service type set
+// in clouds.yaml with the `<service>_endpoint_override` key, or to set one.
+func WithEndpointOverride(serviceType, endpoint string) ParseOption {
+	return func(co *cloudOpts) {
+		if co.endpointOverrides == nil {
+			co.endpointOverrides = make(map[string]string)
+		}
+		co.endpointOverrides[serviceType] = endpoint
+	}
+}
+
 // WithRegion allows to override the endpoint type set in clouds.yaml or in the
 // environment variable `OS_INTERFACE`.
 func WithEndpointType(endpointType string) ParseOption {
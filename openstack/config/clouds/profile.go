@@ -0,0 +1,100 @@
+package clouds
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// publicCloudsFiles are the names of the file holding the vendor profiles,
+// searched in the directory of clouds.yaml.
+var publicCloudsFiles = []string{"public-clouds.yaml", "clouds-public.yaml"}
+
+var (
+	vendorProfilesMu sync.RWMutex
+
+	// vendorProfiles is the built-in registry of vendor profiles, from the
+	// vendor defaults of openstacksdk.
+	vendorProfiles = map[string]Cloud{
+		"dreamcompute": {
+			AuthInfo: &AuthInfo{
+				AuthURL: "https://iad2.dream.io:5000",
+			},
+			IdentityAPIVersion: "3",
+			RegionName:         "RegionOne",
+		},
+		"ovh": {
+			AuthInfo: &AuthInfo{
+				AuthURL: "https://auth.cloud.ovh.net/",
+			},
+			IdentityAPIVersion: "3",
+			Regions:            []Region{{Name: "BHS1"}, {Name: "GRA1"}, {Name: "SBG1"}},
+		},
+		"vexxhost": {
+			AuthInfo: &AuthInfo{
+				AuthURL: "https://auth.vexxhost.net/v3",
+			},
+			AuthType:           AuthV3Password,
+			IdentityAPIVersion: "3",
+			Regions:            []Region{{Name: "ca-ymq-1"}, {Name: "sjc1"}, {Name: "amsterdam"}},
+		},
+	}
+)
+
+// RegisterProfile adds a vendor profile to the built-in registry, or replaces
+// the profile registered with the same name. The profile is used by Parse for
+// the cloud entries that reference it, unless a public-clouds.yaml file
+// defines a profile with the same name.
+//
+// The built-in registry only has the profiles of the dreamcompute, ovh and
+// vexxhost vendors of openstacksdk. The profiles of the other vendors must be
+// registered, or provided by a public-clouds.yaml file.
+func RegisterProfile(name string, profile Cloud) {
+	vendorProfilesMu.Lock()
+	defer vendorProfilesMu.Unlock()
+	vendorProfiles[name] = profile
+}
+
+// applyProfile merges the vendor profile referenced by the cloud entry, if
+// any, under the settings of the entry.
+func applyProfile(cloud Cloud, options cloudOpts) (Cloud, error) {
+	name := coalesce(cloud.Profile, cloud.Cloud)
+	if name == "" {
+		return cloud, nil
+	}
+
+	profile, ok, err := findProfile(name, options)
+	if err != nil {
+		return Cloud{}, err
+	}
+	if !ok {
+		return cloud, nil
+	}
+
+	merged, err := mergeClouds(cloud, profile)
+	if err != nil {
+		return Cloud{}, fmt.Errorf("unable to merge information from the profile %q: %w", name, err)
+	}
+	return merged, nil
+}
+
+// findProfile looks up a vendor profile in public-clouds.yaml, then in the
+// built-in registry.
+func findProfile(name string, options cloudOpts) (Cloud, bool, error) {
+	if options.publicCloudsyamlReader != nil {
+		var publicClouds PublicClouds
+		if err := yaml.NewDecoder(options.publicCloudsyamlReader).Decode(&publicClouds); err != nil && err != io.EOF {
+			return Cloud{}, false, fmt.Errorf("failed to parse public-clouds.yaml: %w", err)
+		}
+		if profile, ok := publicClouds.Clouds[name]; ok {
+			return profile, true, nil
+		}
+	}
+
+	vendorProfilesMu.RLock()
+	defer vendorProfilesMu.RUnlock()
+	profile, ok := vendorProfiles[name]
+	return profile, ok, nil
+}
@@ -9,6 +9,12 @@ type Clouds struct {
 	Clouds map[string]Cloud `yaml:"clouds" json:"clouds"`
 }
 
+// PublicClouds represents a collection of vendor profiles in a
+// public-clouds.yaml file.
+type PublicClouds struct {
+	Clouds map[string]Cloud `yaml:"public-clouds" json:"public-clouds"`
+}
+
 // Cloud represents an entry in a clouds.yaml/public-clouds.yaml/secure.yaml file.
 type Cloud struct {
 	Cloud      string    `yaml:"cloud,omitempty" json:"cloud,omitempty"`
@@ -42,6 +48,26 @@ type Cloud struct {
 	// ClientKeyFile a path to a client key to use as part of the SSL
 	// transaction.
 	ClientKeyFile string `yaml:"key,omitempty" json:"key,omitempty"`
+
+	// ServiceOptions holds the keys of the entry that have no dedicated
+	// field, notably the per-service settings "<service>_endpoint_override",
+	// "<service>_interface" and "<service>_api_version".
+	ServiceOptions map[string]ServiceOption `yaml:",inline" json:"-"`
+}
+
+// ServiceOption is the value of a key of a Cloud entry that has no dedicated
+// field. It holds the value as written in the file, so that an API version
+// such as "2.10" is not read as the number 2.1. Values that are not scalars
+// are ignored.
+type ServiceOption string
+
+// UnmarshalYAML reads scalar values as strings, and ignores other values.
+func (o *ServiceOption) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*o = ServiceOption(s)
+	}
+	return nil
 }
 
 // AuthInfo represents the auth section of a cloud entry or
@@ -132,6 +158,50 @@ type AuthInfo struct {
 	// false, it will not cache these settings, but re-authentication will not be
 	// possible.  This setting defaults to false.
 	AllowReauth bool `yaml:"allow_reauth,omitempty" json:"allow_reauth,omitempty"`
+
+	// IdentityProvider is the name of the identity provider in Keystone, for
+	// the federated authentication types.
+	IdentityProvider string `yaml:"identity_provider,omitempty" json:"identity_provider,omitempty"`
+
+	// Protocol is the federation protocol of the identity provider in
+	// Keystone, for the federated authentication types.
+	Protocol string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+
+	// ClientID is the ID of the OAuth 2.0 client registered with the OpenID
+	// Connect identity provider.
+	ClientID string `yaml:"client_id,omitempty" json:"client_id,omitempty"`
+
+	// ClientSecret is the secret of the OAuth 2.0 client registered with the
+	// OpenID Connect identity provider.
+	ClientSecret string `yaml:"client_secret,omitempty" json:"client_secret,omitempty"`
+
+	// DiscoveryEndpoint is the URL of the OpenID Connect discovery document
+	// of the identity provider.
+	DiscoveryEndpoint string `yaml:"discovery_endpoint,omitempty" json:"discovery_endpoint,omitempty"`
+
+	// AccessTokenEndpoint is the URL of the token endpoint of the OpenID
+	// Connect identity provider.
+	AccessTokenEndpoint string `yaml:"access_token_endpoint,omitempty" json:"access_token_endpoint,omitempty"`
+
+	// AccessTokenType is the token of the identity provider response sent to
+	// Keystone: "access_token" or "id_token".
+	AccessTokenType string `yaml:"access_token_type,omitempty" json:"access_token_type,omitempty"`
+
+	// OpenIDScope is the space-separated list of scopes requested from the
+	// OpenID Connect identity provider.
+	OpenIDScope string `yaml:"openid_scope,omitempty" json:"openid_scope,omitempty"`
+
+	// AccessToken is a token obtained from the OpenID Connect identity
+	// provider, for the v3oidcaccesstoken authentication type.
+	AccessToken string `yaml:"access_token,omitempty" json:"access_token,omitempty"`
+
+	// Code is an authorization code issued by the OpenID Connect identity
+	// provider, for the v3oidcauthcode authentication type.
+	Code string `yaml:"code,omitempty" json:"code,omitempty"`
+
+	// RedirectURI is the redirect URI of the authorization request, for the
+	// v3oidcauthcode authentication type.
+	RedirectURI string `yaml:"redirect_uri,omitempty" json:"redirect_uri,omitempty"`
 }
 
 // Region represents a region included as part of cloud in clouds.yaml
@@ -203,4 +273,13 @@ const (
 
 	// AuthV3ApplicationCredential defines version 3 of the application credential
 	AuthV3ApplicationCredential AuthType = "v3applicationcredential"
+
+	// AuthV3OIDCPassword defines the OpenID Connect password grant
+	AuthV3OIDCPassword AuthType = "v3oidcpassword"
+	// AuthV3OIDCClientCredentials defines the OpenID Connect client credentials grant
+	AuthV3OIDCClientCredentials AuthType = "v3oidcclientcredentials"
+	// AuthV3OIDCAuthCode defines the OpenID Connect authorization code grant
+	AuthV3OIDCAuthCode AuthType = "v3oidcauthcode"
+	// AuthV3OIDCAccessToken defines the OpenID Connect access token
+	AuthV3OIDCAccessToken AuthType = "v3oidcaccesstoken"
 )
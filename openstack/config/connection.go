@@ -0,0 +1,239 @@
+package config
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/config/clouds"
+)
+
+/*
+Connection gives access to the services of a cloud. It holds an authenticated
+ProviderClient, and creates the ServiceClient of each service the first time it
+is requested, with the region, interface, endpoint override, API version and
+microversion settings of the EndpointOpts. The ServiceClients are then cached,
+and shared by all the callers.
+
+A Connection is safe for concurrent use. Since the ServiceClients are shared,
+they must not be modified: use RequestOpts.Microversion to send a single
+request with another microversion, or copy the ServiceClient.
+
+Example to connect to the cloud named by OS_CLOUD in clouds.yaml
+
+	conn, err := config.Connect(context.TODO())
+	if err != nil {
+		panic(err)
+	}
+
+	computeClient, err := conn.Compute(context.TODO())
+	if err != nil {
+		panic(err)
+	}
+*/
+type Connection struct {
+	// Provider is the authenticated ProviderClient of the connection.
+	Provider *gophercloud.ProviderClient
+
+	endpointOpts gophercloud.EndpointOpts
+
+	mu      sync.Mutex
+	clients map[string]*clientEntry
+}
+
+// NewConnection returns a Connection that creates the ServiceClients of an
+// authenticated ProviderClient with the given EndpointOpts.
+func NewConnection(provider *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) *Connection {
+	return &Connection{
+		Provider:     provider,
+		endpointOpts: eo,
+		clients:      make(map[string]*clientEntry),
+	}
+}
+
+// Connect parses clouds.yaml with the given options (see clouds.Parse),
+// authenticates with the TLS configuration of the cloud entry, and returns a
+// Connection that applies the endpoint settings of the cloud entry.
+func Connect(ctx context.Context, opts ...clouds.ParseOption) (*Connection, error) {
+	ao, eo, tlsConfig, err := clouds.Parse(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := NewProviderClient(ctx, ao, WithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewConnection(provider, eo), nil
+}
+
+// EndpointOpts returns the EndpointOpts used to create the ServiceClients.
+func (c *Connection) EndpointOpts() gophercloud.EndpointOpts {
+	return c.endpointOpts
+}
+
+// clientEntry is a ServiceClient of the cache. Its done channel is closed
+// once the creation of the ServiceClient completed, successfully or not.
+type clientEntry struct {
+	done   chan struct{}
+	client *gophercloud.ServiceClient
+}
+
+// serviceClient returns the cached ServiceClient of a service, and creates
+// it if needed. Failures are not cached, so that a later call can succeed.
+// The lock is not held while a ServiceClient is created, so that a slow
+// endpoint discovery only blocks the callers of the same service.
+func (c *Connection) serviceClient(ctx context.Context, key string, newClient func(context.Context, *gophercloud.ProviderClient, gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error)) (*gophercloud.ServiceClient, error) {
+	for {
+		c.mu.Lock()
+		entry, ok := c.clients[key]
+		if !ok {
+			entry = &clientEntry{done: make(chan struct{})}
+			c.clients[key] = entry
+			c.mu.Unlock()
+
+			client, err := newClient(ctx, c.Provider, c.endpointOpts)
+			c.mu.Lock()
+			if err != nil {
+				delete(c.clients, key)
+			} else {
+				entry.client = client
+			}
+			c.mu.Unlock()
+			close(entry.done)
+			return client, err
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if entry.client != nil {
+			return entry.client, nil
+		}
+		// the creation failed with the context of another caller: try again
+	}
+}
+
+// majorAPIVersion returns the major version set in the APIVersions of the
+// EndpointOpts for the given service type, or an empty string.
+func (c *Connection) majorAPIVersion(serviceType string) string {
+	eo := c.endpointOpts
+	eo.ApplyDefaults(serviceType)
+	major, _, _ := strings.Cut(strings.TrimPrefix(eo.APIVersion(), "v"), ".")
+	return major
+}
+
+// Service returns the ServiceClient of any service type, including the
+// services without a dedicated accessor. See openstack.NewServiceClient.
+func (c *Connection) Service(ctx context.Context, serviceType string) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "service:"+serviceType, func(ctx context.Context, provider *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
+		return openstack.NewServiceClient(ctx, provider, serviceType, eo)
+	})
+}
+
+// BareMetal returns the ServiceClient of the v1 bare metal service.
+func (c *Connection) BareMetal(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "baremetal", openstack.NewBareMetalV1)
+}
+
+// BareMetalIntrospection returns the ServiceClient of the v1 bare metal
+// introspection service.
+func (c *Connection) BareMetalIntrospection(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "baremetal-introspection", openstack.NewBareMetalIntrospectionV1)
+}
+
+// BlockStorage returns the ServiceClient of the block storage service. The v3
+// API is used, unless the API version of the service is set to 2.
+func (c *Connection) BlockStorage(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	if c.majorAPIVersion("block-storage") == "2" {
+		return c.serviceClient(ctx, "block-storage-v2", openstack.NewBlockStorageV2)
+	}
+	return c.serviceClient(ctx, "block-storage", openstack.NewBlockStorageV3)
+}
+
+// Compute returns the ServiceClient of the v2 compute service.
+func (c *Connection) Compute(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "compute", openstack.NewComputeV2)
+}
+
+// Container returns the ServiceClient of the v1 application container service.
+func (c *Connection) Container(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "application-container", openstack.NewContainerV1)
+}
+
+// ContainerInfra returns the ServiceClient of the v1 container infrastructure
+// management service.
+func (c *Connection) ContainerInfra(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "container-infrastructure-management", openstack.NewContainerInfraV1)
+}
+
+// DB returns the ServiceClient of the v1 database service.
+func (c *Connection) DB(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "database", openstack.NewDBV1)
+}
+
+// DNS returns the ServiceClient of the v2 DNS service.
+func (c *Connection) DNS(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "dns", openstack.NewDNSV2)
+}
+
+// Identity returns the ServiceClient of the identity service. The v3 API is
+// used, unless the API version of the service is set to 2.
+func (c *Connection) Identity(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	if c.majorAPIVersion("identity") == "2" {
+		return c.serviceClient(ctx, "identity-v2", openstack.NewIdentityV2)
+	}
+	return c.serviceClient(ctx, "identity", openstack.NewIdentityV3)
+}
+
+// Image returns the ServiceClient of the v2 image service.
+func (c *Connection) Image(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "image", openstack.NewImageV2)
+}
+
+// KeyManager returns the ServiceClient of the v1 key manager service.
+func (c *Connection) KeyManager(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "key-manager", openstack.NewKeyManagerV1)
+}
+
+// LoadBalancer returns the ServiceClient of the v2 load balancer service.
+func (c *Connection) LoadBalancer(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "load-balancer", openstack.NewLoadBalancerV2)
+}
+
+// Network returns the ServiceClient of the v2 network service.
+func (c *Connection) Network(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "network", openstack.NewNetworkV2)
+}
+
+// ObjectStorage returns the ServiceClient of the v1 object storage service.
+func (c *Connection) ObjectStorage(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "object-store", openstack.NewObjectStorageV1)
+}
+
+// Orchestration returns the ServiceClient of the v1 orchestration service.
+func (c *Connection) Orchestration(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "orchestration", openstack.NewOrchestrationV1)
+}
+
+// Placement returns the ServiceClient of the v1 placement service.
+func (c *Connection) Placement(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "placement", openstack.NewPlacementV1)
+}
+
+// SharedFileSystem returns the ServiceClient of the v2 shared file system
+// service.
+func (c *Connection) SharedFileSystem(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "shared-file-system", openstack.NewSharedFileSystemV2)
+}
+
+// Workflow returns the ServiceClient of the v2 workflow service.
+func (c *Connection) Workflow(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	return c.serviceClient(ctx, "workflow", openstack.NewWorkflowV2)
+}
@@ -0,0 +1,194 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/config"
+	"github.com/gophercloud/gophercloud/v2/openstack/config/clouds"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/keystone"
+)
+
+func setupKeystone(t *testing.T) *keystone.Server {
+	ks := keystone.Setup()
+	t.Cleanup(ks.Teardown)
+
+	domain := ks.AddDomain("default")
+	project := ks.AddProject("demo", domain.ID)
+	user := ks.AddUser("alice", "secret", domain.ID)
+	ks.AssignProjectRole(user.ID, project.ID, "member")
+
+	ks.RegisterService("compute", "nova",
+		keystone.Endpoint{Interface: "public", Region: "RegionOne", URL: "https://compute.example.com/v2.1"},
+		keystone.Endpoint{Interface: "internal", Region: "RegionOne", URL: "https://compute.internal/v2.1"},
+		keystone.Endpoint{Interface: "public", Region: "RegionTwo", URL: "https://compute.two.example.com/v2.1"},
+	)
+	ks.RegisterService("volumev3", "cinderv3",
+		keystone.Endpoint{Interface: "internal", Region: "RegionOne", URL: "https://volume.internal/v3"},
+	)
+	ks.RegisterService("volumev2", "cinderv2",
+		keystone.Endpoint{Interface: "internal", Region: "RegionOne", URL: "https://volume.internal/v2"},
+	)
+	ks.RegisterService("network", "neutron",
+		keystone.Endpoint{Interface: "internal", Region: "RegionOne", URL: "https://network.internal"},
+	)
+
+	return ks
+}
+
+func connect(t *testing.T, ks *keystone.Server, settings string) *config.Connection {
+	cloudsYAML := fmt.Sprintf(`clouds:
+  test:
+    auth:
+      auth_url: %s
+      username: alice
+      password: secret
+      user_domain_name: default
+      project_name: demo
+      project_domain_name: default
+    region_name: RegionOne
+    interface: internal
+%s`, ks.AuthURL(), settings)
+
+	conn, err := config.Connect(context.TODO(),
+		clouds.WithCloudsYAML(strings.NewReader(cloudsYAML)),
+		clouds.WithCloudName("test"),
+	)
+	th.AssertNoErr(t, err)
+	return conn
+}
+
+func TestConnection(t *testing.T) {
+	ks := setupKeystone(t)
+	conn := connect(t, ks, `    compute_interface: public
+    compute_api_version: 2.79
+    network_endpoint_override: http://localhost:9696`)
+
+	compute, err := conn.Compute(context.TODO())
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "https://compute.example.com/v2.1/", compute.Endpoint)
+	th.AssertEquals(t, "2.79", compute.Microversion)
+
+	volume, err := conn.BlockStorage(context.TODO())
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "https://volume.internal/v3/", volume.Endpoint)
+
+	network, err := conn.Network(context.TODO())
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "http://localhost:9696/", network.Endpoint)
+
+	identity, err := conn.Identity(context.TODO())
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, ks.AuthURL(), identity.Endpoint)
+
+	// the service clients are cached
+	again, err := conn.Compute(context.TODO())
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, compute, again)
+	th.AssertEquals(t, 1, ks.IssuedTokens())
+
+	_, err = conn.Image(context.TODO())
+	if err == nil {
+		t.Fatalf("expected an error for a service missing from the catalog")
+	}
+}
+
+func TestConnectionBlockStorageV2(t *testing.T) {
+	ks := setupKeystone(t)
+	conn := connect(t, ks, `    volume_api_version: 2`)
+
+	volume, err := conn.BlockStorage(context.TODO())
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "https://volume.internal/v2/", volume.Endpoint)
+}
+
+func TestConnectionService(t *testing.T) {
+	ks := setupKeystone(t)
+	conn := connect(t, ks, `    volume_api_version: 3`)
+
+	// an alias of block-storage
+	volume, err := conn.Service(context.TODO(), "volumev3")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "block-storage", volume.Type)
+	th.AssertEquals(t, "https://volume.internal/v3/", volume.Endpoint)
+
+	again, err := conn.Service(context.TODO(), "volumev3")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, volume, again)
+}
+
+func TestConnectionConcurrency(t *testing.T) {
+	ks := setupKeystone(t)
+	conn := connect(t, ks, "")
+
+	var wg sync.WaitGroup
+	clients := make([]*gophercloud.ServiceClient, 10)
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := conn.Compute(context.TODO())
+			if err != nil {
+				t.Error(err)
+			}
+			clients[i] = client
+		}()
+	}
+	wg.Wait()
+
+	for _, client := range clients {
+		th.AssertEquals(t, clients[0], client)
+	}
+	th.AssertEquals(t, "https://compute.internal/v2.1/", clients[0].Endpoint)
+}
+
+func TestConnectionSlowDiscovery(t *testing.T) {
+	ks := setupKeystone(t)
+
+	var once sync.Once
+	discovering := make(chan struct{})
+	release := make(chan struct{})
+	accelerator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(discovering) })
+		<-release
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer accelerator.Close()
+	ks.RegisterService("accelerator", "cyborg",
+		keystone.Endpoint{Interface: "internal", Region: "RegionOne", URL: accelerator.URL},
+	)
+	conn := connect(t, ks, "")
+
+	done := make(chan error)
+	go func() {
+		_, err := conn.Service(context.TODO(), "accelerator")
+		done <- err
+	}()
+	<-discovering
+
+	// the discovery of a service does not block the other services
+	compute, err := conn.Compute(context.TODO())
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "https://compute.internal/v2.1/", compute.Endpoint)
+
+	// the callers of the same service wait for the discovery, or for their
+	// context
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	_, err = conn.Service(ctx, "accelerator")
+	th.AssertErrIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	th.AssertNoErr(t, <-done)
+	client, err := conn.Service(context.TODO(), "accelerator")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, accelerator.URL+"/", client.Endpoint)
+}
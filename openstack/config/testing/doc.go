@@ -0,0 +1,2 @@
+// config
+package testing
@@ -0,0 +1,284 @@
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/applicationcredentials"
+)
+
+// rotationSuffixFormat is the format of the timestamp appended to the name of
+// the replacement application credentials.
+const rotationSuffixFormat = "20060102150405.000000"
+
+// rotationDeleteTimeout bounds the deletion of the rotated application
+// credential, once the grace period elapsed.
+const rotationDeleteTimeout = time.Minute
+
+// ApplicationCredentialRotateOpts configures RotateApplicationCredential.
+type ApplicationCredentialRotateOpts struct {
+	// UserID is the ID of the owner of the application credential. Defaults
+	// to the user of the token of the identity client.
+	UserID string
+
+	// ID is the ID of the application credential to rotate. Defaults to the
+	// application credential that the identity client authenticated with.
+	ID string
+
+	// Name is the name of the replacement. Defaults to the name of the
+	// rotated application credential, suffixed with the time of the rotation.
+	Name string
+
+	// Secret is the secret of the replacement. It is generated by Keystone if
+	// empty.
+	Secret string
+
+	// Lifetime is the time the replacement is valid for. The replacement
+	// expires when the rotated application credential does if it is zero.
+	Lifetime time.Duration
+
+	// GracePeriod is the time the rotated application credential is kept
+	// after the rotation, so that its other consumers can switch to the
+	// replacement. It is deleted right away if GracePeriod is zero.
+	GracePeriod time.Duration
+
+	// Persist is called with the replacement, including its secret, once it
+	// was verified and before it is used. The replacement is deleted and the
+	// rotation is aborted if Persist fails.
+	Persist func(ctx context.Context, ac *applicationcredentials.ApplicationCredential) error
+
+	// ProviderClient, if set, is switched to the replacement: it uses a
+	// token of the replacement, and reauthenticates with it.
+	ProviderClient *gophercloud.ProviderClient
+
+	// EndpointOpts locates the identity endpoint used to verify the
+	// replacement, like the EndpointOpts of AuthenticateV3.
+	EndpointOpts gophercloud.EndpointOpts
+}
+
+// ApplicationCredentialRotation is a rotation of an application credential.
+type ApplicationCredentialRotation struct {
+	// Previous is the rotated application credential.
+	Previous *applicationcredentials.ApplicationCredential
+
+	// Current is the replacement, including its secret.
+	Current *applicationcredentials.ApplicationCredential
+
+	done   chan struct{}
+	cancel context.CancelFunc
+	err    error
+}
+
+// Wait blocks until the rotated application credential is deleted, and
+// returns the error of its deletion.
+func (r *ApplicationCredentialRotation) Wait(ctx context.Context) error {
+	select {
+	case <-r.done:
+		return r.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Cancel abandons the deletion of the rotated application credential, if it
+// is still pending. Wait then returns context.Canceled.
+func (r *ApplicationCredentialRotation) Cancel() {
+	r.cancel()
+}
+
+/*
+RotateApplicationCredential replaces an application credential with a new one,
+that delegates the same roles with the same access rules:
+
+ 1. the replacement is created with the identity client;
+ 2. it is verified by authenticating with it;
+ 3. it is passed to the Persist hook, e.g. to save its secret;
+ 4. the ProviderClient of the options, if any, is switched to it;
+ 5. the rotated application credential is deleted once the grace period
+    elapsed, in the background.
+
+The replacement is deleted if a step before the switch fails, and the rotated
+application credential is kept. Since the tokens of a restricted application
+credential cannot manage application credentials, the identity client must be
+authenticated as the user, or with an unrestricted application credential.
+
+The deletion of the rotated application credential outlives ctx, which is
+usually the context of a request, and is bounded by a timeout of one minute
+once the grace period elapsed. Use the Wait method of the rotation to learn
+the outcome of the deletion, and its Cancel method to abandon it.
+
+Example to rotate the application credential of a long-running workload
+
+	rotation, err := openstack.RotateApplicationCredential(ctx, identityClient, openstack.ApplicationCredentialRotateOpts{
+		GracePeriod:    time.Hour,
+		ProviderClient: provider,
+		Persist: func(ctx context.Context, ac *applicationcredentials.ApplicationCredential) error {
+			return saveSecret(ctx, ac.ID, ac.Secret)
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	if err := rotation.Wait(ctx); err != nil {
+		log.Printf("the previous application credential was not deleted: %v", err)
+	}
+*/
+func RotateApplicationCredential(ctx context.Context, client *gophercloud.ServiceClient, opts ApplicationCredentialRotateOpts) (*ApplicationCredentialRotation, error) {
+	userID, id := opts.UserID, opts.ID
+	if userID == "" || id == "" {
+		tokenUserID, tokenAppCredID, err := tokenApplicationCredential(client.ProviderClient)
+		if err != nil {
+			return nil, err
+		}
+		if userID == "" {
+			userID = tokenUserID
+		}
+		if id == "" {
+			id = tokenAppCredID
+		}
+	}
+	if userID == "" {
+		return nil, gophercloud.ErrMissingInput{Argument: "UserID"}
+	}
+	if id == "" {
+		return nil, gophercloud.ErrMissingInput{Argument: "ID"}
+	}
+
+	previous, err := applicationcredentials.Get(ctx, client, userID, id).Extract()
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := applicationcredentials.Create(ctx, client, userID, replacementOpts(previous, opts)).Extract()
+	if err != nil {
+		return nil, err
+	}
+
+	// the throwaway client verifies the replacement, and reauthenticates the
+	// ProviderClient with it
+	provider := opts.ProviderClient
+	if provider == nil {
+		provider = client.ProviderClient
+	}
+	tac := *provider
+	tac.SetThrowaway(true)
+	tac.ReauthFunc = nil
+	err = tac.SetTokenAndAuthResult(nil)
+	if err == nil {
+		err = AuthenticateV3(ctx, &tac, &gophercloud.AuthOptions{
+			ApplicationCredentialID:     current.ID,
+			ApplicationCredentialSecret: current.Secret,
+		}, opts.EndpointOpts)
+	}
+	if err == nil && opts.Persist != nil {
+		err = opts.Persist(ctx, current)
+	}
+	if err != nil {
+		// the replacement is deleted even if ctx was canceled
+		_ = applicationcredentials.Delete(context.WithoutCancel(ctx), client, userID, current.ID).ExtractErr()
+		return nil, err
+	}
+
+	if opts.ProviderClient != nil {
+		authOpts := &gophercloud.AuthOptions{
+			ApplicationCredentialID:     current.ID,
+			ApplicationCredentialSecret: current.Secret,
+		}
+		provider.CopyTokenFrom(&tac)
+		provider.SetReauthFunc(func(ctx context.Context) error {
+			err := AuthenticateV3(ctx, &tac, authOpts, opts.EndpointOpts)
+			if err != nil {
+				return err
+			}
+			provider.CopyTokenFrom(&tac)
+			return nil
+		})
+	}
+
+	// the deletion is not canceled with ctx, only with the Cancel method
+	deleteCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	rotation := &ApplicationCredentialRotation{
+		Previous: previous,
+		Current:  current,
+		done:     make(chan struct{}),
+		cancel:   cancel,
+	}
+	go func() {
+		defer close(rotation.done)
+		defer cancel()
+
+		timer := time.NewTimer(opts.GracePeriod)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			ctx, stop := context.WithTimeout(deleteCtx, rotationDeleteTimeout)
+			defer stop()
+			rotation.err = applicationcredentials.Delete(ctx, client, userID, previous.ID).ExtractErr()
+		case <-deleteCtx.Done():
+			rotation.err = deleteCtx.Err()
+		}
+	}()
+
+	return rotation, nil
+}
+
+// replacementOpts returns the options to create the replacement of an
+// application credential.
+func replacementOpts(previous *applicationcredentials.ApplicationCredential, opts ApplicationCredentialRotateOpts) applicationcredentials.CreateOpts {
+	now := time.Now().UTC()
+
+	name := opts.Name
+	if name == "" {
+		// drop the suffix of a previous rotation
+		name = previous.Name
+		if i := strings.LastIndex(name, "-"); i >= 0 {
+			if _, err := time.Parse(rotationSuffixFormat, name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+		name += "-" + now.Format(rotationSuffixFormat)
+	}
+
+	createOpts := applicationcredentials.CreateOpts{
+		Name:         name,
+		Description:  previous.Description,
+		Unrestricted: previous.Unrestricted,
+		Secret:       opts.Secret,
+		AccessRules:  previous.AccessRules,
+	}
+	for _, role := range previous.Roles {
+		createOpts.Roles = append(createOpts.Roles, applicationcredentials.Role{ID: role.ID})
+	}
+	switch {
+	case opts.Lifetime > 0:
+		expiresAt := now.Add(opts.Lifetime)
+		createOpts.ExpiresAt = &expiresAt
+	case !previous.ExpiresAt.IsZero():
+		createOpts.ExpiresAt = &previous.ExpiresAt
+	}
+	return createOpts
+}
+
+// tokenApplicationCredential returns the IDs of the user and of the
+// application credential of the token of a client.
+func tokenApplicationCredential(client *gophercloud.ProviderClient) (userID, appCredID string, err error) {
+	result, ok := client.GetAuthResult().(interface{ ExtractInto(any) error })
+	if !ok {
+		return "", "", fmt.Errorf("the token of the identity client is not an Identity v3 token")
+	}
+
+	var s struct {
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+		ApplicationCredential struct {
+			ID string `json:"id"`
+		} `json:"application_credential"`
+	}
+	err = result.ExtractInto(&s)
+	return s.User.ID, s.ApplicationCredential.ID, err
+}
@@ -0,0 +1,136 @@
+package authtoken
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+)
+
+// Auth is the authentication of a request: the details of its validated
+// token.
+type Auth struct {
+	// TokenID is the ID of the token.
+	TokenID string
+
+	// ExpiresAt is the expiration time of the token.
+	ExpiresAt time.Time
+
+	// User is the owner of the token.
+	User tokens.User
+
+	// Project is the project of a project-scoped token.
+	Project *tokens.Project
+
+	// Domain is the domain of a domain-scoped token.
+	Domain *tokens.Domain
+
+	// System reports whether the token is system-scoped.
+	System bool
+
+	// Roles are the roles of the user on the scope of the token.
+	Roles []tokens.Role
+
+	// Catalog is the service catalog of the token.
+	Catalog tokens.ServiceCatalog
+}
+
+// HasRole reports whether the token grants a role, compared without regard to
+// case.
+func (a *Auth) HasRole(name string) bool {
+	return slices.ContainsFunc(a.Roles, func(r tokens.Role) bool {
+		return strings.EqualFold(r.Name, name)
+	})
+}
+
+// clone returns a deep copy of the Auth.
+func (a *Auth) clone() *Auth {
+	c := *a
+	if a.Project != nil {
+		project := *a.Project
+		c.Project = &project
+	}
+	if a.Domain != nil {
+		domain := *a.Domain
+		c.Domain = &domain
+	}
+	c.Roles = slices.Clone(a.Roles)
+	c.Catalog.Entries = slices.Clone(a.Catalog.Entries)
+	for i, entry := range c.Catalog.Entries {
+		c.Catalog.Entries[i].Endpoints = slices.Clone(entry.Endpoints)
+	}
+	return &c
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of the context that carries an Auth.
+func NewContext(ctx context.Context, auth *Auth) context.Context {
+	return context.WithValue(ctx, contextKey{}, auth)
+}
+
+// FromContext returns the Auth carried by the context of a request
+// authenticated by the middleware. Each request carries its own copy of the
+// Auth, so changes to it are not seen by the other requests.
+func FromContext(ctx context.Context) (*Auth, bool) {
+	auth, ok := ctx.Value(contextKey{}).(*Auth)
+	return auth, ok
+}
+
+// User returns the user of the token of an authenticated request.
+func User(ctx context.Context) (tokens.User, bool) {
+	auth, ok := FromContext(ctx)
+	if !ok {
+		return tokens.User{}, false
+	}
+	return auth.User, true
+}
+
+// Project returns the project of the token of an authenticated request, if
+// the token is project-scoped.
+func Project(ctx context.Context) (tokens.Project, bool) {
+	auth, ok := FromContext(ctx)
+	if !ok || auth.Project == nil {
+		return tokens.Project{}, false
+	}
+	return *auth.Project, true
+}
+
+// Domain returns the domain of the token of an authenticated request, if the
+// token is domain-scoped. The domain of the project of a project-scoped token
+// is in the Domain field of the project.
+func Domain(ctx context.Context) (tokens.Domain, bool) {
+	auth, ok := FromContext(ctx)
+	if !ok || auth.Domain == nil {
+		return tokens.Domain{}, false
+	}
+	return *auth.Domain, true
+}
+
+// Roles returns the roles of the token of an authenticated request.
+func Roles(ctx context.Context) []tokens.Role {
+	auth, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return slices.Clone(auth.Roles)
+}
+
+// HasRole reports whether the token of an authenticated request grants a
+// role.
+func HasRole(ctx context.Context, name string) bool {
+	auth, ok := FromContext(ctx)
+	return ok && auth.HasRole(name)
+}
+
+// Catalog returns the service catalog of the token of an authenticated
+// request.
+func Catalog(ctx context.Context) (tokens.ServiceCatalog, bool) {
+	auth, ok := FromContext(ctx)
+	if !ok {
+		return tokens.ServiceCatalog{}, false
+	}
+	return auth.Catalog, true
+}
@@ -0,0 +1,59 @@
+/*
+Package authtoken provides an http.Handler middleware for the services that
+accept Keystone tokens, like the auth_token middleware of keystonemiddleware.
+
+The middleware validates the token of the X-Auth-Token header of each request
+with the Identity v3 API, using a ServiceClient authenticated as the service.
+The requests with a missing, invalid, expired, revoked or unscoped token are
+rejected with a 401 response. The user, scope, roles and catalog of the valid
+tokens are added to the context of the request.
+
+Validations are cached, so that Keystone is not queried on every request: a
+token is validated again once CacheTTL elapsed, and never used past its
+expiry. A cached token is also checked with a lightweight HEAD request every
+RevocationCheckInterval (one minute by default), so that revoked tokens are
+rejected sooner than CacheTTL.
+
+Example to protect a handler
+
+	provider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: "https://keystone.example.com:5000/v3",
+		Username:         "myservice",
+		Password:         "secret",
+		DomainName:       "default",
+		TenantName:       "service",
+		AllowReauth:      true,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	identityClient, err := openstack.NewIdentityV3(context.TODO(), provider, gophercloud.EndpointOpts{})
+	if err != nil {
+		panic(err)
+	}
+
+	mw := authtoken.New(identityClient, authtoken.Opts{
+		CacheTTL:                5 * time.Minute,
+		RevocationCheckInterval: 30 * time.Second,
+	})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		project, _ := authtoken.Project(r.Context())
+		if !authtoken.HasRole(r.Context(), "member") {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, "hello %s", project.Name)
+	})
+
+	http.ListenAndServe(":8080", mw.Handler(handler))
+
+Example to validate a token outside of an HTTP handler
+
+	auth, err := mw.Validate(context.TODO(), tokenID)
+	if errors.Is(err, authtoken.ErrInvalidToken) {
+		fmt.Println("the token is invalid")
+	}
+*/
+package authtoken
@@ -0,0 +1,305 @@
+package authtoken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+)
+
+// DefaultCacheTTL is the time a validation is cached when Opts.CacheTTL is
+// not set, like the token_cache_time of keystonemiddleware.
+const DefaultCacheTTL = 5 * time.Minute
+
+// DefaultRevocationCheckInterval is the interval at which the cached valid
+// tokens are checked when Opts.RevocationCheckInterval is not set.
+const DefaultRevocationCheckInterval = time.Minute
+
+// maxCacheEntries is the number of validations kept in the cache.
+const maxCacheEntries = 10000
+
+// cacheEvictionBatch is the number of validations evicted at once, oldest
+// first, when the cache is full of unexpired validations.
+const cacheEvictionBatch = maxCacheEntries / 10
+
+var (
+	// ErrMissingToken is returned when a request carries no token.
+	ErrMissingToken = errors.New("missing token")
+
+	// ErrInvalidToken is returned when Keystone does not accept a token, e.g.
+	// because it was revoked.
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrTokenExpired is returned when a token has expired.
+	ErrTokenExpired = errors.New("token expired")
+
+	// ErrUnscopedToken is returned when a token is neither scoped to a
+	// project, a domain nor the system.
+	ErrUnscopedToken = errors.New("unscoped token")
+)
+
+// Opts configures the middleware.
+type Opts struct {
+	// CacheTTL is the time a validation is cached. Defaults to
+	// DefaultCacheTTL. A token is never accepted past its expiry, even if
+	// its validation is still cached.
+	CacheTTL time.Duration
+
+	// RevocationCheckInterval is the interval at which the cached valid
+	// tokens are checked with a HEAD request, so that revoked tokens are
+	// rejected before their validation leaves the cache. Defaults to
+	// DefaultRevocationCheckInterval. Set it to a negative value to only
+	// check the tokens when their validation leaves the cache.
+	RevocationCheckInterval time.Duration
+
+	// ErrorHandler writes the response to the rejected requests, and to the
+	// requests whose token could not be validated, e.g. because Keystone is
+	// unavailable. The rejected requests are answered with a 401 error, and
+	// the others with a 503 error, by default.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+	// Now returns the current time, used to check the expiry of the tokens
+	// and of the cached validations. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Middleware validates the tokens of the requests of a service. Create it
+// with New.
+type Middleware struct {
+	client *gophercloud.ServiceClient
+	opts   Opts
+
+	mu    sync.Mutex
+	cache map[string]*cacheEntry
+}
+
+// cacheEntry is a cached validation, successful or not.
+type cacheEntry struct {
+	auth      *Auth
+	err       error
+	expiresAt time.Time
+	checkedAt time.Time
+	storedAt  time.Time
+}
+
+// New returns a Middleware that validates the tokens with an Identity v3
+// ServiceClient. The ProviderClient of the ServiceClient must be
+// authenticated, and should be able to reauthenticate.
+func New(client *gophercloud.ServiceClient, opts Opts) *Middleware {
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
+	if opts.RevocationCheckInterval == 0 {
+		opts.RevocationCheckInterval = DefaultRevocationCheckInterval
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = defaultErrorHandler(client.Endpoint)
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return &Middleware{
+		client: client,
+		opts:   opts,
+		cache:  make(map[string]*cacheEntry),
+	}
+}
+
+// Handler wraps a handler, so that it is only called for the requests that
+// carry a valid scoped token in their X-Auth-Token header. The Auth of the
+// token is added to the context of the request.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, err := m.Validate(r.Context(), r.Header.Get("X-Auth-Token"))
+		if err != nil {
+			m.opts.ErrorHandler(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), auth)))
+	})
+}
+
+// Validate validates a token, and returns its Auth. The token is rejected
+// with ErrMissingToken, ErrInvalidToken, ErrTokenExpired or
+// ErrUnscopedToken; other errors mean that the token could not be validated.
+// The returned Auth is a copy that the caller may modify without affecting
+// the later validations of the token.
+func (m *Middleware) Validate(ctx context.Context, tokenID string) (*Auth, error) {
+	if tokenID == "" {
+		return nil, ErrMissingToken
+	}
+
+	now := m.opts.Now()
+	if e, ok := m.cached(tokenID, now); ok {
+		if e.err != nil {
+			return nil, e.err
+		}
+		if m.opts.RevocationCheckInterval < 0 || now.Sub(e.checkedAt) < m.opts.RevocationCheckInterval {
+			return e.auth.clone(), nil
+		}
+
+		valid, err := tokens.Validate(ctx, m.client, tokenID)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			m.store(tokenID, &cacheEntry{err: ErrInvalidToken, expiresAt: e.expiresAt})
+			return nil, ErrInvalidToken
+		}
+		m.mu.Lock()
+		if stored, ok := m.cache[tokenID]; ok && stored.auth == e.auth {
+			stored.checkedAt = now
+		}
+		m.mu.Unlock()
+		return e.auth.clone(), nil
+	}
+
+	auth, err := m.fetch(ctx, tokenID, now)
+	switch {
+	case err == nil:
+		expiresAt := now.Add(m.opts.CacheTTL)
+		if auth.ExpiresAt.Before(expiresAt) {
+			expiresAt = auth.ExpiresAt
+		}
+		m.store(tokenID, &cacheEntry{auth: auth, expiresAt: expiresAt, checkedAt: now})
+	case errors.Is(err, ErrInvalidToken), errors.Is(err, ErrTokenExpired), errors.Is(err, ErrUnscopedToken):
+		// a rejected token is never accepted later
+		m.store(tokenID, &cacheEntry{err: err, expiresAt: now.Add(m.opts.CacheTTL)})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return auth.clone(), nil
+}
+
+// Invalidate removes the validation of a token from the cache, e.g. when the
+// service learns that the token was revoked.
+func (m *Middleware) Invalidate(tokenID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.cache, tokenID)
+}
+
+// cached returns the cached validation of a token, unless it expired.
+func (m *Middleware) cached(tokenID string, now time.Time) (cacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.cache[tokenID]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	if !now.Before(e.expiresAt) {
+		delete(m.cache, tokenID)
+		return cacheEntry{}, false
+	}
+	return *e, true
+}
+
+// store caches a validation. When the cache is full, the expired
+// validations are evicted, and then the oldest ones if needed.
+func (m *Middleware) store(tokenID string, e *cacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.opts.Now()
+	if _, ok := m.cache[tokenID]; !ok && len(m.cache) >= maxCacheEntries {
+		for id, cached := range m.cache {
+			if !now.Before(cached.expiresAt) {
+				delete(m.cache, id)
+			}
+		}
+		if len(m.cache) >= maxCacheEntries {
+			ids := slices.SortedFunc(maps.Keys(m.cache), func(a, b string) int {
+				return m.cache[a].storedAt.Compare(m.cache[b].storedAt)
+			})
+			for _, id := range ids[:cacheEvictionBatch] {
+				delete(m.cache, id)
+			}
+		}
+	}
+	e.storedAt = now
+	m.cache[tokenID] = e
+}
+
+// fetch validates a token with Keystone.
+func (m *Middleware) fetch(ctx context.Context, tokenID string, now time.Time) (*Auth, error) {
+	r := tokens.Get(ctx, m.client, tokenID)
+	if r.Err != nil {
+		if gophercloud.ResponseCodeIs(r.Err, http.StatusNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, r.Err
+	}
+
+	var s struct {
+		ExpiresAt time.Time             `json:"expires_at"`
+		User      tokens.User           `json:"user"`
+		Project   *tokens.Project       `json:"project"`
+		Domain    *tokens.Domain        `json:"domain"`
+		System    map[string]any        `json:"system"`
+		Roles     []tokens.Role         `json:"roles"`
+		Catalog   []tokens.CatalogEntry `json:"catalog"`
+	}
+	if err := r.ExtractInto(&s); err != nil {
+		return nil, err
+	}
+
+	if !now.Before(s.ExpiresAt) {
+		return nil, ErrTokenExpired
+	}
+	if s.Project == nil && s.Domain == nil && len(s.System) == 0 {
+		return nil, ErrUnscopedToken
+	}
+
+	return &Auth{
+		TokenID:   tokenID,
+		ExpiresAt: s.ExpiresAt,
+		User:      s.User,
+		Project:   s.Project,
+		Domain:    s.Domain,
+		System:    len(s.System) > 0,
+		Roles:     s.Roles,
+		Catalog:   tokens.ServiceCatalog{Entries: s.Catalog},
+	}, nil
+}
+
+// defaultErrorHandler answers the rejected requests with a 401 error in the
+// format of Keystone, that points to the identity endpoint, and the other
+// failures with a 503 error.
+func defaultErrorHandler(identityEndpoint string) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		status := http.StatusUnauthorized
+		message := "The request you have made requires authentication."
+		switch {
+		case errors.Is(err, ErrMissingToken):
+		case errors.Is(err, ErrInvalidToken), errors.Is(err, ErrTokenExpired), errors.Is(err, ErrUnscopedToken):
+			message = fmt.Sprintf("The request you have made requires authentication: %s.", err)
+		default:
+			status = http.StatusServiceUnavailable
+			message = "The token could not be validated."
+		}
+
+		if status == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Keystone uri=%q", identityEndpoint))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"error": map[string]any{
+				"code":    status,
+				"title":   http.StatusText(status),
+				"message": message,
+			},
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/authtoken"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/client"
+	"github.com/gophercloud/gophercloud/v2/testhelper/keystone"
+)
+
+type cloud struct {
+	ks      *keystone.Server
+	domain  keystone.Domain
+	project keystone.Project
+	user    keystone.User
+	client  *gophercloud.ServiceClient
+}
+
+func setupCloud(t *testing.T) *cloud {
+	c := &cloud{ks: keystone.Setup()}
+	t.Cleanup(c.ks.Teardown)
+
+	c.domain = c.ks.AddDomain("default")
+	c.project = c.ks.AddProject("demo", c.domain.ID)
+	c.user = c.ks.AddUser("alice", "secret", c.domain.ID)
+	c.ks.AssignProjectRole(c.user.ID, c.project.ID, "member")
+	c.ks.AssignDomainRole(c.user.ID, c.domain.ID, "admin")
+
+	service := c.ks.AddProject("service", c.domain.ID)
+	nova := c.ks.AddUser("nova", "secret", c.domain.ID)
+	c.ks.AssignProjectRole(nova.ID, service.ID, "service")
+
+	provider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.AuthURL(),
+		Username:         "nova",
+		Password:         "secret",
+		DomainName:       "default",
+		TenantName:       "service",
+		AllowReauth:      true,
+	})
+	th.AssertNoErr(t, err)
+	c.client, err = openstack.NewIdentityV3(context.TODO(), provider, gophercloud.EndpointOpts{})
+	th.AssertNoErr(t, err)
+
+	return c
+}
+
+// token returns a token of alice with the given scope.
+func (c *cloud) token(t *testing.T, scope *gophercloud.AuthScope) string {
+	t.Helper()
+
+	provider, err := openstack.NewClient(c.ks.AuthURL())
+	th.AssertNoErr(t, err)
+	err = openstack.AuthenticateV3(context.TODO(), provider, &gophercloud.AuthOptions{
+		Username:   "alice",
+		Password:   "secret",
+		DomainName: "default",
+		Scope:      scope,
+	}, gophercloud.EndpointOpts{})
+	th.AssertNoErr(t, err)
+	return provider.Token()
+}
+
+func (c *cloud) projectToken(t *testing.T) string {
+	return c.token(t, &gophercloud.AuthScope{ProjectID: c.project.ID})
+}
+
+func serve(handler http.Handler, tokenID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/resources", nil)
+	if tokenID != "" {
+		req.Header.Set("X-Auth-Token", tokenID)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprint(w, "ok")
+})
+
+func TestHandler(t *testing.T) {
+	c := setupCloud(t)
+
+	var auth *authtoken.Auth
+	handler := authtoken.New(c.client, authtoken.Opts{}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := authtoken.User(r.Context())
+		th.AssertEquals(t, true, ok)
+		th.AssertEquals(t, c.user.ID, user.ID)
+
+		project, ok := authtoken.Project(r.Context())
+		th.AssertEquals(t, true, ok)
+		th.AssertEquals(t, c.project.ID, project.ID)
+		th.AssertEquals(t, c.domain.ID, project.Domain.ID)
+
+		_, ok = authtoken.Domain(r.Context())
+		th.AssertEquals(t, false, ok)
+
+		roles := authtoken.Roles(r.Context())
+		th.AssertEquals(t, 1, len(roles))
+		th.AssertEquals(t, "member", roles[0].Name)
+		th.AssertEquals(t, true, authtoken.HasRole(r.Context(), "Member"))
+		th.AssertEquals(t, false, authtoken.HasRole(r.Context(), "admin"))
+
+		catalog, ok := authtoken.Catalog(r.Context())
+		th.AssertEquals(t, true, ok)
+		th.AssertEquals(t, "identity", catalog.Entries[0].Type)
+
+		auth, _ = authtoken.FromContext(r.Context())
+		okHandler(w, r)
+	}))
+
+	tokenID := c.projectToken(t)
+	rec := serve(handler, tokenID)
+	th.AssertEquals(t, http.StatusOK, rec.Code)
+	th.AssertEquals(t, tokenID, auth.TokenID)
+	th.AssertEquals(t, false, auth.System)
+}
+
+func TestHandlerDomainScope(t *testing.T) {
+	c := setupCloud(t)
+
+	handler := authtoken.New(c.client, authtoken.Opts{}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		domain, ok := authtoken.Domain(r.Context())
+		th.AssertEquals(t, true, ok)
+		th.AssertEquals(t, c.domain.ID, domain.ID)
+
+		_, ok = authtoken.Project(r.Context())
+		th.AssertEquals(t, false, ok)
+		th.AssertEquals(t, true, authtoken.HasRole(r.Context(), "admin"))
+		okHandler(w, r)
+	}))
+
+	rec := serve(handler, c.token(t, &gophercloud.AuthScope{DomainID: c.domain.ID}))
+	th.AssertEquals(t, http.StatusOK, rec.Code)
+}
+
+func TestHandlerRejections(t *testing.T) {
+	c := setupCloud(t)
+	handler := authtoken.New(c.client, authtoken.Opts{}).Handler(okHandler)
+
+	rec := serve(handler, "")
+	th.AssertEquals(t, http.StatusUnauthorized, rec.Code)
+	th.AssertEquals(t, fmt.Sprintf("Keystone uri=%q", c.client.Endpoint), rec.Header().Get("WWW-Authenticate"))
+
+	rec = serve(handler, "invalid")
+	th.AssertEquals(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serve(handler, c.token(t, nil))
+	th.AssertEquals(t, http.StatusUnauthorized, rec.Code)
+	th.AssertJSONEquals(t, `{
+		"error": {
+			"code": 401,
+			"title": "Unauthorized",
+			"message": "The request you have made requires authentication: unscoped token."
+		}
+	}`, json.RawMessage(rec.Body.Bytes()))
+}
+
+func TestValidate(t *testing.T) {
+	c := setupCloud(t)
+	mw := authtoken.New(c.client, authtoken.Opts{Now: c.ks.Now})
+
+	_, err := mw.Validate(context.TODO(), "")
+	th.AssertEquals(t, true, errors.Is(err, authtoken.ErrMissingToken))
+
+	_, err = mw.Validate(context.TODO(), c.token(t, nil))
+	th.AssertEquals(t, true, errors.Is(err, authtoken.ErrUnscopedToken))
+
+	tokenID := c.projectToken(t)
+	auth, err := mw.Validate(context.TODO(), tokenID)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, c.project.ID, auth.Project.ID)
+
+	// the token is not accepted past its expiry, even though its validation
+	// is cached
+	c.ks.Advance(keystone.DefaultTokenTTL)
+	_, err = mw.Validate(context.TODO(), tokenID)
+	th.AssertEquals(t, true, errors.Is(err, authtoken.ErrInvalidToken))
+}
+
+func TestCache(t *testing.T) {
+	c := setupCloud(t)
+	handler := authtoken.New(c.client, authtoken.Opts{
+		CacheTTL:                10 * time.Minute,
+		RevocationCheckInterval: -1,
+		Now:                     c.ks.Now,
+	}).Handler(okHandler)
+
+	tokenID := c.projectToken(t)
+	th.AssertEquals(t, http.StatusOK, serve(handler, tokenID).Code)
+
+	// without revocation checks, the cached validation is used until it
+	// expires
+	c.ks.RevokeToken(tokenID)
+	th.AssertEquals(t, http.StatusOK, serve(handler, tokenID).Code)
+	c.ks.Advance(9 * time.Minute)
+	th.AssertEquals(t, http.StatusOK, serve(handler, tokenID).Code)
+	c.ks.Advance(time.Minute)
+	th.AssertEquals(t, http.StatusUnauthorized, serve(handler, tokenID).Code)
+}
+
+func TestCachedAuthIsCopied(t *testing.T) {
+	c := setupCloud(t)
+	var roles, projects []string
+	handler := authtoken.New(c.client, authtoken.Opts{
+		RevocationCheckInterval: -1,
+		Now:                     c.ks.Now,
+	}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, _ := authtoken.FromContext(r.Context())
+		roles = append(roles, auth.Roles[0].Name)
+		projects = append(projects, auth.Project.ID)
+
+		// the changes of a handler are not seen by the later requests
+		auth.Roles[0].Name = "admin"
+		auth.Roles = append(auth.Roles, tokens.Role{Name: "reader"})
+		auth.Project.ID = "other"
+		okHandler(w, r)
+	}))
+
+	tokenID := c.projectToken(t)
+	th.AssertEquals(t, http.StatusOK, serve(handler, tokenID).Code)
+	th.AssertEquals(t, http.StatusOK, serve(handler, tokenID).Code)
+	th.AssertDeepEquals(t, []string{"member", "member"}, roles)
+	th.AssertDeepEquals(t, []string{c.project.ID, c.project.ID}, projects)
+}
+
+func TestRevocationCheck(t *testing.T) {
+	c := setupCloud(t)
+	mw := authtoken.New(c.client, authtoken.Opts{
+		RevocationCheckInterval: 30 * time.Second,
+		Now:                     c.ks.Now,
+	})
+	handler := mw.Handler(okHandler)
+
+	tokenID := c.projectToken(t)
+	th.AssertEquals(t, http.StatusOK, serve(handler, tokenID).Code)
+
+	c.ks.RevokeToken(tokenID)
+	th.AssertEquals(t, http.StatusOK, serve(handler, tokenID).Code)
+	c.ks.Advance(30 * time.Second)
+	th.AssertEquals(t, http.StatusUnauthorized, serve(handler, tokenID).Code)
+
+	// the validation of a token can also be dropped explicitly
+	tokenID = c.projectToken(t)
+	th.AssertEquals(t, http.StatusOK, serve(handler, tokenID).Code)
+	c.ks.RevokeToken(tokenID)
+	mw.Invalidate(tokenID)
+	th.AssertEquals(t, http.StatusUnauthorized, serve(handler, tokenID).Code)
+}
+
+func TestDefaultRevocationCheck(t *testing.T) {
+	c := setupCloud(t)
+	handler := authtoken.New(c.client, authtoken.Opts{Now: c.ks.Now}).Handler(okHandler)
+
+	tokenID := c.projectToken(t)
+	th.AssertEquals(t, http.StatusOK, serve(handler, tokenID).Code)
+
+	// a revoked token is rejected well before its validation leaves the cache
+	c.ks.RevokeToken(tokenID)
+	c.ks.Advance(authtoken.DefaultRevocationCheckInterval)
+	th.AssertEquals(t, http.StatusUnauthorized, serve(handler, tokenID).Code)
+}
+
+func TestKeystoneUnavailable(t *testing.T) {
+	c := setupCloud(t)
+
+	var handlerErr error
+	mw := authtoken.New(c.client, authtoken.Opts{})
+	tokenID := c.projectToken(t)
+	c.ks.Teardown()
+
+	rec := serve(mw.Handler(okHandler), tokenID)
+	th.AssertEquals(t, http.StatusServiceUnavailable, rec.Code)
+	th.AssertEquals(t, "", rec.Header().Get("WWW-Authenticate"))
+
+	handler := authtoken.New(c.client, authtoken.Opts{
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			handlerErr = err
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}).Handler(okHandler)
+	th.AssertEquals(t, http.StatusBadGateway, serve(handler, tokenID).Code)
+	th.AssertEquals(t, false, errors.Is(handlerErr, authtoken.ErrInvalidToken))
+}
+
+func TestAuthHasRole(t *testing.T) {
+	auth := &authtoken.Auth{Roles: []tokens.Role{{Name: "reader"}}}
+	th.AssertEquals(t, true, auth.HasRole("READER"))
+	th.AssertEquals(t, false, auth.HasRole("member"))
+	th.AssertEquals(t, false, authtoken.HasRole(context.TODO(), "reader"))
+}
+
+func TestCacheEviction(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	validations := map[string]int{}
+	fakeServer.Mux.HandleFunc("/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		validations[r.Header.Get("X-Subject-Token")]++
+		fmt.Fprintf(w, `{"token": {"expires_at": %q, "project": {"id": "p1"}}}`, now.Add(time.Hour).Format(time.RFC3339))
+	})
+
+	mw := authtoken.New(client.ServiceClient(fakeServer), authtoken.Opts{
+		CacheTTL:                30 * time.Minute,
+		RevocationCheckInterval: -1,
+		Now:                     func() time.Time { return now },
+	})
+	validate := func(tokenID string) {
+		t.Helper()
+		_, err := mw.Validate(context.TODO(), tokenID)
+		th.AssertNoErr(t, err)
+	}
+
+	// fill the cache; the first validations expire before the others
+	validate("short-lived")
+	now = now.Add(20 * time.Minute)
+	for i := range 9999 {
+		validate(fmt.Sprintf("token-%d", i))
+		now = now.Add(time.Millisecond)
+	}
+	validate("token-0")
+	th.AssertEquals(t, 1, validations["token-0"])
+
+	// the expired validation is evicted first
+	now = now.Add(10 * time.Minute)
+	validate("new-1")
+	validate("token-0")
+	th.AssertEquals(t, 1, validations["token-0"])
+
+	// then the oldest ones
+	validate("new-2")
+	validate("token-0")
+	th.AssertEquals(t, 2, validations["token-0"])
+	validate("token-9998")
+	th.AssertEquals(t, 1, validations["token-9998"])
+}
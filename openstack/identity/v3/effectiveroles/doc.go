@@ -0,0 +1,57 @@
+/*
+Package effectiveroles computes the effective roles of a user on the projects
+of the OpenStack Identity service, and explains where each of them comes from.
+
+The roles of a user on a project are the roles assigned to the user or to one
+of its groups on the project, the roles assigned with OS-INHERIT on the domain
+of the project or on one of its ancestors, and the roles they imply. Unlike the
+effective role assignments of Keystone, each role is reported with the
+assignments that grant it.
+
+Example to Get the Effective Roles of a User on a Project and its Subtree
+
+	opts := effectiveroles.Opts{
+		UserID:         "0fe36e73809d46aeae6705c39077b1b3",
+		ProjectID:      "9fe1d3",
+		IncludeSubtree: true,
+	}
+
+	allProjectRoles, err := effectiveroles.Get(context.TODO(), identityClient, opts)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, projectRoles := range allProjectRoles {
+		fmt.Printf("%s: %+v\n", projectRoles.Project.Name, projectRoles.Roles)
+	}
+
+Example to Explain why a User has a Role on a Project
+
+	allProjectRoles, err := effectiveroles.Get(context.TODO(), identityClient, effectiveroles.Opts{
+		UserID:    "0fe36e73809d46aeae6705c39077b1b3",
+		ProjectID: "9fe1d3",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	for _, grant := range allProjectRoles[0].Grants {
+		if grant.Role.Name != "reader" {
+			continue
+		}
+		fmt.Printf("assigned %s on %+v, via group: %t, inherited: %t, implied by: %+v\n",
+			grant.Assignment.Role.Name, grant.Assignment.Scope, grant.ViaGroup(), grant.Inherited, grant.ImpliedBy)
+	}
+
+Example to List the Ancestors of a Project
+
+	ancestors, err := effectiveroles.Ancestors(context.TODO(), identityClient, "9fe1d3")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, project := range ancestors {
+		fmt.Printf("%+v\n", project)
+	}
+*/
+package effectiveroles
@@ -0,0 +1,247 @@
+package effectiveroles
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/groups"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/roles"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+)
+
+// inheritedToProjects is the InheritedTo of the OS-INHERIT assignments.
+const inheritedToProjects = "projects"
+
+// Opts selects the user and the projects to report the effective roles of.
+type Opts struct {
+	// UserID is the ID of the user.
+	UserID string
+
+	// ProjectID is the ID of the project.
+	ProjectID string
+
+	// IncludeSubtree reports the effective roles on the projects of the
+	// subtree of the project too.
+	IncludeSubtree bool
+}
+
+// Get returns the effective roles of a user on a project, and on its subtree
+// if requested, with the provenance of each role: the role assignments of
+// the user and of its groups on the project, the OS-INHERIT assignments on the
+// ancestors and the domain of the project, and the roles they imply.
+//
+// The project is first in the result, followed by its subtree, parents first.
+func Get(ctx context.Context, client *gophercloud.ServiceClient, opts Opts) ([]ProjectRoles, error) {
+	if opts.UserID == "" {
+		return nil, gophercloud.ErrMissingInput{Argument: "UserID"}
+	}
+	if opts.ProjectID == "" {
+		return nil, gophercloud.ErrMissingInput{Argument: "ProjectID"}
+	}
+
+	project, err := projects.Get(ctx, client, opts.ProjectID).Extract()
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := ancestorsOf(ctx, client, project)
+	if err != nil {
+		return nil, err
+	}
+	tree := []projects.Project{*project}
+	if opts.IncludeSubtree {
+		subtree, err := Subtree(ctx, client, project.ID)
+		if err != nil {
+			return nil, err
+		}
+		tree = append(tree, subtree...)
+	}
+
+	// the assignments that apply to the tree are on its projects, their
+	// ancestors and their domains
+	scopeProjectIDs := projectIDs(append(slices.Clone(tree), ancestors...))
+	var scopeDomainIDs []string
+	for _, p := range tree {
+		if !slices.Contains(scopeDomainIDs, p.DomainID) {
+			scopeDomainIDs = append(scopeDomainIDs, p.DomainID)
+		}
+	}
+	assignments, err := userAssignments(ctx, client, opts.UserID, scopeProjectIDs, scopeDomainIDs)
+	if err != nil {
+		return nil, err
+	}
+	implied, err := impliedRoles(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// the ancestors of the projects of the tree, parents first
+	ancestorIDs := map[string][]string{project.ID: projectIDs(ancestors)}
+	result := make([]ProjectRoles, 0, len(tree))
+	for _, p := range tree {
+		if _, ok := ancestorIDs[p.ID]; !ok {
+			ancestorIDs[p.ID] = append([]string{p.ParentID}, ancestorIDs[p.ParentID]...)
+		}
+
+		var grants []Grant
+		for _, a := range assignments {
+			inherited := a.Scope.InheritedTo == inheritedToProjects
+			switch {
+			case !inherited && a.Scope.Project.ID == p.ID:
+			case inherited && a.Scope.Project.ID != "" && slices.Contains(ancestorIDs[p.ID], a.Scope.Project.ID):
+			case inherited && a.Scope.Domain.ID != "" && a.Scope.Domain.ID == p.DomainID:
+			default:
+				continue
+			}
+			grants = append(grants, Grant{Role: a.Role, Assignment: a, Inherited: inherited})
+			grants = append(grants, impliedGrants(a, inherited, implied)...)
+		}
+		result = append(result, ProjectRoles{
+			Project: p,
+			Roles:   rolesOf(grants),
+			Grants:  grants,
+		})
+	}
+	return result, nil
+}
+
+// Ancestors returns the ancestors of a project, from its parent to the top
+// level project of its domain.
+func Ancestors(ctx context.Context, client *gophercloud.ServiceClient, projectID string) ([]projects.Project, error) {
+	project, err := projects.Get(ctx, client, projectID).Extract()
+	if err != nil {
+		return nil, err
+	}
+	return ancestorsOf(ctx, client, project)
+}
+
+func ancestorsOf(ctx context.Context, client *gophercloud.ServiceClient, project *projects.Project) ([]projects.Project, error) {
+	var ancestors []projects.Project
+	seen := map[string]bool{project.ID: true}
+	// the parent of the top level projects is their domain
+	for p := project; p.ParentID != "" && p.ParentID != p.DomainID && !seen[p.ParentID]; {
+		parent, err := projects.Get(ctx, client, p.ParentID).Extract()
+		if err != nil {
+			return nil, err
+		}
+		seen[parent.ID] = true
+		ancestors = append(ancestors, *parent)
+		p = parent
+	}
+	return ancestors, nil
+}
+
+// Subtree returns the projects of the subtree of a project, excluding the
+// project itself. Parents come before their children.
+func Subtree(ctx context.Context, client *gophercloud.ServiceClient, projectID string) ([]projects.Project, error) {
+	var subtree []projects.Project
+	seen := map[string]bool{projectID: true}
+	for queue := []string{projectID}; len(queue) > 0; queue = queue[1:] {
+		pager := projects.List(client, projects.ListOpts{ParentID: queue[0]})
+		for child, err := range pagination.Items(ctx, pager, projects.ExtractProjects) {
+			if err != nil {
+				return nil, err
+			}
+			if seen[child.ID] {
+				continue
+			}
+			seen[child.ID] = true
+			subtree = append(subtree, child)
+			queue = append(queue, child.ID)
+		}
+	}
+	return subtree, nil
+}
+
+// userAssignments returns the role assignments of a user and of its groups on
+// the given projects and domains. The assignments of each actor are listed
+// once, and filtered by scope on the client.
+func userAssignments(ctx context.Context, client *gophercloud.ServiceClient, userID string, projectIDs, domainIDs []string) ([]roles.RoleAssignment, error) {
+	includeNames := true
+	opts := []roles.ListAssignmentsOpts{{UserID: userID, IncludeNames: &includeNames}}
+	userGroups := map[string]groups.Group{}
+	for group, err := range pagination.Items(ctx, users.ListGroups(client, userID), groups.ExtractGroups) {
+		if err != nil {
+			return nil, err
+		}
+		userGroups[group.ID] = group
+		opts = append(opts, roles.ListAssignmentsOpts{GroupID: group.ID, IncludeNames: &includeNames})
+	}
+
+	var assignments []roles.RoleAssignment
+	for _, o := range opts {
+		for a, err := range pagination.Items(ctx, roles.ListAssignments(client, o), roles.ExtractRoleAssignments) {
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(projectIDs, a.Scope.Project.ID) && !slices.Contains(domainIDs, a.Scope.Domain.ID) {
+				continue
+			}
+			if g, ok := userGroups[a.Group.ID]; ok && a.Group.Name == "" {
+				a.Group.Name = g.Name
+			}
+			assignments = append(assignments, a)
+		}
+	}
+	return assignments, nil
+}
+
+// impliedRoles returns the roles directly implied by each role, by ID.
+func impliedRoles(ctx context.Context, client *gophercloud.ServiceClient) (map[string][]roles.AssignedRole, error) {
+	rules, err := roles.ListRoleInferenceRules(ctx, client).Extract()
+	if err != nil {
+		return nil, err
+	}
+
+	implied := make(map[string][]roles.AssignedRole)
+	for _, rule := range rules.RoleInferenceRuleList {
+		for _, i := range rule.ImpliedRoles {
+			implied[rule.PriorRole.ID] = append(implied[rule.PriorRole.ID], roles.AssignedRole{ID: i.ID, Name: i.Name})
+		}
+	}
+	return implied, nil
+}
+
+// impliedGrants returns the grants of the roles implied by the role of an
+// assignment, recursively.
+func impliedGrants(a roles.RoleAssignment, inherited bool, implied map[string][]roles.AssignedRole) []Grant {
+	var grants []Grant
+	var walk func(role roles.AssignedRole, chain []roles.AssignedRole)
+	walk = func(role roles.AssignedRole, chain []roles.AssignedRole) {
+		chain = append(slices.Clip(chain), role)
+		for _, i := range implied[role.ID] {
+			if slices.ContainsFunc(chain, func(r roles.AssignedRole) bool { return r.ID == i.ID }) {
+				continue
+			}
+			grants = append(grants, Grant{Role: i, Assignment: a, Inherited: inherited, ImpliedBy: chain})
+			walk(i, chain)
+		}
+	}
+	walk(a.Role, nil)
+	return grants
+}
+
+// rolesOf returns the distinct roles of grants, sorted by name.
+func rolesOf(grants []Grant) []roles.AssignedRole {
+	var result []roles.AssignedRole
+	for _, g := range grants {
+		if !slices.ContainsFunc(result, func(r roles.AssignedRole) bool { return r.ID == g.Role.ID }) {
+			result = append(result, g.Role)
+		}
+	}
+	slices.SortFunc(result, func(a, b roles.AssignedRole) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+func projectIDs(ps []projects.Project) []string {
+	ids := make([]string, 0, len(ps))
+	for _, p := range ps {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
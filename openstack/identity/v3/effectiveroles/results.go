@@ -0,0 +1,55 @@
+package effectiveroles
+
+import (
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/roles"
+)
+
+// ProjectRoles are the effective roles of a user on a project.
+type ProjectRoles struct {
+	// Project is the project.
+	Project projects.Project
+
+	// Roles are the effective roles of the user on the project, sorted by
+	// name.
+	Roles []roles.AssignedRole
+
+	// Grants are the provenance of the roles: a role granted several ways
+	// has several grants.
+	Grants []Grant
+}
+
+// HasRole reports whether the user has a role on the project, by name.
+func (r ProjectRoles) HasRole(name string) bool {
+	for _, role := range r.Roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Grant is a way a role is granted to a user on a project.
+type Grant struct {
+	// Role is the granted role.
+	Role roles.AssignedRole
+
+	// Assignment is the role assignment the role comes from. Its role is the
+	// role that implies Role when the role is implied. Its group is set when
+	// the role is granted to a group of the user.
+	Assignment roles.RoleAssignment
+
+	// Inherited reports whether the assignment is an OS-INHERIT assignment
+	// on the domain of the project or on one of its ancestors.
+	Inherited bool
+
+	// ImpliedBy are the roles that imply Role, from the assigned role to
+	// the role that implies Role directly. It is empty when Role is
+	// assigned.
+	ImpliedBy []roles.AssignedRole
+}
+
+// ViaGroup reports whether the role is granted to a group of the user.
+func (g Grant) ViaGroup() bool {
+	return g.Assignment.Group.ID != ""
+}
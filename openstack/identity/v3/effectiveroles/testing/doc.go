@@ -0,0 +1,2 @@
+// effectiveroles unit tests
+package testing
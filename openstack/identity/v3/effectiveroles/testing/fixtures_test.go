@@ -0,0 +1,192 @@
+package testing
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/client"
+)
+
+// The fixtures are a domain d1 with the projects p-root, its child p-team and
+// the child of p-team p-app, and a user u1 member of a group g1.
+
+// ProjectOutputs provides the results of Get requests of the projects.
+var ProjectOutputs = map[string]string{
+	"p-root": `{"project": {"id": "p-root", "name": "root", "domain_id": "d1", "parent_id": "d1", "enabled": true}}`,
+	"p-team": `{"project": {"id": "p-team", "name": "team", "domain_id": "d1", "parent_id": "p-root", "enabled": true}}`,
+	"p-app":  `{"project": {"id": "p-app", "name": "app", "domain_id": "d1", "parent_id": "p-team", "enabled": true}}`,
+}
+
+// ChildrenOutputs provides the results of List requests of the projects,
+// filtered by parent.
+var ChildrenOutputs = map[string]string{
+	"p-root": `{"projects": [{"id": "p-team", "name": "team", "domain_id": "d1", "parent_id": "p-root", "enabled": true}], "links": {"next": null}}`,
+	"p-team": `{"projects": [{"id": "p-app", "name": "app", "domain_id": "d1", "parent_id": "p-team", "enabled": true}], "links": {"next": null}}`,
+	"p-app":  `{"projects": [], "links": {"next": null}}`,
+}
+
+// ListGroupsOutput provides the groups of u1.
+const ListGroupsOutput = `
+{
+    "groups": [
+        {
+            "id": "g1",
+            "name": "developers",
+            "domain_id": "d1"
+        }
+    ],
+    "links": {
+        "next": null
+    }
+}
+`
+
+// RoleAssignmentsOutputs provides the results of List requests of the role
+// assignments, by query: member on p-team, reader inherited from p-root, admin
+// on d1, which does not apply to the projects of d1, and admin on p-other of
+// d2 for u1, and creator inherited from d1 and reader inherited from d2 for
+// g1.
+var RoleAssignmentsOutputs = map[string]string{
+	"include_names=true&user.id=u1": `
+{
+    "role_assignments": [
+        {
+            "role": {"id": "r-member", "name": "member"},
+            "scope": {"project": {"id": "p-team", "name": "team"}},
+            "user": {"id": "u1", "name": "alice"}
+        },
+        {
+            "role": {"id": "r-reader", "name": "reader"},
+            "scope": {"project": {"id": "p-root", "name": "root"}, "OS-INHERIT:inherited_to": "projects"},
+            "user": {"id": "u1", "name": "alice"}
+        },
+        {
+            "role": {"id": "r-admin", "name": "admin"},
+            "scope": {"domain": {"id": "d1", "name": "d1"}},
+            "user": {"id": "u1", "name": "alice"}
+        },
+        {
+            "role": {"id": "r-admin", "name": "admin"},
+            "scope": {"project": {"id": "p-other", "name": "other"}},
+            "user": {"id": "u1", "name": "alice"}
+        }
+    ],
+    "links": {
+        "next": null
+    }
+}
+`,
+	"group.id=g1&include_names=true": `
+{
+    "role_assignments": [
+        {
+            "role": {"id": "r-creator", "name": "creator"},
+            "scope": {"domain": {"id": "d1", "name": "d1"}, "OS-INHERIT:inherited_to": "projects"},
+            "group": {"id": "g1"}
+        },
+        {
+            "role": {"id": "r-reader", "name": "reader"},
+            "scope": {"domain": {"id": "d2", "name": "d2"}, "OS-INHERIT:inherited_to": "projects"},
+            "group": {"id": "g1"}
+        }
+    ],
+    "links": {
+        "next": null
+    }
+}
+`,
+}
+
+// RoleInferencesOutput provides the role inference rules: member implies
+// reader, creator implies observer, and observer implies creator.
+const RoleInferencesOutput = `
+{
+    "role_inferences": [
+        {
+            "prior_role": {"id": "r-member", "name": "member"},
+            "implies": [{"id": "r-reader", "name": "reader"}]
+        },
+        {
+            "prior_role": {"id": "r-creator", "name": "creator"},
+            "implies": [{"id": "r-observer", "name": "observer"}]
+        },
+        {
+            "prior_role": {"id": "r-observer", "name": "observer"},
+            "implies": [{"id": "r-creator", "name": "creator"}]
+        }
+    ],
+    "links": {
+        "self": "http://example.com/identity/v3/role_inferences"
+    }
+}
+`
+
+// HandleEffectiveRolesSuccessfully creates an HTTP handler at `/projects`,
+// `/users/u1/groups`, `/role_assignments` and `/role_inferences` on the test
+// handler mux that responds with the fixtures.
+func HandleEffectiveRolesSuccessfully(t *testing.T, fakeServer th.FakeServer) {
+	fakeServer.Mux.HandleFunc("/projects/", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", client.TokenID)
+
+		output, ok := ProjectOutputs[r.URL.Path[len("/projects/"):]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, output)
+	})
+
+	fakeServer.Mux.HandleFunc("/projects", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", client.TokenID)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ChildrenOutputs[r.URL.Query().Get("parent_id")])
+	})
+
+	fakeServer.Mux.HandleFunc("/users/u1/groups", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", client.TokenID)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ListGroupsOutput)
+	})
+
+	listed := map[string]bool{}
+	fakeServer.Mux.HandleFunc("/role_assignments", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", client.TokenID)
+
+		// the assignments of each actor must be listed once
+		if listed[r.URL.RawQuery] {
+			t.Errorf("role assignments listed twice: %s", r.URL.RawQuery)
+		}
+		listed[r.URL.RawQuery] = true
+		output, ok := RoleAssignmentsOutputs[r.URL.RawQuery]
+		if !ok {
+			t.Errorf("unexpected role assignments query: %s", r.URL.RawQuery)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, output)
+	})
+
+	fakeServer.Mux.HandleFunc("/role_inferences", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", client.TokenID)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, RoleInferencesOutput)
+	})
+}
@@ -0,0 +1,132 @@
+package testing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/effectiveroles"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/roles"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/client"
+)
+
+func roleNames(rs []roles.AssignedRole) []string {
+	names := make([]string, 0, len(rs))
+	for _, r := range rs {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func projectNames(ps []projects.Project) []string {
+	names := make([]string, 0, len(ps))
+	for _, p := range ps {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestGet(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	HandleEffectiveRolesSuccessfully(t, fakeServer)
+
+	actual, err := effectiveroles.Get(context.TODO(), client.ServiceClient(fakeServer), effectiveroles.Opts{
+		UserID:    "u1",
+		ProjectID: "p-team",
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, len(actual))
+	th.AssertEquals(t, "p-team", actual[0].Project.ID)
+	th.AssertDeepEquals(t, []string{"creator", "member", "observer", "reader"}, roleNames(actual[0].Roles))
+	th.AssertEquals(t, true, actual[0].HasRole("member"))
+	th.AssertEquals(t, false, actual[0].HasRole("admin"))
+
+	// reader is granted by the inherited assignment on p-root, and implied
+	// by member
+	var readerGrants []effectiveroles.Grant
+	for _, g := range actual[0].Grants {
+		if g.Role.Name == "reader" {
+			readerGrants = append(readerGrants, g)
+		}
+	}
+	th.AssertEquals(t, 2, len(readerGrants))
+	th.AssertEquals(t, "member", readerGrants[0].Assignment.Role.Name)
+	th.AssertEquals(t, false, readerGrants[0].Inherited)
+	th.AssertDeepEquals(t, []string{"member"}, roleNames(readerGrants[0].ImpliedBy))
+	th.AssertEquals(t, "p-root", readerGrants[1].Assignment.Scope.Project.ID)
+	th.AssertEquals(t, true, readerGrants[1].Inherited)
+	th.AssertEquals(t, 0, len(readerGrants[1].ImpliedBy))
+
+	// creator and observer imply each other: each is granted once
+	var groupGrants []effectiveroles.Grant
+	for _, g := range actual[0].Grants {
+		if g.ViaGroup() {
+			groupGrants = append(groupGrants, g)
+		}
+	}
+	th.AssertEquals(t, 2, len(groupGrants))
+	th.AssertEquals(t, "creator", groupGrants[0].Role.Name)
+	th.AssertEquals(t, "developers", groupGrants[0].Assignment.Group.Name)
+	th.AssertEquals(t, true, groupGrants[0].Inherited)
+	th.AssertEquals(t, "observer", groupGrants[1].Role.Name)
+	th.AssertDeepEquals(t, []string{"creator"}, roleNames(groupGrants[1].ImpliedBy))
+}
+
+func TestGetIncludeSubtree(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	HandleEffectiveRolesSuccessfully(t, fakeServer)
+
+	actual, err := effectiveroles.Get(context.TODO(), client.ServiceClient(fakeServer), effectiveroles.Opts{
+		UserID:         "u1",
+		ProjectID:      "p-root",
+		IncludeSubtree: true,
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 3, len(actual))
+
+	// the assignment on p-root is inherited by its subtree only
+	th.AssertEquals(t, "p-root", actual[0].Project.ID)
+	th.AssertDeepEquals(t, []string{"creator", "observer"}, roleNames(actual[0].Roles))
+	th.AssertEquals(t, "p-team", actual[1].Project.ID)
+	th.AssertDeepEquals(t, []string{"creator", "member", "observer", "reader"}, roleNames(actual[1].Roles))
+	th.AssertEquals(t, "p-app", actual[2].Project.ID)
+	th.AssertDeepEquals(t, []string{"creator", "observer", "reader"}, roleNames(actual[2].Roles))
+}
+
+func TestGetMissingInput(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	_, err := effectiveroles.Get(context.TODO(), client.ServiceClient(fakeServer), effectiveroles.Opts{ProjectID: "p-team"})
+	th.AssertDeepEquals(t, gophercloud.ErrMissingInput{Argument: "UserID"}, err)
+
+	_, err = effectiveroles.Get(context.TODO(), client.ServiceClient(fakeServer), effectiveroles.Opts{UserID: "u1"})
+	th.AssertDeepEquals(t, gophercloud.ErrMissingInput{Argument: "ProjectID"}, err)
+}
+
+func TestAncestors(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	HandleEffectiveRolesSuccessfully(t, fakeServer)
+
+	actual, err := effectiveroles.Ancestors(context.TODO(), client.ServiceClient(fakeServer), "p-app")
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, []string{"team", "root"}, projectNames(actual))
+
+	_, err = effectiveroles.Ancestors(context.TODO(), client.ServiceClient(fakeServer), "unknown")
+	th.AssertEquals(t, true, gophercloud.ResponseCodeIs(err, 404))
+}
+
+func TestSubtree(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	HandleEffectiveRolesSuccessfully(t, fakeServer)
+
+	actual, err := effectiveroles.Subtree(context.TODO(), client.ServiceClient(fakeServer), "p-root")
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, []string{"team", "app"}, projectNames(actual))
+}
@@ -0,0 +1,56 @@
+/*
+Package k2k provides the Keystone to Keystone (K2K) federated authentication,
+with the flow of the Keystone2Keystone plugin of keystoneauth.
+
+The local Keystone, acting as a SAML2 identity provider, issues an ECP
+assertion for one of its service providers in exchange for a token. The
+assertion is presented to the service provider endpoint of the remote
+Keystone, which answers with a session that is exchanged for an unscoped
+token at the auth_url of the service provider. That token is finally rescoped
+to the requested project or domain of the remote cloud.
+
+Example to authenticate with a remote cloud
+
+	localProvider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: "https://keystone.example.com:5000/v3",
+		Username:         "alice",
+		Password:         "secret",
+		DomainName:       "default",
+		TenantName:       "demo",
+		AllowReauth:      true,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	localIdentity, err := openstack.NewIdentityV3(context.TODO(), localProvider, gophercloud.EndpointOpts{})
+	if err != nil {
+		panic(err)
+	}
+
+	remoteProvider, err := openstack.AuthenticatedClientK2K(context.TODO(), &k2k.AuthOptions{
+		IdentityClient:  localIdentity,
+		ServiceProvider: "remote-cloud",
+		Scope: tokens.Scope{
+			ProjectName: "shared",
+			DomainName:  "federated",
+		},
+		AllowReauth: true,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+When AllowReauth is set, the remote ProviderClient obtains a new assertion
+when its token expires. The local ProviderClient must itself be able to
+reauthenticate, so that the remote tokens can still be renewed after the
+local token expired.
+
+Example to get the ECP assertion of a service provider
+
+	assertion, err := k2k.GetECPAssertion(context.TODO(), localIdentity, "remote-cloud").Extract()
+	if err != nil {
+		panic(err)
+	}
+*/
+package k2k
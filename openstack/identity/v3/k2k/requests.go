@@ -0,0 +1,239 @@
+package k2k
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+)
+
+// ecpContentType is the media type of the SAML2 ECP messages.
+const ecpContentType = "application/vnd.paos+xml"
+
+// AuthOptions represents options for authenticating with a remote Keystone,
+// registered as a service provider of the local Keystone.
+type AuthOptions struct {
+	// IdentityClient is the Identity v3 client of the local Keystone. The
+	// token of its ProviderClient is exchanged for the assertion.
+	IdentityClient *gophercloud.ServiceClient
+
+	// ServiceProvider is the ID of the service provider of the remote
+	// Keystone in the local Keystone.
+	ServiceProvider string
+
+	// Scope is the scope of the token on the remote Keystone. The unscoped
+	// federated token issued by the remote Keystone is rescoped to it, unless
+	// it is empty.
+	Scope tokens.Scope
+
+	// AllowReauth should be set to true if you grant permission for
+	// Gophercloud to attempt to obtain a new assertion automatically if/when
+	// the remote token expires.
+	AllowReauth bool
+}
+
+// ToTokenV3CreateMap allows AuthOptions to satisfy the AuthOptionsBuilder
+// interface in the v3 tokens package. The federated authentication does not
+// use the token creation request: use Create instead of tokens.Create.
+func (opts *AuthOptions) ToTokenV3CreateMap(map[string]any) (map[string]any, error) {
+	return nil, fmt.Errorf("K2K authentication must use k2k.Create")
+}
+
+// ToTokenV3ScopeMap builds a scope request body from AuthOptions.
+func (opts *AuthOptions) ToTokenV3ScopeMap() (map[string]any, error) {
+	scope := gophercloud.AuthScope(opts.Scope)
+	return (&gophercloud.AuthOptions{Scope: &scope}).ToTokenV3ScopeMap()
+}
+
+// ToTokenV3HeadersMap allows AuthOptions to satisfy the AuthOptionsBuilder
+// interface in the v3 tokens package.
+func (opts *AuthOptions) ToTokenV3HeadersMap(map[string]any) (map[string]string, error) {
+	return nil, nil
+}
+
+// CanReauth reports whether a new token can be obtained with the same
+// options.
+func (opts *AuthOptions) CanReauth() bool {
+	return opts.AllowReauth
+}
+
+// Create obtains an assertion for the service provider from the local
+// Keystone, exchanges it for an unscoped token of the remote Keystone, and
+// rescopes that token to the scope of the options, if any. The ServiceClient
+// is the Identity v3 client of the remote Keystone.
+func Create(ctx context.Context, c *gophercloud.ServiceClient, opts *AuthOptions) (r tokens.CreateResult) {
+	if opts.IdentityClient == nil {
+		r.Err = gophercloud.ErrMissingInput{Argument: "IdentityClient"}
+		return
+	}
+	if opts.ServiceProvider == "" {
+		r.Err = gophercloud.ErrMissingInput{Argument: "ServiceProvider"}
+		return
+	}
+
+	sp, err := GetServiceProvider(ctx, opts.IdentityClient, opts.ServiceProvider).Extract()
+	if err != nil {
+		r.Err = err
+		return
+	}
+
+	assertion, err := GetECPAssertion(ctx, opts.IdentityClient, opts.ServiceProvider).Extract()
+	if err != nil {
+		r.Err = err
+		return
+	}
+
+	r = Authenticate(ctx, c, sp, assertion)
+	if r.Err != nil || opts.Scope == (tokens.Scope{}) {
+		return
+	}
+
+	unscopedTokenID, err := r.ExtractTokenID()
+	if err != nil {
+		r.Err = err
+		return
+	}
+
+	return tokens.Create(ctx, c, &tokens.AuthOptions{
+		TokenID: unscopedTokenID,
+		Scope:   opts.Scope,
+	})
+}
+
+// GetServiceProvider retrieves a service provider of the local Keystone.
+func GetServiceProvider(ctx context.Context, client *gophercloud.ServiceClient, id string) (r GetServiceProviderResult) {
+	resp, err := client.Get(ctx, serviceProviderURL(client, id), &r.Body, nil)
+	_, r.Header, r.Err = gophercloud.ParseResponse(resp, err)
+	return
+}
+
+// GetECPAssertion exchanges the token of the ProviderClient for a SAML2 ECP
+// assertion of the local Keystone for a service provider.
+func GetECPAssertion(ctx context.Context, client *gophercloud.ServiceClient, serviceProvider string) (r ECPAssertionResult) {
+	tokenID := client.Token()
+	r = getECPAssertion(ctx, client, tokenID, serviceProvider)
+	if gophercloud.ResponseCodeIs(r.Err, http.StatusUnauthorized) && client.Token() != tokenID {
+		// the ProviderClient reauthenticated, but the request was retried
+		// with the rejected token in its body
+		r = getECPAssertion(ctx, client, client.Token(), serviceProvider)
+	}
+	return
+}
+
+func getECPAssertion(ctx context.Context, client *gophercloud.ServiceClient, tokenID, serviceProvider string) (r ECPAssertionResult) {
+	b := map[string]any{
+		"auth": map[string]any{
+			"identity": map[string]any{
+				"methods": []string{"token"},
+				"token": map[string]any{
+					"id": tokenID,
+				},
+			},
+			"scope": map[string]any{
+				"service_provider": map[string]any{
+					"id": serviceProvider,
+				},
+			},
+		},
+	}
+
+	resp, err := client.Post(ctx, ecpAssertionURL(client), b, nil, &gophercloud.RequestOpts{
+		MoreHeaders:      map[string]string{"Accept": ecpContentType},
+		OmitHeaders:      []string{"X-Auth-Token"},
+		OkCodes:          []int{200},
+		KeepResponseBody: true,
+	})
+	body, header, err := gophercloud.ParseResponse(resp, err)
+	r.Header, r.Err = header, err
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	r.Body, r.Err = io.ReadAll(body)
+	return
+}
+
+// Authenticate presents an assertion to the service provider endpoint of a
+// remote Keystone, and exchanges the session it establishes for an unscoped
+// token at the AuthURL of the service provider. The ServiceClient is the
+// Identity v3 client of the remote Keystone.
+func Authenticate(ctx context.Context, c *gophercloud.ServiceClient, sp *ServiceProvider, assertion []byte) (r tokens.CreateResult) {
+	if sp.SPURL == "" {
+		r.Err = gophercloud.ErrMissingInput{Argument: "SPURL"}
+		return
+	}
+	if sp.AuthURL == "" {
+		r.Err = gophercloud.ErrMissingInput{Argument: "AuthURL"}
+		return
+	}
+
+	cookies, err := sendAssertion(ctx, c.ProviderClient, sp.SPURL, assertion)
+	if err != nil {
+		r.Err = err
+		return
+	}
+
+	resp, err := c.Get(ctx, sp.AuthURL, &r.Body, &gophercloud.RequestOpts{
+		MoreHeaders: map[string]string{"Cookie": cookies},
+		OmitHeaders: []string{"X-Auth-Token"},
+		OkCodes:     []int{200, 201},
+	})
+	_, r.Header, r.Err = gophercloud.ParseResponse(resp, err)
+	return
+}
+
+// sendAssertion posts an assertion to the service provider endpoint, and
+// returns the cookies of the session it establishes, as the value of a
+// Cookie header. The redirection to the AuthURL that usually follows is not
+// followed.
+func sendAssertion(ctx context.Context, client *gophercloud.ProviderClient, spURL string, assertion []byte) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, spURL, bytes.NewReader(assertion))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", ecpContentType)
+
+	httpClient := client.HTTPClient
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusFound, http.StatusSeeOther:
+	default:
+		return "", gophercloud.ErrUnexpectedResponseCode{
+			URL:            spURL,
+			Method:         req.Method,
+			Expected:       []int{http.StatusOK, http.StatusFound, http.StatusSeeOther},
+			Actual:         resp.StatusCode,
+			Body:           body,
+			ResponseHeader: resp.Header,
+		}
+	}
+
+	var cookies []string
+	for _, cookie := range resp.Cookies() {
+		cookies = append(cookies, cookie.Name+"="+cookie.Value)
+	}
+	if len(cookies) == 0 {
+		return "", fmt.Errorf("the service provider at %s did not establish a session", spURL)
+	}
+	return strings.Join(cookies, "; "), nil
+}
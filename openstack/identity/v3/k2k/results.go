@@ -0,0 +1,67 @@
+package k2k
+
+import (
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// ServiceProvider is a remote Keystone registered as a service provider of
+// the local Keystone.
+type ServiceProvider struct {
+	// ID is the ID of the service provider.
+	ID string `json:"id"`
+
+	// AuthURL is the federated authentication endpoint of the remote
+	// Keystone, where the session established with the assertion is
+	// exchanged for a token.
+	AuthURL string `json:"auth_url"`
+
+	// SPURL is the SAML2 ECP endpoint of the remote Keystone, where the
+	// assertion is presented.
+	SPURL string `json:"sp_url"`
+
+	// Description is the description of the service provider.
+	Description string `json:"description"`
+
+	// Enabled reports whether assertions can be issued for the service
+	// provider.
+	Enabled bool `json:"enabled"`
+
+	// RelayStatePrefix is the prefix of the RelayState of the assertions.
+	RelayStatePrefix string `json:"relay_state_prefix"`
+}
+
+// IdentityEndpoint returns the Identity v3 endpoint of the remote Keystone,
+// derived from the AuthURL of the service provider.
+func (sp ServiceProvider) IdentityEndpoint() string {
+	endpoint, _, _ := strings.Cut(sp.AuthURL, "/OS-FEDERATION/")
+	return gophercloud.NormalizeURL(endpoint)
+}
+
+// GetServiceProviderResult is the response from a GetServiceProvider
+// operation. Call its Extract method to interpret it as a ServiceProvider.
+type GetServiceProviderResult struct {
+	gophercloud.Result
+}
+
+// Extract interprets a GetServiceProviderResult as a ServiceProvider.
+func (r GetServiceProviderResult) Extract() (*ServiceProvider, error) {
+	var s struct {
+		ServiceProvider *ServiceProvider `json:"service_provider"`
+	}
+	err := r.ExtractInto(&s)
+	return s.ServiceProvider, err
+}
+
+// ECPAssertionResult is the response from a GetECPAssertion operation. Call
+// its Extract method to get the assertion.
+type ECPAssertionResult struct {
+	gophercloud.HeaderResult
+	Body []byte
+}
+
+// Extract returns the SOAP envelope of the ECP assertion.
+func (r ECPAssertionResult) Extract() ([]byte, error) {
+	return r.Body, r.Err
+}
@@ -0,0 +1,205 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/k2k"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/keystone"
+)
+
+type clouds struct {
+	local       *keystone.Server
+	remote      *keystone.Server
+	compute     th.FakeServer
+	localUser   keystone.User
+	remoteUser  keystone.User
+	shared      keystone.Project
+	localClient *gophercloud.ServiceClient
+}
+
+// setupClouds starts a local Keystone, where alice is authenticated, and a
+// remote Keystone registered as its service provider, where alice is mapped
+// to a federated user.
+func setupClouds(t *testing.T) *clouds {
+	c := &clouds{
+		local:  keystone.Setup(),
+		remote: keystone.Setup(),
+	}
+	t.Cleanup(c.local.Teardown)
+	t.Cleanup(c.remote.Teardown)
+
+	localDomain := c.local.AddDomain("default")
+	demo := c.local.AddProject("demo", localDomain.ID)
+	c.localUser = c.local.AddUser("alice", "secret", localDomain.ID)
+	c.local.AssignProjectRole(c.localUser.ID, demo.ID, "member")
+
+	remoteDomain := c.remote.AddDomain("federated")
+	c.shared = c.remote.AddProject("shared", remoteDomain.ID)
+	c.remoteUser = c.remote.AddUser("alice", "", remoteDomain.ID)
+	c.remote.AssignProjectRole(c.remoteUser.ID, c.shared.ID, "member")
+
+	c.local.AddServiceProvider("remote", c.remote.FederatedAuthURL("local", "saml2"), c.remote.ECPURL())
+	c.remote.AddSAML2Protocol("local", c.local.AssertionValidator(map[string]string{
+		c.localUser.ID: c.remoteUser.ID,
+	}))
+
+	c.compute = th.SetupHTTP()
+	t.Cleanup(c.compute.Teardown)
+	c.compute.Mux.Handle("/servers", c.remote.RequireToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"servers": []}`)
+	})))
+	c.remote.RegisterService("compute", "nova", keystone.PublicEndpoint(c.compute.Endpoint()))
+
+	localProvider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.local.AuthURL(),
+		Username:         "alice",
+		Password:         "secret",
+		DomainName:       "default",
+		TenantName:       "demo",
+		AllowReauth:      true,
+	})
+	th.AssertNoErr(t, err)
+	c.localClient, err = openstack.NewIdentityV3(context.TODO(), localProvider, gophercloud.EndpointOpts{})
+	th.AssertNoErr(t, err)
+
+	return c
+}
+
+func (c *clouds) listServers(t *testing.T, provider *gophercloud.ProviderClient) error {
+	t.Helper()
+
+	endpoint, err := provider.EndpointLocator(context.TODO(), gophercloud.EndpointOpts{Type: "compute", Availability: gophercloud.AvailabilityPublic})
+	th.AssertNoErr(t, err)
+	_, err = provider.Request(context.TODO(), "GET", endpoint+"servers", &gophercloud.RequestOpts{OkCodes: []int{200}})
+	return err
+}
+
+func TestGetServiceProvider(t *testing.T) {
+	c := setupClouds(t)
+
+	sp, err := k2k.GetServiceProvider(context.TODO(), c.localClient, "remote").Extract()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "remote", sp.ID)
+	th.AssertEquals(t, c.remote.ECPURL(), sp.SPURL)
+	th.AssertEquals(t, c.remote.AuthURL(), sp.IdentityEndpoint())
+
+	_, err = k2k.GetServiceProvider(context.TODO(), c.localClient, "unknown").Extract()
+	if !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		t.Fatalf("expected a 404 error, got %v", err)
+	}
+}
+
+func TestGetECPAssertion(t *testing.T) {
+	c := setupClouds(t)
+
+	assertion, err := k2k.GetECPAssertion(context.TODO(), c.localClient, "remote").Extract()
+	th.AssertNoErr(t, err)
+	if !strings.Contains(string(assertion), "<saml2:Assertion") {
+		t.Fatalf("expected a SAML2 assertion, got %s", assertion)
+	}
+
+	// the local ProviderClient reauthenticates, and the request is sent again
+	// with the new token
+	c.local.RevokeUserTokens(c.localUser.ID)
+	_, err = k2k.GetECPAssertion(context.TODO(), c.localClient, "remote").Extract()
+	th.AssertNoErr(t, err)
+
+	_, err = k2k.GetECPAssertion(context.TODO(), c.localClient, "unknown").Extract()
+	if !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		t.Fatalf("expected a 404 error, got %v", err)
+	}
+}
+
+func TestAuthenticatedClientK2K(t *testing.T) {
+	c := setupClouds(t)
+
+	provider, err := openstack.AuthenticatedClientK2K(context.TODO(), &k2k.AuthOptions{
+		IdentityClient:  c.localClient,
+		ServiceProvider: "remote",
+		Scope:           tokens.Scope{ProjectName: "shared", DomainName: "federated"},
+		AllowReauth:     true,
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, c.remote.AuthURL(), provider.IdentityEndpoint)
+	th.AssertEquals(t, true, c.remote.ValidToken(provider.Token()))
+
+	project, err := provider.GetAuthResult().(tokens.CreateResult).ExtractProject()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, c.shared.ID, project.ID)
+	th.AssertNoErr(t, c.listServers(t, provider))
+
+	// the remote token is renewed with a new assertion
+	c.remote.RevokeUserTokens(c.remoteUser.ID)
+	th.AssertNoErr(t, c.listServers(t, provider))
+
+	// and so it is once the local token expired
+	localIssued := c.local.IssuedTokens()
+	c.local.Advance(2 * keystone.DefaultTokenTTL)
+	c.remote.Advance(2 * keystone.DefaultTokenTTL)
+	th.AssertNoErr(t, c.listServers(t, provider))
+	th.AssertEquals(t, localIssued+1, c.local.IssuedTokens())
+}
+
+func TestCreateUnscoped(t *testing.T) {
+	c := setupClouds(t)
+
+	provider, err := openstack.NewClient(c.remote.AuthURL())
+	th.AssertNoErr(t, err)
+	remoteClient, err := openstack.NewIdentityV3(context.TODO(), provider, gophercloud.EndpointOpts{})
+	th.AssertNoErr(t, err)
+
+	r := k2k.Create(context.TODO(), remoteClient, &k2k.AuthOptions{
+		IdentityClient:  c.localClient,
+		ServiceProvider: "remote",
+	})
+	th.AssertNoErr(t, r.Err)
+
+	token, err := r.Extract()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, true, c.remote.ValidToken(token.ID))
+	user, err := r.ExtractUser()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, c.remoteUser.ID, user.ID)
+	var s struct {
+		Methods []string `json:"methods"`
+	}
+	th.AssertNoErr(t, r.ExtractInto(&s))
+	th.AssertDeepEquals(t, []string{"saml2"}, s.Methods)
+}
+
+func TestAssertionUsedOnce(t *testing.T) {
+	c := setupClouds(t)
+
+	provider, err := openstack.NewClient(c.remote.AuthURL())
+	th.AssertNoErr(t, err)
+	remoteClient, err := openstack.NewIdentityV3(context.TODO(), provider, gophercloud.EndpointOpts{})
+	th.AssertNoErr(t, err)
+
+	sp, err := k2k.GetServiceProvider(context.TODO(), c.localClient, "remote").Extract()
+	th.AssertNoErr(t, err)
+	assertion, err := k2k.GetECPAssertion(context.TODO(), c.localClient, "remote").Extract()
+	th.AssertNoErr(t, err)
+
+	th.AssertNoErr(t, k2k.Authenticate(context.TODO(), remoteClient, sp, assertion).Err)
+
+	err = k2k.Authenticate(context.TODO(), remoteClient, sp, assertion).Err
+	if !gophercloud.ResponseCodeIs(err, http.StatusUnauthorized) {
+		t.Fatalf("expected a 401 error from the service provider, got %v", err)
+	}
+}
+
+func TestMissingInput(t *testing.T) {
+	_, err := openstack.AuthenticatedClientK2K(context.TODO(), &k2k.AuthOptions{ServiceProvider: "remote"})
+	th.AssertEquals(t, "Missing input for argument [IdentityClient]", err.Error())
+
+	r := k2k.Create(context.TODO(), nil, &k2k.AuthOptions{IdentityClient: &gophercloud.ServiceClient{}})
+	th.AssertEquals(t, "Missing input for argument [ServiceProvider]", r.Err.Error())
+}
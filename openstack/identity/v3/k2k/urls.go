@@ -0,0 +1,11 @@
+package k2k
+
+import "github.com/gophercloud/gophercloud/v2"
+
+func serviceProviderURL(c *gophercloud.ServiceClient, id string) string {
+	return c.ServiceURL("OS-FEDERATION", "service_providers", id)
+}
+
+func ecpAssertionURL(c *gophercloud.ServiceClient) string {
+	return c.ServiceURL("auth", "OS-FEDERATION", "saml2", "ecp")
+}
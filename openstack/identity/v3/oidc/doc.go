@@ -0,0 +1,44 @@
+/*
+Package oidc provides the authentication with an OpenID Connect identity
+provider (IdP) through Keystone federation, with the flows of the
+v3oidcpassword, v3oidcclientcredentials, v3oidcauthcode and v3oidcaccesstoken
+plugins of keystoneauth.
+
+A token is obtained from the IdP, then exchanged for an unscoped Keystone token
+at /OS-FEDERATION/identity_providers/{idp}/protocols/{protocol}/auth, which is
+finally rescoped to the requested project or domain.
+
+Example to authenticate with the password grant
+
+	authOptions := &oidc.AuthOptions{
+		OIDCOptions: gophercloud.OIDCOptions{
+			IdentityProvider:  "myidp",
+			Protocol:          "openid",
+			GrantType:         gophercloud.OIDCGrantPassword,
+			DiscoveryEndpoint: "https://idp.example.com/.well-known/openid-configuration",
+			ClientID:          "openstack",
+			ClientSecret:      "client-secret",
+			Username:          "alice",
+			Password:          "secret",
+		},
+		Scope: tokens.Scope{
+			ProjectName: "demo",
+			DomainName:  "default",
+		},
+		AllowReauth: true,
+	}
+
+	provider, err := openstack.NewClient("https://keystone.example.com:5000/v3")
+	if err != nil {
+		panic(err)
+	}
+
+	err = openstack.AuthenticateV3(context.TODO(), provider, authOptions, gophercloud.EndpointOpts{})
+	if err != nil {
+		panic(err)
+	}
+
+The same authentication can be configured in gophercloud.AuthOptions with the
+OIDC field, or in clouds.yaml with the auth types of the keystoneauth plugins.
+*/
+package oidc
@@ -0,0 +1,249 @@
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+)
+
+// AuthOptions represents options for authenticating with an OpenID Connect
+// identity provider through Keystone federation.
+type AuthOptions struct {
+	gophercloud.OIDCOptions
+
+	// Scope is the scope of the token. The unscoped federated token issued by
+	// Keystone is rescoped to it, unless it is empty.
+	Scope tokens.Scope
+
+	// AllowReauth should be set to true if you grant permission for
+	// Gophercloud to cache your credentials in memory, and to allow
+	// Gophercloud to attempt to re-authenticate automatically if/when your
+	// token expires. Authorization codes are single use, so tokens obtained
+	// with the authorization code grant cannot be renewed.
+	AllowReauth bool
+}
+
+// ToTokenV3CreateMap allows AuthOptions to satisfy the AuthOptionsBuilder
+// interface in the v3 tokens package. The federated authentication does not
+// use the token creation request: use Create instead of tokens.Create.
+func (opts *AuthOptions) ToTokenV3CreateMap(map[string]any) (map[string]any, error) {
+	return nil, fmt.Errorf("OIDC authentication must use oidc.Create")
+}
+
+// ToTokenV3ScopeMap builds a scope request body from AuthOptions.
+func (opts *AuthOptions) ToTokenV3ScopeMap() (map[string]any, error) {
+	scope := gophercloud.AuthScope(opts.Scope)
+	return (&gophercloud.AuthOptions{Scope: &scope}).ToTokenV3ScopeMap()
+}
+
+// ToTokenV3HeadersMap allows AuthOptions to satisfy the AuthOptionsBuilder
+// interface in the v3 tokens package.
+func (opts *AuthOptions) ToTokenV3HeadersMap(map[string]any) (map[string]string, error) {
+	return nil, nil
+}
+
+// CanReauth reports whether a new token can be obtained with the same
+// options.
+func (opts *AuthOptions) CanReauth() bool {
+	if opts.AccessToken == "" && opts.GrantType == gophercloud.OIDCGrantAuthorizationCode {
+		// cannot reauth using a single-use authorization code
+		return false
+	}
+
+	return opts.AllowReauth
+}
+
+// Create obtains a token from the identity provider, exchanges it for an
+// unscoped Keystone token, and rescopes that token to the scope of the
+// options, if any.
+func Create(ctx context.Context, c *gophercloud.ServiceClient, opts *AuthOptions) (r tokens.CreateResult) {
+	accessToken, err := GetAccessToken(ctx, c.ProviderClient, opts.OIDCOptions)
+	if err != nil {
+		r.Err = err
+		return
+	}
+
+	r = Authenticate(ctx, c, opts.IdentityProvider, opts.Protocol, accessToken)
+	if r.Err != nil || opts.Scope == (tokens.Scope{}) {
+		return
+	}
+
+	unscopedTokenID, err := r.ExtractTokenID()
+	if err != nil {
+		r.Err = err
+		return
+	}
+
+	return tokens.Create(ctx, c, &tokens.AuthOptions{
+		TokenID: unscopedTokenID,
+		Scope:   opts.Scope,
+	})
+}
+
+// Authenticate exchanges a token of an identity provider for an unscoped
+// Keystone token, at the federated authentication endpoint of the identity
+// provider and protocol.
+func Authenticate(ctx context.Context, c *gophercloud.ServiceClient, identityProvider, protocol, accessToken string) (r tokens.CreateResult) {
+	if identityProvider == "" {
+		r.Err = gophercloud.ErrMissingInput{Argument: "IdentityProvider"}
+		return
+	}
+	if protocol == "" {
+		r.Err = gophercloud.ErrMissingInput{Argument: "Protocol"}
+		return
+	}
+
+	resp, err := c.Post(ctx, federatedAuthURL(c, identityProvider, protocol), nil, &r.Body, &gophercloud.RequestOpts{
+		MoreHeaders: map[string]string{"Authorization": "Bearer " + accessToken},
+		OmitHeaders: []string{"X-Auth-Token"},
+		OkCodes:     []int{201},
+	})
+	_, r.Header, r.Err = gophercloud.ParseResponse(resp, err)
+	return
+}
+
+// tokenResponse is the response of the token endpoint of an identity
+// provider.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+}
+
+// GetAccessToken obtains a token from the identity provider with the grant of
+// the options, and returns the access token or the ID token, depending on
+// AccessTokenType. When the options hold an access token, it is returned
+// as is. The requests to the identity provider are sent by the client, so
+// they go through its logger, middlewares, rate limiters and retry policy,
+// but they do not carry its token.
+func GetAccessToken(ctx context.Context, client *gophercloud.ProviderClient, opts gophercloud.OIDCOptions) (string, error) {
+	if opts.AccessToken != "" {
+		return opts.AccessToken, nil
+	}
+
+	form := url.Values{
+		"grant_type": {opts.GrantType},
+		"scope":      {opts.OpenIDScope},
+	}
+	if opts.OpenIDScope == "" {
+		form.Set("scope", "openid profile")
+	}
+
+	switch opts.GrantType {
+	case gophercloud.OIDCGrantPassword:
+		if opts.Username == "" {
+			return "", gophercloud.ErrMissingInput{Argument: "Username"}
+		}
+		if opts.Password == "" {
+			return "", gophercloud.ErrMissingInput{Argument: "Password"}
+		}
+		form.Set("username", opts.Username)
+		form.Set("password", opts.Password)
+	case gophercloud.OIDCGrantClientCredentials:
+	case gophercloud.OIDCGrantAuthorizationCode:
+		if opts.Code == "" {
+			return "", gophercloud.ErrMissingInput{Argument: "Code"}
+		}
+		form.Set("code", opts.Code)
+		if opts.RedirectURI != "" {
+			form.Set("redirect_uri", opts.RedirectURI)
+		}
+		if opts.CodeVerifier != "" {
+			form.Set("code_verifier", opts.CodeVerifier)
+		}
+	case "":
+		return "", gophercloud.ErrMissingInput{Argument: "GrantType"}
+	default:
+		return "", fmt.Errorf("unsupported OIDC grant type %q", opts.GrantType)
+	}
+
+	if opts.ClientID == "" {
+		return "", gophercloud.ErrMissingInput{Argument: "ClientID"}
+	}
+	if opts.ClientSecret == "" {
+		// public client
+		form.Set("client_id", opts.ClientID)
+	}
+
+	tokenEndpoint := opts.AccessTokenEndpoint
+	if tokenEndpoint == "" {
+		if opts.DiscoveryEndpoint == "" {
+			return "", gophercloud.ErrMissingInput{Argument: "AccessTokenEndpoint"}
+		}
+		var err error
+		tokenEndpoint, err = discoverTokenEndpoint(ctx, client, opts.DiscoveryEndpoint)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	if opts.ClientSecret != "" {
+		credentials := url.QueryEscape(opts.ClientID) + ":" + url.QueryEscape(opts.ClientSecret)
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+
+	var token tokenResponse
+	if err := doIdPRequest(ctx, client, http.MethodPost, tokenEndpoint, &gophercloud.RequestOpts{
+		RawBody:      strings.NewReader(form.Encode()),
+		MoreHeaders:  headers,
+		JSONResponse: &token,
+	}); err != nil {
+		return "", err
+	}
+
+	switch opts.AccessTokenType {
+	case "", "access_token":
+		if token.AccessToken == "" {
+			return "", fmt.Errorf("the identity provider did not return an access token")
+		}
+		return token.AccessToken, nil
+	case "id_token":
+		if token.IDToken == "" {
+			return "", fmt.Errorf("the identity provider did not return an ID token")
+		}
+		return token.IDToken, nil
+	default:
+		return "", fmt.Errorf("unsupported OIDC access token type %q", opts.AccessTokenType)
+	}
+}
+
+// discoverTokenEndpoint reads the token endpoint of an identity provider from
+// its discovery document.
+func discoverTokenEndpoint(ctx context.Context, client *gophercloud.ProviderClient, discoveryEndpoint string) (string, error) {
+	var discovery struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := doIdPRequest(ctx, client, http.MethodGet, discoveryEndpoint, &gophercloud.RequestOpts{
+		JSONResponse: &discovery,
+	}); err != nil {
+		return "", err
+	}
+	if discovery.TokenEndpoint == "" {
+		return "", fmt.Errorf("the discovery document at %s has no token endpoint", discoveryEndpoint)
+	}
+	return discovery.TokenEndpoint, nil
+}
+
+// doIdPRequest sends a request to the identity provider with a throwaway copy
+// of the ProviderClient. The request goes through the logger, the
+// middlewares, the rate limiters and the retry policy of the ProviderClient,
+// but without its Keystone token, and a 401 response does not reauthenticate
+// it.
+func doIdPRequest(ctx context.Context, client *gophercloud.ProviderClient, method, url string, opts *gophercloud.RequestOpts) error {
+	idp := *client
+	idp.SetThrowaway(true)
+	idp.ReauthFunc = nil
+	if err := idp.SetTokenAndAuthResult(nil); err != nil {
+		return err
+	}
+
+	opts.OkCodes = []int{http.StatusOK}
+	_, err := idp.Request(ctx, method, url, opts)
+	return err
+}
@@ -0,0 +1,2 @@
+// oidc unit tests
+package testing
@@ -0,0 +1,329 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/oidc"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+	"github.com/gophercloud/gophercloud/v2/openstack/tokenstore"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/keystone"
+)
+
+const redirectURI = "http://localhost:8080/callback"
+
+type cloud struct {
+	ks      *keystone.Server
+	idp     *keystone.IdP
+	compute th.FakeServer
+	project keystone.Project
+	user    keystone.User
+}
+
+func setupCloud(t *testing.T, clientSecret string) *cloud {
+	c := &cloud{
+		ks:  keystone.Setup(),
+		idp: keystone.SetupIdP("openstack", clientSecret),
+	}
+	t.Cleanup(c.ks.Teardown)
+	t.Cleanup(c.idp.Teardown)
+
+	domain := c.ks.AddDomain("federated")
+	c.project = c.ks.AddProject("demo", domain.ID)
+	c.user = c.ks.AddUser("alice", "", domain.ID)
+	c.ks.AssignProjectRole(c.user.ID, c.project.ID, "member")
+	service := c.ks.AddUser("service", "", domain.ID)
+	c.ks.AssignProjectRole(service.ID, c.project.ID, "member")
+
+	c.idp.AddUser("alice", "secret")
+	c.ks.AddFederatedProtocol("myidp", "openid", c.idp.Validator(map[string]string{
+		"alice":     c.user.ID,
+		"openstack": service.ID,
+	}))
+
+	c.compute = th.SetupHTTP()
+	t.Cleanup(c.compute.Teardown)
+	c.compute.Mux.Handle("/servers", c.ks.RequireToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"servers": []}`)
+	})))
+	c.ks.RegisterService("compute", "nova", keystone.PublicEndpoint(c.compute.Endpoint()))
+
+	return c
+}
+
+func (c *cloud) authenticate(t *testing.T, opts *oidc.AuthOptions) (*gophercloud.ProviderClient, error) {
+	t.Helper()
+
+	provider, err := openstack.NewClient(c.ks.AuthURL())
+	th.AssertNoErr(t, err)
+	return provider, openstack.AuthenticateV3(context.TODO(), provider, opts, gophercloud.EndpointOpts{})
+}
+
+func (c *cloud) listServers(t *testing.T, provider *gophercloud.ProviderClient) error {
+	t.Helper()
+
+	_, err := provider.Request(context.TODO(), "GET", c.compute.Endpoint()+"servers", &gophercloud.RequestOpts{OkCodes: []int{200}})
+	return err
+}
+
+func TestPasswordGrant(t *testing.T) {
+	c := setupCloud(t, "client-secret")
+
+	provider, err := c.authenticate(t, &oidc.AuthOptions{
+		OIDCOptions: gophercloud.OIDCOptions{
+			IdentityProvider:  "myidp",
+			Protocol:          "openid",
+			GrantType:         gophercloud.OIDCGrantPassword,
+			DiscoveryEndpoint: c.idp.DiscoveryURL(),
+			ClientID:          "openstack",
+			ClientSecret:      "client-secret",
+			Username:          "alice",
+			Password:          "secret",
+		},
+		Scope:       tokens.Scope{ProjectID: c.project.ID},
+		AllowReauth: true,
+	})
+	th.AssertNoErr(t, err)
+
+	project, err := provider.GetAuthResult().(tokens.CreateResult).ExtractProject()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, c.project.ID, project.ID)
+	th.AssertNoErr(t, c.listServers(t, provider))
+	th.AssertEquals(t, 1, c.idp.TokenRequests())
+
+	// the token is renewed with a new IdP token
+	c.ks.RevokeUserTokens(c.user.ID)
+	th.AssertNoErr(t, c.listServers(t, provider))
+	th.AssertEquals(t, 2, c.idp.TokenRequests())
+}
+
+func TestPasswordGrantInvalidCredentials(t *testing.T) {
+	c := setupCloud(t, "client-secret")
+
+	_, err := c.authenticate(t, &oidc.AuthOptions{
+		OIDCOptions: gophercloud.OIDCOptions{
+			IdentityProvider:    "myidp",
+			Protocol:            "openid",
+			GrantType:           gophercloud.OIDCGrantPassword,
+			AccessTokenEndpoint: c.idp.TokenURL(),
+			ClientID:            "openstack",
+			ClientSecret:        "client-secret",
+			Username:            "alice",
+			Password:            "wrong",
+		},
+	})
+	if !gophercloud.ResponseCodeIs(err, http.StatusBadRequest) {
+		t.Fatalf("expected a 400 error from the identity provider, got %v", err)
+	}
+}
+
+func TestIdPRequestsMiddlewares(t *testing.T) {
+	c := setupCloud(t, "client-secret")
+
+	idpURL, err := url.Parse(c.idp.DiscoveryURL())
+	th.AssertNoErr(t, err)
+
+	provider, err := openstack.NewClient(c.ks.AuthURL())
+	th.AssertNoErr(t, err)
+	var idpRequests int
+	provider.Middlewares = []gophercloud.Middleware{
+		func(next gophercloud.RoundTripFunc) gophercloud.RoundTripFunc {
+			return func(req *http.Request, info gophercloud.RequestInfo) (*http.Response, error) {
+				if req.URL.Host == idpURL.Host {
+					idpRequests++
+					// the Keystone token is not sent to the identity provider
+					th.CheckEquals(t, "", req.Header.Get("X-Auth-Token"))
+				}
+				return next(req, info)
+			}
+		},
+	}
+
+	err = openstack.AuthenticateV3(context.TODO(), provider, &oidc.AuthOptions{
+		OIDCOptions: gophercloud.OIDCOptions{
+			IdentityProvider:  "myidp",
+			Protocol:          "openid",
+			GrantType:         gophercloud.OIDCGrantPassword,
+			DiscoveryEndpoint: c.idp.DiscoveryURL(),
+			ClientID:          "openstack",
+			ClientSecret:      "client-secret",
+			Username:          "alice",
+			Password:          "secret",
+		},
+		Scope:       tokens.Scope{ProjectID: c.project.ID},
+		AllowReauth: true,
+	}, gophercloud.EndpointOpts{})
+	th.AssertNoErr(t, err)
+	// the discovery and the token requests
+	th.AssertEquals(t, 2, idpRequests)
+
+	c.ks.RevokeUserTokens(c.user.ID)
+	th.AssertNoErr(t, c.listServers(t, provider))
+	th.AssertEquals(t, 4, idpRequests)
+}
+
+func TestClientCredentialsGrant(t *testing.T) {
+	c := setupCloud(t, "client-secret")
+
+	provider, err := c.authenticate(t, &oidc.AuthOptions{
+		OIDCOptions: gophercloud.OIDCOptions{
+			IdentityProvider:    "myidp",
+			Protocol:            "openid",
+			GrantType:           gophercloud.OIDCGrantClientCredentials,
+			AccessTokenEndpoint: c.idp.TokenURL(),
+			ClientID:            "openstack",
+			ClientSecret:        "client-secret",
+			AccessTokenType:     "id_token",
+		},
+		Scope: tokens.Scope{ProjectName: "demo", DomainName: "federated"},
+	})
+	th.AssertNoErr(t, err)
+	th.AssertNoErr(t, c.listServers(t, provider))
+}
+
+func TestAuthorizationCodeGrant(t *testing.T) {
+	c := setupCloud(t, "")
+
+	opts := &oidc.AuthOptions{
+		OIDCOptions: gophercloud.OIDCOptions{
+			IdentityProvider:  "myidp",
+			Protocol:          "openid",
+			GrantType:         gophercloud.OIDCGrantAuthorizationCode,
+			DiscoveryEndpoint: c.idp.DiscoveryURL(),
+			ClientID:          "openstack",
+			Code:              c.idp.AuthorizationCode("alice", redirectURI),
+			RedirectURI:       redirectURI,
+		},
+		Scope:       tokens.Scope{ProjectID: c.project.ID},
+		AllowReauth: true,
+	}
+	th.AssertEquals(t, false, opts.CanReauth())
+
+	provider, err := c.authenticate(t, opts)
+	th.AssertNoErr(t, err)
+	th.AssertNoErr(t, c.listServers(t, provider))
+
+	// authorization codes are single use
+	_, err = c.authenticate(t, opts)
+	if !gophercloud.ResponseCodeIs(err, http.StatusBadRequest) {
+		t.Fatalf("expected a 400 error from the identity provider, got %v", err)
+	}
+}
+
+func TestAccessToken(t *testing.T) {
+	c := setupCloud(t, "client-secret")
+
+	provider, err := c.authenticate(t, &oidc.AuthOptions{
+		OIDCOptions: gophercloud.OIDCOptions{
+			IdentityProvider: "myidp",
+			Protocol:         "openid",
+			AccessToken:      c.idp.IssueToken("alice"),
+		},
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, c.idp.TokenRequests())
+
+	// without scope, the token is unscoped
+	user, err := provider.GetAuthResult().(tokens.CreateResult).ExtractUser()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, c.user.ID, user.ID)
+	project, err := provider.GetAuthResult().(tokens.CreateResult).ExtractProject()
+	th.AssertNoErr(t, err)
+	if project != nil {
+		t.Errorf("expected an unscoped token, got a token scoped to %s", project.ID)
+	}
+
+	_, err = c.authenticate(t, &oidc.AuthOptions{
+		OIDCOptions: gophercloud.OIDCOptions{
+			IdentityProvider: "myidp",
+			Protocol:         "openid",
+			AccessToken:      "invalid",
+		},
+	})
+	if !gophercloud.ResponseCodeIs(err, http.StatusUnauthorized) {
+		t.Fatalf("expected a 401 error from Keystone, got %v", err)
+	}
+}
+
+func TestAuthOptionsOIDC(t *testing.T) {
+	c := setupCloud(t, "client-secret")
+
+	provider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.AuthURL(),
+		TenantName:       "demo",
+		DomainName:       "federated",
+		AllowReauth:      true,
+		OIDC: &gophercloud.OIDCOptions{
+			IdentityProvider:  "myidp",
+			Protocol:          "openid",
+			GrantType:         gophercloud.OIDCGrantPassword,
+			DiscoveryEndpoint: c.idp.DiscoveryURL(),
+			ClientID:          "openstack",
+			ClientSecret:      "client-secret",
+			Username:          "alice",
+			Password:          "secret",
+		},
+	})
+	th.AssertNoErr(t, err)
+	th.AssertNoErr(t, c.listServers(t, provider))
+
+	c.ks.RevokeUserTokens(c.user.ID)
+	th.AssertNoErr(t, c.listServers(t, provider))
+	th.AssertEquals(t, 2, c.idp.TokenRequests())
+}
+
+func TestAuthOptionsOIDCTokenStore(t *testing.T) {
+	c := setupCloud(t, "client-secret")
+
+	opts := gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.AuthURL(),
+		TenantName:       "demo",
+		DomainName:       "federated",
+		AllowReauth:      true,
+		TokenStore:       tokenstore.NewFileStore(t.TempDir()),
+		OIDC: &gophercloud.OIDCOptions{
+			IdentityProvider:  "myidp",
+			Protocol:          "openid",
+			GrantType:         gophercloud.OIDCGrantPassword,
+			DiscoveryEndpoint: c.idp.DiscoveryURL(),
+			ClientID:          "openstack",
+			ClientSecret:      "client-secret",
+			Username:          "alice",
+			Password:          "secret",
+		},
+	}
+	provider, err := openstack.AuthenticatedClient(context.TODO(), opts)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, c.idp.TokenRequests())
+
+	// a second login reuses the stored token without contacting the IdP
+	reused, err := openstack.AuthenticatedClient(context.TODO(), opts)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, provider.Token(), reused.Token())
+	th.AssertEquals(t, 1, c.idp.TokenRequests())
+	th.AssertNoErr(t, c.listServers(t, reused))
+
+	// the token obtained when reauthenticating is stored too
+	c.ks.RevokeUserTokens(c.user.ID)
+	th.AssertNoErr(t, c.listServers(t, reused))
+	th.AssertEquals(t, 2, c.idp.TokenRequests())
+	reused, err = openstack.AuthenticatedClient(context.TODO(), opts)
+	th.AssertNoErr(t, err)
+	th.AssertNoErr(t, c.listServers(t, reused))
+	th.AssertEquals(t, 2, c.idp.TokenRequests())
+
+	// another IdP user does not share the token
+	opts.OIDC.Username, opts.OIDC.Password = "bob", "secret"
+	c.idp.AddUser("bob", "secret")
+	_, err = openstack.AuthenticatedClient(context.TODO(), opts)
+	th.AssertEquals(t, 3, c.idp.TokenRequests())
+	if !gophercloud.ResponseCodeIs(err, http.StatusUnauthorized) {
+		t.Fatalf("expected a 401 error from Keystone, got %v", err)
+	}
+}
@@ -0,0 +1,7 @@
+package oidc
+
+import "github.com/gophercloud/gophercloud/v2"
+
+func federatedAuthURL(c *gophercloud.ServiceClient, identityProvider, protocol string) string {
+	return c.ServiceURL("OS-FEDERATION", "identity_providers", identityProvider, "protocols", protocol, "auth")
+}
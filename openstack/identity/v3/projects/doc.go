@@ -22,6 +22,13 @@ Example to List Projects
 		fmt.Printf("%+v\n", project)
 	}
 
+Example to Find a Project by Name or ID
+
+	project, err := projects.Find(context.TODO(), identityClient, "demo")
+	if err != nil {
+		panic(err)
+	}
+
 Example to Create a Project
 
 	createOpts := projects.CreateOpts{
@@ -101,6 +101,17 @@ func Get(ctx context.Context, client *gophercloud.ServiceClient, id string) (r G
 	return
 }
 
+// Find returns the project with the given ID or name. When no project has the
+// ID, the name must match a single project; gophercloud.ErrResourceNotFound or
+// gophercloud.ErrMultipleResourcesFound is returned otherwise.
+func Find(ctx context.Context, client *gophercloud.ServiceClient, nameOrID string) (*Project, error) {
+	return pagination.FindByIDOrName(ctx, func(ctx context.Context) (*Project, error) {
+		return Get(ctx, client, nameOrID).Extract()
+	}, List(client, ListOpts{Name: nameOrID}), ExtractProjects, "project", nameOrID, func(p Project) bool {
+		return p.Name == nameOrID
+	})
+}
+
 // CreateOptsBuilder allows extensions to add additional parameters to
 // the Create request.
 type CreateOptsBuilder interface {
@@ -150,6 +150,11 @@ type AssignedRole struct {
 type Scope struct {
 	Domain  Domain  `json:"domain,omitempty"`
 	Project Project `json:"project,omitempty"`
+
+	// InheritedTo is "projects" for the OS-INHERIT assignments, that apply
+	// to the projects of the domain or to the subtree of the project rather
+	// than to the domain or project itself.
+	InheritedTo string `json:"OS-INHERIT:inherited_to,omitempty"`
 }
 
 // Domain represents a domain in a role assignment scope.
@@ -103,5 +103,32 @@ Example to Create a Token from a Username and Password with Project Name Scope
 	if err != nil {
 		panic(err)
 	}
+
+Example to Complete a Multi-Factor Authentication with an Auth Receipt
+
+	authOptions := tokens.AuthOptions{
+		UserID:   "username",
+		Password: "password",
+	}
+
+	token, err = tokens.Create(context.TODO(), identityClient, &authOptions).ExtractToken()
+	var receiptErr gophercloud.ErrAuthReceiptRequired
+	if errors.As(err, &receiptErr) {
+		fmt.Println("remaining methods:", receiptErr.Receipt.RemainingMethods())
+
+		authOptions = tokens.AuthOptions{
+			UserID:      "username",
+			Passcode:    "123456",
+			AuthReceipt: receiptErr.Receipt.ID,
+		}
+		token, err = tokens.Create(context.TODO(), identityClient, &authOptions).ExtractToken()
+	}
+	if err != nil {
+		panic(err)
+	}
+
+When authenticating a ProviderClient with openstack.AuthenticateV3, the
+PasscodeFunc of the AuthOptions can instead prompt for the passcode, and is
+called again when the ProviderClient reauthenticates.
 */
 package tokens
@@ -2,6 +2,10 @@ package tokens
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
 
 	"github.com/gophercloud/gophercloud/v2"
 )
@@ -69,6 +73,16 @@ type AuthOptions struct {
 	ApplicationCredentialSecret string `json:"-"`
 
 	Scope Scope `json:"-"`
+
+	// AuthReceipt is the ID of an auth receipt issued by Keystone, to
+	// complete a multi-factor authentication with the remaining methods (for
+	// example with Passcode). See gophercloud.ErrAuthReceiptRequired.
+	AuthReceipt string `json:"-"`
+
+	// PasscodeFunc, if set, is called when Keystone answers the
+	// authentication with an auth receipt requiring the TOTP method. The
+	// authentication is then completed with the returned passcode.
+	PasscodeFunc gophercloud.PasscodeFunc `json:"-"`
 }
 
 // ToTokenV3CreateMap builds a request body from AuthOptions.
@@ -113,8 +127,12 @@ func (opts *AuthOptions) CanReauth() bool {
 }
 
 // ToTokenV3HeadersMap allows AuthOptions to satisfy the AuthOptionsBuilder
-// interface in the v3 tokens package.
+// interface in the v3 tokens package. It returns the auth receipt header when
+// an auth receipt is set.
 func (opts *AuthOptions) ToTokenV3HeadersMap(map[string]any) (map[string]string, error) {
+	if opts.AuthReceipt != "" {
+		return map[string]string{gophercloud.AuthReceiptHeader: opts.AuthReceipt}, nil
+	}
 	return nil, nil
 }
 
@@ -139,13 +157,61 @@ func Create(ctx context.Context, c *gophercloud.ServiceClient, opts AuthOptionsB
 		return
 	}
 
+	headers, err := opts.ToTokenV3HeadersMap(b)
+	if err != nil {
+		r.Err = err
+		return
+	}
+
 	resp, err := c.Post(ctx, tokenURL(c), b, &r.Body, &gophercloud.RequestOpts{
+		MoreHeaders: headers,
 		OmitHeaders: []string{"X-Auth-Token"},
 	})
 	_, r.Header, r.Err = gophercloud.ParseResponse(resp, err)
+	if r.Err != nil {
+		r.Err = authReceiptError(r.Err)
+	}
 	return
 }
 
+// authReceiptError returns an ErrAuthReceiptRequired if the error is the 401
+// response of Keystone carrying an auth receipt, and the error otherwise.
+func authReceiptError(err error) error {
+	var codeErr gophercloud.ErrUnexpectedResponseCode
+	if !errors.As(err, &codeErr) || codeErr.Actual != http.StatusUnauthorized {
+		return err
+	}
+	receiptID := codeErr.ResponseHeader.Get(gophercloud.AuthReceiptHeader)
+	if receiptID == "" {
+		return err
+	}
+
+	var s struct {
+		Receipt struct {
+			Methods []string `json:"methods"`
+			User    struct {
+				ID string `json:"id"`
+			} `json:"user"`
+			ExpiresAt time.Time `json:"expires_at"`
+		} `json:"receipt"`
+		RequiredAuthMethods [][]string `json:"required_auth_methods"`
+	}
+	if json.Unmarshal(codeErr.Body, &s) != nil {
+		return err
+	}
+
+	return gophercloud.ErrAuthReceiptRequired{
+		ErrUnexpectedResponseCode: codeErr,
+		Receipt: gophercloud.AuthReceipt{
+			ID:                  receiptID,
+			Methods:             s.Receipt.Methods,
+			RequiredAuthMethods: s.RequiredAuthMethods,
+			UserID:              s.Receipt.User.ID,
+			ExpiresAt:           s.Receipt.ExpiresAt,
+		},
+	}
+}
+
 // Get validates and retrieves information about another token.
 func Get(ctx context.Context, c *gophercloud.ServiceClient, token string) (r GetResult) {
 	resp, err := c.Get(ctx, tokenURL(c), &r.Body, &gophercloud.RequestOpts{
@@ -128,6 +128,15 @@ func (r GetResult) ExtractTokenID() (string, error) {
 	return r.Header.Get("X-Subject-Token"), r.Err
 }
 
+// ExtractExpiresAt returns the time at which the token expires. A
+// ProviderClient uses it to refresh the token before it expires (see
+// gophercloud.ProviderClient.TokenRefreshWindow).
+func (r commonResult) ExtractExpiresAt() (time.Time, error) {
+	var s Token
+	err := r.ExtractInto(&s)
+	return s.ExpiresAt, err
+}
+
 // ExtractServiceCatalog returns the ServiceCatalog that was generated along
 // with the user's Token.
 func (r commonResult) ExtractServiceCatalog() (*ServiceCatalog, error) {
@@ -59,3 +59,12 @@ func TestExtractDomain(t *testing.T) {
 
 	th.CheckDeepEquals(t, &ExpectedDomain, domain)
 }
+
+func TestExtractExpiresAt(t *testing.T) {
+	result := getGetResult(t)
+
+	expiresAt, err := result.ExtractExpiresAt()
+	th.AssertNoErr(t, err)
+
+	th.CheckEquals(t, ExpectedToken.ExpiresAt, expiresAt)
+}
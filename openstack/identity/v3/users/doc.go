@@ -21,6 +21,13 @@ Example to List Users
 		fmt.Printf("%+v\n", user)
 	}
 
+Example to Find an User by Name or ID
+
+	user, err := users.Find(context.TODO(), identityClient, "alice")
+	if err != nil {
+		panic(err)
+	}
+
 Example to Create a User
 
 	projectID := "a99e9b4e620e4db09a2dfb6e42a01e66"
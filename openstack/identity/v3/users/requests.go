@@ -100,6 +100,17 @@ func Get(ctx context.Context, client *gophercloud.ServiceClient, id string) (r G
 	return
 }
 
+// Find returns the user with the given ID or name. When no user has the
+// ID, the name must match a single user; gophercloud.ErrResourceNotFound or
+// gophercloud.ErrMultipleResourcesFound is returned otherwise.
+func Find(ctx context.Context, client *gophercloud.ServiceClient, nameOrID string) (*User, error) {
+	return pagination.FindByIDOrName(ctx, func(ctx context.Context) (*User, error) {
+		return Get(ctx, client, nameOrID).Extract()
+	}, List(client, ListOpts{Name: nameOrID}), ExtractUsers, "user", nameOrID, func(u User) bool {
+		return u.Name == nameOrID
+	})
+}
+
 // CreateOptsBuilder allows extensions to add additional parameters to
 // the Create request.
 type CreateOptsBuilder interface {
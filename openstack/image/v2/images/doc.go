@@ -22,6 +22,13 @@ Example to List Images
 		fmt.Printf("%+v\n", image)
 	}
 
+Example to Find an Image by Name or ID
+
+	image, err := images.Find(context.TODO(), imagesClient, "cirros")
+	if err != nil {
+		panic(err)
+	}
+
 Example to Create an Image
 
 	createOpts := images.CreateOpts{
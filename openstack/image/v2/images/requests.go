@@ -232,6 +232,17 @@ func Get(ctx context.Context, client *gophercloud.ServiceClient, id string) (r G
 	return
 }
 
+// Find returns the image with the given ID or name. When no image has the
+// ID, the name must match a single image; gophercloud.ErrResourceNotFound or
+// gophercloud.ErrMultipleResourcesFound is returned otherwise.
+func Find(ctx context.Context, client *gophercloud.ServiceClient, nameOrID string) (*Image, error) {
+	return pagination.FindByIDOrName(ctx, func(ctx context.Context) (*Image, error) {
+		return Get(ctx, client, nameOrID).Extract()
+	}, List(client, ListOpts{Name: nameOrID}), ExtractImages, "image", nameOrID, func(i Image) bool {
+		return i.Name == nameOrID
+	})
+}
+
 // Update implements image updated request.
 func Update(ctx context.Context, client *gophercloud.ServiceClient, id string, opts UpdateOptsBuilder) (r UpdateResult) {
 	b, err := opts.ToImageUpdateMap()
@@ -0,0 +1,40 @@
+package images
+
+import (
+	"context"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// WaitForStatus will continually poll an image until it successfully
+// transitions to a specified status. It fails with an ErrWaitFailed if the
+// image is killed.
+func WaitForStatus(ctx context.Context, c *gophercloud.ServiceClient, id string, status ImageStatus) error {
+	waiter := gophercloud.Waiter{
+		Multiplier: 1,
+		Jitter:     -1,
+		Target:     []string{string(status)},
+		Failure:    []string{string(ImageStatusKilled)},
+	}
+	return waiter.WaitForState(ctx, statusRefreshFunc(c, id))
+}
+
+// WaitForDeleted will continually poll an image until it is deleted.
+func WaitForDeleted(ctx context.Context, c *gophercloud.ServiceClient, id string) error {
+	waiter := gophercloud.Waiter{
+		Multiplier: 1,
+		Jitter:     -1,
+		Deleted:    true,
+	}
+	return waiter.WaitForState(ctx, statusRefreshFunc(c, id))
+}
+
+func statusRefreshFunc(c *gophercloud.ServiceClient, id string) gophercloud.StateRefreshFunc {
+	return func(ctx context.Context) (string, error) {
+		current, err := Get(ctx, c, id).Extract()
+		if err != nil {
+			return "", err
+		}
+		return string(current.Status), nil
+	}
+}
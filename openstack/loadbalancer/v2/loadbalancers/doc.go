@@ -22,6 +22,13 @@ Example to List Load Balancers
 		fmt.Printf("%+v\n", lb)
 	}
 
+Example to Find a Load Balancer by Name or ID
+
+	lb, err := loadbalancers.Find(context.TODO(), networkClient, "web_lb")
+	if err != nil {
+		panic(err)
+	}
+
 Example to Create a Load Balancer
 
 	createOpts := loadbalancers.CreateOpts{
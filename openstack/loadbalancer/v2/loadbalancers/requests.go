@@ -175,6 +175,18 @@ func Get(ctx context.Context, c *gophercloud.ServiceClient, id string) (r GetRes
 	return
 }
 
+// Find returns the load balancer with the given ID or name. When no load
+// balancer has the ID, the name must match a single load balancer;
+// gophercloud.ErrResourceNotFound or gophercloud.ErrMultipleResourcesFound is
+// returned otherwise.
+func Find(ctx context.Context, c *gophercloud.ServiceClient, nameOrID string) (*LoadBalancer, error) {
+	return pagination.FindByIDOrName(ctx, func(ctx context.Context) (*LoadBalancer, error) {
+		return Get(ctx, c, nameOrID).Extract()
+	}, List(c, ListOpts{Name: nameOrID}), ExtractLoadBalancers, "load balancer", nameOrID, func(l LoadBalancer) bool {
+		return l.Name == nameOrID
+	})
+}
+
 // UpdateOptsBuilder allows extensions to add additional parameters to the
 // Update request.
 type UpdateOptsBuilder interface {
@@ -0,0 +1,43 @@
+package loadbalancers
+
+import (
+	"context"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// WaitForStatus will continually poll a load balancer until its provisioning
+// status transitions to a specified status. It fails with an ErrWaitFailed if
+// the load balancer goes into the ERROR provisioning status.
+func WaitForStatus(ctx context.Context, c *gophercloud.ServiceClient, id, status string) error {
+	waiter := gophercloud.Waiter{
+		Multiplier: 1,
+		Jitter:     -1,
+		Target:     []string{status},
+		Failure:    []string{"ERROR"},
+	}
+	return waiter.WaitForState(ctx, statusRefreshFunc(c, id))
+}
+
+// WaitForDeleted will continually poll a load balancer until it is deleted. It
+// fails with an ErrWaitFailed if the load balancer goes into the ERROR
+// provisioning status.
+func WaitForDeleted(ctx context.Context, c *gophercloud.ServiceClient, id string) error {
+	waiter := gophercloud.Waiter{
+		Multiplier: 1,
+		Jitter:     -1,
+		Failure:    []string{"ERROR"},
+		Deleted:    true,
+	}
+	return waiter.WaitForState(ctx, statusRefreshFunc(c, id))
+}
+
+func statusRefreshFunc(c *gophercloud.ServiceClient, id string) gophercloud.StateRefreshFunc {
+	return func(ctx context.Context) (string, error) {
+		current, err := Get(ctx, c, id).Extract()
+		if err != nil {
+			return "", err
+		}
+		return current.ProvisioningStatus, nil
+	}
+}
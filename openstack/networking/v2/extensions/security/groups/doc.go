@@ -22,6 +22,13 @@ Example to List Security Groups
 		fmt.Printf("%+v\n", group)
 	}
 
+Example to Find a Security Group by Name or ID
+
+	group, err := groups.Find(context.TODO(), networkClient, "default")
+	if err != nil {
+		panic(err)
+	}
+
 Example to Create a Security Group
 
 	createOpts := groups.CreateOpts{
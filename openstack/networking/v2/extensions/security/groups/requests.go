@@ -166,6 +166,18 @@ func Get(ctx context.Context, c *gophercloud.ServiceClient, id string) (r GetRes
 	return
 }
 
+// Find returns the security group with the given ID or name. When no security
+// group has the ID, the name must match a single security group;
+// gophercloud.ErrResourceNotFound or gophercloud.ErrMultipleResourcesFound is
+// returned otherwise.
+func Find(ctx context.Context, c *gophercloud.ServiceClient, nameOrID string) (*SecGroup, error) {
+	return pagination.FindByIDOrName(ctx, func(ctx context.Context) (*SecGroup, error) {
+		return Get(ctx, c, nameOrID).Extract()
+	}, List(c, ListOpts{Name: nameOrID}), ExtractGroups, "security group", nameOrID, func(s SecGroup) bool {
+		return s.Name == nameOrID
+	})
+}
+
 // Delete will permanently delete a particular security group based on its
 // unique ID.
 func Delete(ctx context.Context, c *gophercloud.ServiceClient, id string) (r DeleteResult) {
@@ -28,6 +28,13 @@ Example to List Networks
 		fmt.Printf("%+v", network)
 	}
 
+Example to Find a Network by Name or ID
+
+	network, err := networks.Find(context.TODO(), networkClient, "private")
+	if err != nil {
+		panic(err)
+	}
+
 Example to Create a Network
 
 	iTrue := true
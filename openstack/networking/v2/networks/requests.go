@@ -69,6 +69,17 @@ func Get(ctx context.Context, c *gophercloud.ServiceClient, id string) (r GetRes
 	return
 }
 
+// Find returns the network with the given ID or name. When no network has the
+// ID, the name must match a single network; gophercloud.ErrResourceNotFound or
+// gophercloud.ErrMultipleResourcesFound is returned otherwise.
+func Find(ctx context.Context, c *gophercloud.ServiceClient, nameOrID string) (*Network, error) {
+	return pagination.FindByIDOrName(ctx, func(ctx context.Context) (*Network, error) {
+		return Get(ctx, c, nameOrID).Extract()
+	}, List(c, ListOpts{Name: nameOrID}), ExtractNetworks, "network", nameOrID, func(n Network) bool {
+		return n.Name == nameOrID
+	})
+}
+
 // CreateOptsBuilder allows extensions to add additional parameters to the
 // Create request.
 type CreateOptsBuilder interface {
@@ -29,6 +29,13 @@ Example to List Subnets
 		fmt.Printf("%+v\n", subnet)
 	}
 
+Example to Find a Subnet by Name or ID
+
+	subnet, err := subnets.Find(context.TODO(), networkClient, "private-subnet")
+	if err != nil {
+		panic(err)
+	}
+
 Example to Create a Subnet With Specified Gateway
 
 	var gatewayIP = "192.168.199.1"
@@ -80,6 +80,17 @@ func Get(ctx context.Context, c *gophercloud.ServiceClient, id string) (r GetRes
 	return
 }
 
+// Find returns the subnet with the given ID or name. When no subnet has the
+// ID, the name must match a single subnet; gophercloud.ErrResourceNotFound or
+// gophercloud.ErrMultipleResourcesFound is returned otherwise.
+func Find(ctx context.Context, c *gophercloud.ServiceClient, nameOrID string) (*Subnet, error) {
+	return pagination.FindByIDOrName(ctx, func(ctx context.Context) (*Subnet, error) {
+		return Get(ctx, c, nameOrID).Extract()
+	}, List(c, ListOpts{Name: nameOrID}), ExtractSubnets, "subnet", nameOrID, func(s Subnet) bool {
+		return s.Name == nameOrID
+	})
+}
+
 // CreateOptsBuilder allows extensions to add additional parameters to the
 // List request.
 type CreateOptsBuilder interface {
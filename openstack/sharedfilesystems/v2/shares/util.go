@@ -0,0 +1,42 @@
+package shares
+
+import (
+	"context"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// WaitForStatus will continually poll a share until it successfully transitions
+// to a specified status. It fails with an ErrWaitFailed if the share goes into
+// an error status.
+func WaitForStatus(ctx context.Context, c *gophercloud.ServiceClient, id, status string) error {
+	waiter := gophercloud.Waiter{
+		Multiplier: 1,
+		Jitter:     -1,
+		Target:     []string{status},
+		Failure:    []string{"error", "error_deleting", "extending_error", "shrinking_error", "shrinking_possible_data_loss_error", "reverting_error", "manage_error", "unmanage_error"},
+	}
+	return waiter.WaitForState(ctx, statusRefreshFunc(c, id))
+}
+
+// WaitForDeleted will continually poll a share until it is deleted. It fails
+// with an ErrWaitFailed if the deletion of the share fails.
+func WaitForDeleted(ctx context.Context, c *gophercloud.ServiceClient, id string) error {
+	waiter := gophercloud.Waiter{
+		Multiplier: 1,
+		Jitter:     -1,
+		Failure:    []string{"error_deleting"},
+		Deleted:    true,
+	}
+	return waiter.WaitForState(ctx, statusRefreshFunc(c, id))
+}
+
+func statusRefreshFunc(c *gophercloud.ServiceClient, id string) gophercloud.StateRefreshFunc {
+	return func(ctx context.Context) (string, error) {
+		current, err := Get(ctx, c, id).Extract()
+		if err != nil {
+			return "", err
+		}
+		return current.Status, nil
+	}
+}
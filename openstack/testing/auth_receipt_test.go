@@ -0,0 +1,111 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/keystone"
+)
+
+const totpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func setupMFAKeystone(t *testing.T) (*keystone.Server, keystone.User) {
+	ks := keystone.Setup()
+	t.Cleanup(ks.Teardown)
+
+	domain := ks.AddDomain("default")
+	project := ks.AddProject("demo", domain.ID)
+	user := ks.AddUser("alice", "secret", domain.ID)
+	ks.AssignProjectRole(user.ID, project.ID, "member")
+	ks.SetTOTPSecret(user.ID, totpSecret)
+	ks.SetMFARules(user.ID, [][]string{{"password", "totp"}})
+
+	return ks, user
+}
+
+func TestAuthReceipt(t *testing.T) {
+	ks, user := setupMFAKeystone(t)
+
+	ao := gophercloud.AuthOptions{
+		IdentityEndpoint: ks.AuthURL(),
+		Username:         "alice",
+		Password:         "secret",
+		DomainName:       "default",
+		TenantName:       "demo",
+	}
+	_, err := openstack.AuthenticatedClient(context.TODO(), ao)
+
+	var receiptErr gophercloud.ErrAuthReceiptRequired
+	if !errors.As(err, &receiptErr) {
+		t.Fatalf("expected an auth receipt, got %v", err)
+	}
+	th.AssertEquals(t, true, gophercloud.ResponseCodeIs(err, http.StatusUnauthorized))
+	th.AssertEquals(t, user.ID, receiptErr.Receipt.UserID)
+	th.AssertDeepEquals(t, []string{"password"}, receiptErr.Receipt.Methods)
+	th.AssertDeepEquals(t, [][]string{{"totp"}}, receiptErr.Receipt.RemainingMethods())
+	th.AssertEquals(t, 0, ks.IssuedTokens())
+
+	// complete the authentication with the receipt and a passcode
+	passcode, err := keystone.TOTPPasscode(totpSecret, ks.Now())
+	th.AssertNoErr(t, err)
+	ao.Password = ""
+	ao.Passcode = passcode
+	ao.AuthReceipt = receiptErr.Receipt.ID
+	provider, err := openstack.AuthenticatedClient(context.TODO(), ao)
+	th.AssertNoErr(t, err)
+
+	token, err := provider.GetAuthResult().(tokens.CreateResult).ExtractToken()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, provider.Token(), token.ID)
+	th.AssertEquals(t, 1, ks.IssuedTokens())
+
+	// a receipt is only valid for a few minutes
+	ks.Advance(10 * time.Minute)
+	_, err = openstack.AuthenticatedClient(context.TODO(), ao)
+	th.AssertEquals(t, true, gophercloud.ResponseCodeIs(err, http.StatusUnauthorized))
+}
+
+func TestAuthReceiptPasscodeFunc(t *testing.T) {
+	ks, user := setupMFAKeystone(t)
+
+	var prompts int
+	ao := &tokens.AuthOptions{
+		Username:    "alice",
+		Password:    "secret",
+		DomainName:  "default",
+		Scope:       tokens.Scope{ProjectName: "demo", DomainName: "default"},
+		AllowReauth: true,
+		PasscodeFunc: func(ctx context.Context, receipt gophercloud.AuthReceipt) (string, error) {
+			prompts++
+			th.AssertEquals(t, user.ID, receipt.UserID)
+			return keystone.TOTPPasscode(totpSecret, ks.Now())
+		},
+	}
+
+	provider, err := openstack.NewClient(ks.AuthURL())
+	th.AssertNoErr(t, err)
+	err = openstack.AuthenticateV3(context.TODO(), provider, ao, gophercloud.EndpointOpts{})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, prompts)
+	th.AssertEquals(t, true, ks.ValidToken(provider.Token()))
+
+	// reauthentication prompts again
+	ks.RevokeUserTokens(user.ID)
+	th.AssertNoErr(t, provider.Reauthenticate(context.TODO(), provider.Token()))
+	th.AssertEquals(t, 2, prompts)
+	th.AssertEquals(t, true, ks.ValidToken(provider.Token()))
+
+	// errors of the callback are returned
+	ao.PasscodeFunc = func(context.Context, gophercloud.AuthReceipt) (string, error) {
+		return "", errors.New("canceled by the user")
+	}
+	err = openstack.AuthenticateV3(context.TODO(), provider, ao, gophercloud.EndpointOpts{})
+	th.AssertEquals(t, "canceled by the user", err.Error())
+}
@@ -0,0 +1,213 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/applicationcredentials"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/keystone"
+)
+
+type rotationCloud struct {
+	ks       *keystone.Server
+	user     keystone.User
+	appCred  keystone.ApplicationCredential
+	provider *gophercloud.ProviderClient
+	identity *gophercloud.ServiceClient
+}
+
+// setupRotation returns a ProviderClient authenticated with an application
+// credential, that can reauthenticate.
+func setupRotation(t *testing.T, unrestricted bool) *rotationCloud {
+	c := &rotationCloud{ks: keystone.Setup()}
+	t.Cleanup(c.ks.Teardown)
+
+	domain := c.ks.AddDomain("default")
+	project := c.ks.AddProject("demo", domain.ID)
+	c.user = c.ks.AddUser("alice", "secret", domain.ID)
+	c.ks.AssignProjectRole(c.user.ID, project.ID, "member")
+	c.ks.AssignProjectRole(c.user.ID, project.ID, "reader")
+
+	c.appCred = c.ks.AddApplicationCredential(keystone.ApplicationCredential{
+		Name:         "ci",
+		Description:  "CI pipelines",
+		UserID:       c.user.ID,
+		ProjectID:    project.ID,
+		Roles:        []string{"member"},
+		ExpiresAt:    time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		Unrestricted: unrestricted,
+		AccessRules: []keystone.AccessRule{
+			{ID: "rule", Service: "compute", Method: "GET", Path: "/v2.1/servers"},
+		},
+	})
+
+	var err error
+	c.provider, err = openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint:            c.ks.AuthURL(),
+		ApplicationCredentialID:     c.appCred.ID,
+		ApplicationCredentialSecret: c.appCred.Secret,
+		AllowReauth:                 true,
+	})
+	th.AssertNoErr(t, err)
+	c.identity, err = openstack.NewIdentityV3(context.TODO(), c.provider, gophercloud.EndpointOpts{})
+	th.AssertNoErr(t, err)
+
+	return c
+}
+
+// appCredIDs returns the IDs of the application credentials of the user.
+func (c *rotationCloud) appCredIDs() []string {
+	var ids []string
+	for _, ac := range c.ks.ApplicationCredentials(c.user.ID) {
+		ids = append(ids, ac.ID)
+	}
+	return ids
+}
+
+func TestRotateApplicationCredential(t *testing.T) {
+	c := setupRotation(t, true)
+	previousToken := c.provider.Token()
+
+	var persisted *applicationcredentials.ApplicationCredential
+	rotation, err := openstack.RotateApplicationCredential(context.TODO(), c.identity, openstack.ApplicationCredentialRotateOpts{
+		ProviderClient: c.provider,
+		Persist: func(_ context.Context, ac *applicationcredentials.ApplicationCredential) error {
+			persisted = ac
+			return nil
+		},
+	})
+	th.AssertNoErr(t, err)
+
+	current := rotation.Current
+	th.AssertEquals(t, c.appCred.ID, rotation.Previous.ID)
+	th.AssertEquals(t, current, persisted)
+	th.AssertEquals(t, true, strings.HasPrefix(current.Name, "ci-"))
+	th.AssertEquals(t, "CI pipelines", current.Description)
+	th.AssertEquals(t, true, current.Unrestricted)
+	th.AssertEquals(t, true, current.Secret != "")
+	th.AssertEquals(t, 1, len(current.Roles))
+	th.AssertEquals(t, "member", current.Roles[0].Name)
+	th.AssertDeepEquals(t, rotation.Previous.AccessRules, current.AccessRules)
+	th.AssertEquals(t, true, rotation.Previous.ExpiresAt.Equal(current.ExpiresAt))
+
+	// the ProviderClient uses a token of the replacement
+	th.AssertEquals(t, true, c.provider.Token() != previousToken)
+	th.AssertEquals(t, true, c.ks.ValidToken(c.provider.Token()))
+
+	// the rotated application credential is deleted right away
+	th.AssertNoErr(t, rotation.Wait(context.TODO()))
+	th.AssertDeepEquals(t, []string{current.ID}, c.appCredIDs())
+	th.AssertEquals(t, false, c.ks.ValidToken(previousToken))
+
+	// the ProviderClient reauthenticates with the replacement
+	c.ks.RevokeToken(c.provider.Token())
+	_, err = applicationcredentials.Get(context.TODO(), c.identity, c.user.ID, current.ID).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, true, c.ks.ValidToken(c.provider.Token()))
+
+	// a rotated application credential can be rotated again
+	rotation, err = openstack.RotateApplicationCredential(context.TODO(), c.identity, openstack.ApplicationCredentialRotateOpts{
+		ProviderClient: c.provider,
+		Name:           "ci-next",
+		Lifetime:       24 * time.Hour,
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, current.ID, rotation.Previous.ID)
+	th.AssertEquals(t, "ci-next", rotation.Current.Name)
+	th.AssertEquals(t, true, rotation.Current.ExpiresAt.Before(rotation.Previous.ExpiresAt))
+	th.AssertNoErr(t, rotation.Wait(context.TODO()))
+}
+
+func TestRotateApplicationCredentialGracePeriod(t *testing.T) {
+	c := setupRotation(t, true)
+
+	rotation, err := openstack.RotateApplicationCredential(context.TODO(), c.identity, openstack.ApplicationCredentialRotateOpts{
+		ProviderClient: c.provider,
+		GracePeriod:    50 * time.Millisecond,
+	})
+	th.AssertNoErr(t, err)
+
+	// the rotated application credential is kept during the grace period
+	th.AssertEquals(t, 2, len(c.appCredIDs()))
+	th.AssertNoErr(t, rotation.Wait(context.TODO()))
+	th.AssertDeepEquals(t, []string{rotation.Current.ID}, c.appCredIDs())
+
+	// the deletion outlives the context of the rotation
+	ctx, cancel := context.WithCancel(context.TODO())
+	rotation, err = openstack.RotateApplicationCredential(ctx, c.identity, openstack.ApplicationCredentialRotateOpts{
+		ID:             rotation.Current.ID,
+		ProviderClient: c.provider,
+		GracePeriod:    50 * time.Millisecond,
+	})
+	th.AssertNoErr(t, err)
+	cancel()
+	th.AssertNoErr(t, rotation.Wait(context.TODO()))
+	th.AssertDeepEquals(t, []string{rotation.Current.ID}, c.appCredIDs())
+
+	// the deletion is abandoned when the rotation is canceled
+	rotation, err = openstack.RotateApplicationCredential(context.TODO(), c.identity, openstack.ApplicationCredentialRotateOpts{
+		ID:             rotation.Current.ID,
+		ProviderClient: c.provider,
+		GracePeriod:    time.Hour,
+	})
+	th.AssertNoErr(t, err)
+	rotation.Cancel()
+	th.AssertErrIs(t, rotation.Wait(context.TODO()), context.Canceled)
+	th.AssertEquals(t, 2, len(c.appCredIDs()))
+}
+
+func TestRotateApplicationCredentialPersistFailure(t *testing.T) {
+	c := setupRotation(t, true)
+	previousToken := c.provider.Token()
+
+	persistErr := errors.New("vault is sealed")
+	_, err := openstack.RotateApplicationCredential(context.TODO(), c.identity, openstack.ApplicationCredentialRotateOpts{
+		ProviderClient: c.provider,
+		Persist: func(context.Context, *applicationcredentials.ApplicationCredential) error {
+			return persistErr
+		},
+	})
+	th.AssertErrIs(t, err, persistErr)
+
+	// the replacement is deleted, and the ProviderClient is unchanged
+	th.AssertDeepEquals(t, []string{c.appCred.ID}, c.appCredIDs())
+	th.AssertEquals(t, previousToken, c.provider.Token())
+}
+
+func TestRotateRestrictedApplicationCredential(t *testing.T) {
+	c := setupRotation(t, false)
+
+	_, err := openstack.RotateApplicationCredential(context.TODO(), c.identity, openstack.ApplicationCredentialRotateOpts{})
+	th.AssertEquals(t, true, gophercloud.ResponseCodeIs(err, http.StatusForbidden))
+
+	// the identity client of the user can rotate it
+	provider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.AuthURL(),
+		Username:         "alice",
+		Password:         "secret",
+		DomainName:       "default",
+		TenantName:       "demo",
+	})
+	th.AssertNoErr(t, err)
+	identity, err := openstack.NewIdentityV3(context.TODO(), provider, gophercloud.EndpointOpts{})
+	th.AssertNoErr(t, err)
+
+	rotation, err := openstack.RotateApplicationCredential(context.TODO(), identity, openstack.ApplicationCredentialRotateOpts{
+		ID:             c.appCred.ID,
+		ProviderClient: c.provider,
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, false, rotation.Current.Unrestricted)
+	th.AssertNoErr(t, rotation.Wait(context.TODO()))
+
+	c.ks.RevokeToken(c.provider.Token())
+	th.AssertNoErr(t, c.provider.Reauthenticate(context.TODO(), ""))
+	th.AssertEquals(t, true, c.ks.ValidToken(c.provider.Token()))
+}
@@ -0,0 +1,118 @@
+package testing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+func catalogProvider(located *[]gophercloud.EndpointOpts) *gophercloud.ProviderClient {
+	return &gophercloud.ProviderClient{
+		IdentityBase: "https://identity.example.com/",
+		EndpointLocator: func(_ context.Context, eo gophercloud.EndpointOpts) (string, error) {
+			*located = append(*located, eo)
+			return "https://" + string(eo.Availability) + "." + eo.Type + ".example.com/", nil
+		},
+	}
+}
+
+func TestEndpointOverride(t *testing.T) {
+	var located []gophercloud.EndpointOpts
+	provider := catalogProvider(&located)
+
+	eo := gophercloud.EndpointOpts{
+		Region: "RegionOne",
+		EndpointOverrides: map[string]string{
+			"compute": "http://localhost:8774/v2.1",
+			// an alias of block-storage
+			"volumev3": "http://localhost:8776/v3",
+			"network":  "http://localhost:9696",
+		},
+	}
+
+	compute, err := openstack.NewComputeV2(context.TODO(), provider, eo)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "http://localhost:8774/v2.1/", compute.Endpoint)
+
+	volume, err := openstack.NewBlockStorageV3(context.TODO(), provider, eo)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "http://localhost:8776/v3/", volume.Endpoint)
+
+	network, err := openstack.NewNetworkV2(context.TODO(), provider, eo)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "http://localhost:9696/", network.Endpoint)
+	th.AssertEquals(t, "http://localhost:9696/v2.0/", network.ResourceBase)
+
+	th.AssertEquals(t, 0, len(located))
+
+	image, err := openstack.NewImageV2(context.TODO(), provider, eo)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "https://public.image.example.com/", image.Endpoint)
+	th.AssertEquals(t, 1, len(located))
+}
+
+func TestEndpointOverrideIdentity(t *testing.T) {
+	var located []gophercloud.EndpointOpts
+	provider := catalogProvider(&located)
+
+	identity, err := openstack.NewIdentityV3(context.TODO(), provider, gophercloud.EndpointOpts{
+		EndpointOverrides: map[string]string{"identity": "http://localhost:5000"},
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "http://localhost:5000/v3/", identity.Endpoint)
+	th.AssertEquals(t, 0, len(located))
+}
+
+func TestServiceAvailabilities(t *testing.T) {
+	var located []gophercloud.EndpointOpts
+	provider := catalogProvider(&located)
+
+	eo := gophercloud.EndpointOpts{
+		Availability: gophercloud.AvailabilityInternal,
+		Availabilities: map[string]gophercloud.Availability{
+			"compute": gophercloud.AvailabilityAdmin,
+		},
+	}
+
+	compute, err := openstack.NewComputeV2(context.TODO(), provider, eo)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "https://admin.compute.example.com/", compute.Endpoint)
+
+	image, err := openstack.NewImageV2(context.TODO(), provider, eo)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "https://internal.image.example.com/", image.Endpoint)
+}
+
+func TestAPIVersions(t *testing.T) {
+	var located []gophercloud.EndpointOpts
+	provider := catalogProvider(&located)
+
+	eo := gophercloud.EndpointOpts{
+		APIVersions: map[string]string{
+			"compute":  "2.79",
+			"volume":   "3.60",
+			"image":    "2",
+			"identity": "3",
+		},
+	}
+
+	compute, err := openstack.NewComputeV2(context.TODO(), provider, eo)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "2.79", compute.Microversion)
+
+	volumeV3, err := openstack.NewBlockStorageV3(context.TODO(), provider, eo)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "3.60", volumeV3.Microversion)
+
+	// the version is for another major version of the service
+	volumeV2, err := openstack.NewBlockStorageV2(context.TODO(), provider, eo)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "", volumeV2.Microversion)
+
+	image, err := openstack.NewImageV2(context.TODO(), provider, eo)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "", image.Microversion)
+}
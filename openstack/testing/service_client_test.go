@@ -0,0 +1,150 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/keystone"
+)
+
+// setupServices starts a simulated Keystone, and a server of the version
+// discovery documents of a few services.
+func setupServices(t *testing.T) (*gophercloud.ProviderClient, th.FakeServer) {
+	ks := keystone.Setup()
+	t.Cleanup(ks.Teardown)
+
+	domain := ks.AddDomain("default")
+	project := ks.AddProject("demo", domain.ID)
+	user := ks.AddUser("alice", "secret", domain.ID)
+	ks.AssignProjectRole(user.ID, project.ID, "member")
+
+	fakeServer := th.SetupHTTP()
+	t.Cleanup(fakeServer.Teardown)
+
+	versions := map[string]string{
+		"network": `{"versions": [
+			{"id": "v2.0", "status": "CURRENT", "links": [{"href": "%[1]snetwork/v2.0/", "rel": "self"}]}
+		]}`,
+		"accelerator": `{"versions": [
+			{"id": "v2.0", "status": "CURRENT", "min_version": "2.0", "max_version": "2.3", "links": [{"href": "%[1]saccelerator/v2/", "rel": "self"}]},
+			{"id": "v1", "status": "DEPRECATED", "links": [{"href": "%[1]saccelerator/v1/", "rel": "self"}]}
+		]}`,
+		"placement": `{"versions": [
+			{"id": "v1.0", "status": "CURRENT", "min_version": "1.0", "max_version": "1.39", "links": [{"href": "", "rel": "self"}]}
+		]}`,
+	}
+	for service, doc := range versions {
+		fakeServer.Mux.HandleFunc("GET /"+service+"/{$}", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, doc, fakeServer.Endpoint())
+		})
+		ks.RegisterService(service, service, keystone.PublicEndpoint(fakeServer.Endpoint()+service))
+	}
+	ks.RegisterService("volumev3", "cinderv3", keystone.PublicEndpoint(fakeServer.Endpoint()+"volume/v3/"+project.ID))
+
+	provider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: ks.AuthURL(),
+		Username:         "alice",
+		Password:         "secret",
+		DomainName:       "default",
+		TenantName:       "demo",
+	})
+	th.AssertNoErr(t, err)
+	return provider, fakeServer
+}
+
+func TestNewServiceClient(t *testing.T) {
+	provider, fakeServer := setupServices(t)
+
+	// a service of this package with an unversioned endpoint
+	network, err := openstack.NewServiceClient(context.TODO(), provider, "network", gophercloud.EndpointOpts{})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "network", network.Type)
+	th.AssertEquals(t, fakeServer.Endpoint()+"network/", network.Endpoint)
+	th.AssertEquals(t, fakeServer.Endpoint()+"network/v2.0/networks", network.ServiceURL("networks"))
+
+	// a service of this package that serves its API at the root of its
+	// endpoint
+	placement, err := openstack.NewServiceClient(context.TODO(), provider, "placement", gophercloud.EndpointOpts{
+		APIVersions: map[string]string{"placement": "1.39"},
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, fakeServer.Endpoint()+"placement/resource_providers", placement.ServiceURL("resource_providers"))
+	th.AssertEquals(t, "1.39", placement.Microversion)
+}
+
+func TestNewServiceClientAliases(t *testing.T) {
+	provider, fakeServer := setupServices(t)
+
+	// the catalog uses the legacy volumev3 type
+	for _, serviceType := range []string{"block-storage", "volumev3", "volume"} {
+		volume, err := openstack.NewServiceClient(context.TODO(), provider, serviceType, gophercloud.EndpointOpts{})
+		th.AssertNoErr(t, err)
+		th.AssertEquals(t, "block-storage", volume.Type)
+		th.AssertEquals(t, fakeServer.Endpoint()+"volume/v3/", volume.Endpoint[:len(fakeServer.Endpoint())+len("volume/v3/")])
+		th.AssertEquals(t, "", volume.ResourceBase)
+	}
+}
+
+func TestNewServiceClientDiscovery(t *testing.T) {
+	provider, fakeServer := setupServices(t)
+
+	// a service unknown to this package uses its current version
+	accelerator, err := openstack.NewServiceClient(context.TODO(), provider, "accelerator", gophercloud.EndpointOpts{
+		APIVersions: map[string]string{"accelerator": "2.3"},
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, fakeServer.Endpoint()+"accelerator/v2/", accelerator.ResourceBase)
+	th.AssertEquals(t, "2.3", accelerator.Microversion)
+
+	accelerator, err = openstack.NewServiceClient(context.TODO(), provider, "accelerator", gophercloud.EndpointOpts{Version: 1})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, fakeServer.Endpoint()+"accelerator/v1/", accelerator.ResourceBase)
+	th.AssertEquals(t, "", accelerator.Microversion)
+
+	_, err = openstack.NewServiceClient(context.TODO(), provider, "accelerator", gophercloud.EndpointOpts{
+		Version:           3,
+		EndpointOverrides: map[string]string{"accelerator": fakeServer.Endpoint() + "accelerator"},
+	})
+	if err == nil {
+		t.Fatalf("expected an error for an unsupported version")
+	}
+
+	// without discovery document, a versioned endpoint is used as is
+	widget, err := openstack.NewServiceClient(context.TODO(), provider, "widget", gophercloud.EndpointOpts{
+		EndpointOverrides: map[string]string{"widget": fakeServer.Endpoint() + "widget/v1"},
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, fakeServer.Endpoint()+"widget/v1/", widget.Endpoint)
+	th.AssertEquals(t, "", widget.ResourceBase)
+
+	_, err = openstack.NewServiceClient(context.TODO(), provider, "widget", gophercloud.EndpointOpts{})
+	if err == nil {
+		t.Fatalf("expected an error for a service missing from the catalog")
+	}
+
+	// without discovery document, the requested version is used
+	gadget, err := openstack.NewServiceClient(context.TODO(), provider, "gadget", gophercloud.EndpointOpts{
+		Version:           2,
+		EndpointOverrides: map[string]string{"gadget": fakeServer.Endpoint() + "gadget"},
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, fakeServer.Endpoint()+"gadget/", gadget.Endpoint)
+
+	// the other discovery errors are not ignored
+	fakeServer.Mux.HandleFunc("GET /broken/{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	_, err = openstack.NewServiceClient(context.TODO(), provider, "broken", gophercloud.EndpointOpts{
+		Version:           2,
+		EndpointOverrides: map[string]string{"broken": fakeServer.Endpoint() + "broken"},
+	})
+	if !gophercloud.ResponseCodeIs(err, http.StatusServiceUnavailable) {
+		t.Fatalf("expected the discovery error, got %v", err)
+	}
+}
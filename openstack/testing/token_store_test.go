@@ -0,0 +1,187 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+type memoryTokenStore struct {
+	tokens map[gophercloud.TokenStoreKey]gophercloud.StoredToken
+	saves  int
+}
+
+func (s *memoryTokenStore) LoadToken(_ context.Context, key gophercloud.TokenStoreKey) (*gophercloud.StoredToken, error) {
+	token, ok := s.tokens[key]
+	if !ok {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+func (s *memoryTokenStore) SaveToken(_ context.Context, key gophercloud.TokenStoreKey, token gophercloud.StoredToken) error {
+	s.tokens[key] = token
+	s.saves++
+	return nil
+}
+
+func setupTokenStoreServer(fakeServer th.FakeServer, expiresAt time.Time, tokenRequests *int) {
+	fakeServer.Mux.HandleFunc("/v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		*tokenRequests++
+		w.Header().Add("X-Subject-Token", fmt.Sprintf("token-%d", *tokenRequests))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `
+			{
+				"token": {
+					"expires_at": "%s",
+					"catalog": [
+						{
+							"type": "compute",
+							"endpoints": [
+								{ "interface": "public", "region": "RegionOne", "url": "%s" }
+							]
+						}
+					]
+				}
+			}
+		`, expiresAt.UTC().Format(gophercloud.RFC3339Milli), fakeServer.Endpoint()+"compute/")
+	})
+}
+
+func TestAuthenticateWithTokenStore(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	var tokenRequests int
+	setupTokenStoreServer(fakeServer, time.Now().Add(time.Hour), &tokenRequests)
+
+	store := &memoryTokenStore{tokens: map[gophercloud.TokenStoreKey]gophercloud.StoredToken{}}
+	options := gophercloud.AuthOptions{
+		Username:         "me",
+		Password:         "secret",
+		DomainName:       "default",
+		TenantName:       "project",
+		IdentityEndpoint: fakeServer.Endpoint() + "v3/",
+		TokenStore:       store,
+	}
+
+	client, err := openstack.AuthenticatedClient(context.TODO(), options)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "token-1", client.Token())
+	th.AssertEquals(t, 1, tokenRequests)
+	th.AssertEquals(t, 1, store.saves)
+
+	// a second client with the same options reuses the stored token
+	client, err = openstack.AuthenticatedClient(context.TODO(), options)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "token-1", client.Token())
+	th.AssertEquals(t, 1, tokenRequests)
+
+	endpoint, err := client.EndpointLocator(context.TODO(), gophercloud.EndpointOpts{Type: "compute", Region: "RegionOne", Availability: gophercloud.AvailabilityPublic})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, fakeServer.Endpoint()+"compute/", endpoint)
+
+	// another project does not share the token
+	options.TenantName = "other"
+	client, err = openstack.AuthenticatedClient(context.TODO(), options)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "token-2", client.Token())
+	th.AssertEquals(t, 2, tokenRequests)
+	th.AssertEquals(t, 2, store.saves)
+}
+
+func TestAuthenticateWithTokenStoreExpiring(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	var tokenRequests int
+	setupTokenStoreServer(fakeServer, time.Now().Add(30*time.Second), &tokenRequests)
+
+	store := &memoryTokenStore{tokens: map[gophercloud.TokenStoreKey]gophercloud.StoredToken{}}
+	options := gophercloud.AuthOptions{
+		UserID:           "0123456789",
+		Password:         "secret",
+		IdentityEndpoint: fakeServer.Endpoint() + "v3/",
+		TokenStore:       store,
+	}
+
+	_, err := openstack.AuthenticatedClient(context.TODO(), options)
+	th.AssertNoErr(t, err)
+
+	// the stored token expires too soon to be reused
+	client, err := openstack.AuthenticatedClient(context.TODO(), options)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "token-2", client.Token())
+	th.AssertEquals(t, 2, tokenRequests)
+}
+
+func TestAuthenticateWithTokenStoreTokenID(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	var tokenRequests int
+	setupTokenStoreServer(fakeServer, time.Now().Add(time.Hour), &tokenRequests)
+
+	store := &memoryTokenStore{tokens: map[gophercloud.TokenStoreKey]gophercloud.StoredToken{}}
+	options := gophercloud.AuthOptions{
+		TokenID:          "alice-token",
+		Scope:            &gophercloud.AuthScope{ProjectID: "project"},
+		IdentityEndpoint: fakeServer.Endpoint() + "v3/",
+		TokenStore:       store,
+	}
+
+	client, err := openstack.AuthenticatedClient(context.TODO(), options)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "token-1", client.Token())
+
+	// the token ID is not part of the key, only its digest
+	for key := range store.tokens {
+		th.AssertEquals(t, false, key.CredentialHash == "")
+		th.AssertEquals(t, false, key.CredentialHash == options.TokenID)
+	}
+
+	// the same token ID reuses the stored token
+	client, err = openstack.AuthenticatedClient(context.TODO(), options)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "token-1", client.Token())
+	th.AssertEquals(t, 1, tokenRequests)
+
+	// another token ID with the same scope does not share it
+	options.TokenID = "mallory-token"
+	client, err = openstack.AuthenticatedClient(context.TODO(), options)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "token-2", client.Token())
+	th.AssertEquals(t, 2, tokenRequests)
+	th.AssertEquals(t, 2, len(store.tokens))
+}
+
+func TestAuthenticateWithTokenStorePassword(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	var tokenRequests int
+	setupTokenStoreServer(fakeServer, time.Now().Add(time.Hour), &tokenRequests)
+
+	store := &memoryTokenStore{tokens: map[gophercloud.TokenStoreKey]gophercloud.StoredToken{}}
+	options := gophercloud.AuthOptions{
+		UserID:           "0123456789",
+		Password:         "secret",
+		IdentityEndpoint: fakeServer.Endpoint() + "v3/",
+		TokenStore:       store,
+	}
+
+	_, err := openstack.AuthenticatedClient(context.TODO(), options)
+	th.AssertNoErr(t, err)
+
+	// knowing the user is not enough to get the stored token
+	options.Password = "guess"
+	client, err := openstack.AuthenticatedClient(context.TODO(), options)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "token-2", client.Token())
+}
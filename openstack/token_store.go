@@ -0,0 +1,145 @@
+package openstack
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	tokens3 "github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+)
+
+// storedTokenMinValidity is the minimum remaining lifetime of a stored token
+// for it to be reused.
+const storedTokenMinValidity = time.Minute
+
+// tokenStoreKey derives the key under which the tokens created with the given
+// options are stored. It returns false if the options identify neither a user
+// nor a credential, in which case the tokens must not be shared.
+func tokenStoreKey(endpoint string, opts *gophercloud.AuthOptions, eo gophercloud.EndpointOpts) (gophercloud.TokenStoreKey, bool) {
+	if endpoint == "" {
+		endpoint = opts.IdentityEndpoint
+	}
+
+	key := gophercloud.TokenStoreKey{
+		AuthURL:                   endpoint,
+		UserID:                    opts.UserID,
+		Username:                  opts.Username,
+		UserDomainID:              opts.DomainID,
+		UserDomainName:            opts.DomainName,
+		ApplicationCredentialID:   opts.ApplicationCredentialID,
+		ApplicationCredentialName: opts.ApplicationCredentialName,
+		CredentialHash:            credentialHash(endpoint, opts),
+		Region:                    eo.Region,
+	}
+
+	if opts.Scope != nil {
+		key.ProjectID = opts.Scope.ProjectID
+		key.ProjectName = opts.Scope.ProjectName
+		key.ScopeDomainID = opts.Scope.DomainID
+		key.ScopeDomainName = opts.Scope.DomainName
+		key.System = opts.Scope.System
+		key.TrustID = opts.Scope.TrustID
+	} else {
+		// mirror the implicit scope of AuthOptions.ToTokenV3ScopeMap
+		key.ProjectID = opts.TenantID
+		if opts.TenantID == "" && opts.TenantName != "" {
+			key.ProjectName = opts.TenantName
+			key.ScopeDomainID = opts.DomainID
+			key.ScopeDomainName = opts.DomainName
+		}
+	}
+
+	identified := key.UserID != "" || key.Username != "" ||
+		key.ApplicationCredentialID != "" || key.ApplicationCredentialName != "" ||
+		key.CredentialHash != ""
+	return key, identified
+}
+
+// credentialHash returns a digest of the secrets of the options, including
+// the OIDC settings, or an empty string if there are none. The endpoint and
+// the user are part of the digest, so that equal secrets of different users
+// have different digests.
+func credentialHash(endpoint string, opts *gophercloud.AuthOptions) string {
+	if opts.Password == "" && opts.Passcode == "" && opts.ApplicationCredentialSecret == "" && opts.TokenID == "" && opts.OIDC == nil {
+		return ""
+	}
+
+	// marshaling strings and a struct of strings cannot fail
+	b, _ := json.Marshal([]any{
+		endpoint, opts.UserID, opts.Username,
+		opts.Password, opts.Passcode, opts.ApplicationCredentialSecret, opts.TokenID,
+		opts.OIDC,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadStoredToken looks up a reusable token in the store. A stored token is
+// only returned if it does not expire soon and carries a service catalog.
+// Failures of the store are not fatal: a new token is created instead.
+func loadStoredToken(ctx context.Context, client *gophercloud.ProviderClient, store gophercloud.TokenStore, key gophercloud.TokenStoreKey) (tokens3.CreateResult, bool) {
+	var result tokens3.CreateResult
+
+	stored, err := store.LoadToken(ctx, key)
+	if err != nil {
+		logTokenStoreError(ctx, client, "Unable to load a stored token", err)
+		return result, false
+	}
+	if stored == nil || stored.ID == "" {
+		return result, false
+	}
+
+	minValidity := max(client.TokenRefreshWindow, storedTokenMinValidity)
+	if time.Until(stored.ExpiresAt) < minValidity {
+		return result, false
+	}
+
+	var body any
+	if err := json.Unmarshal(stored.Body, &body); err != nil {
+		logTokenStoreError(ctx, client, "Ignoring an invalid stored token", err)
+		return result, false
+	}
+	result.Body = body
+	result.Header = http.Header{"X-Subject-Token": []string{stored.ID}}
+
+	catalog, err := result.ExtractServiceCatalog()
+	if err != nil || len(catalog.Entries) == 0 {
+		return result, false
+	}
+
+	return result, true
+}
+
+// saveStoredToken saves a newly created token to the store.
+func saveStoredToken(ctx context.Context, client *gophercloud.ProviderClient, store gophercloud.TokenStore, key gophercloud.TokenStoreKey, result tokens3.CreateResult) {
+	token, err := result.ExtractToken()
+	if err != nil {
+		return
+	}
+
+	body, err := json.Marshal(result.Body)
+	if err != nil {
+		logTokenStoreError(ctx, client, "Unable to store the token", err)
+		return
+	}
+
+	err = store.SaveToken(ctx, key, gophercloud.StoredToken{
+		ID:        token.ID,
+		ExpiresAt: token.ExpiresAt,
+		Body:      body,
+	})
+	if err != nil {
+		logTokenStoreError(ctx, client, "Unable to store the token", err)
+	}
+}
+
+func logTokenStoreError(ctx context.Context, client *gophercloud.ProviderClient, msg string, err error) {
+	if client.Logger != nil {
+		client.Logger.LogAttrs(ctx, slog.LevelWarn, msg, slog.Any("error", err))
+	}
+}
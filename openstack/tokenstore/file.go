@@ -0,0 +1,162 @@
+/*
+Package tokenstore provides implementations of gophercloud.TokenStore, which
+allow processes authenticating with the same credentials to share a Keystone
+token.
+
+Example to share tokens between invocations of a command line tool
+
+	store, err := tokenstore.NewDefaultFileStore()
+	if err != nil {
+		panic(err)
+	}
+
+	opts, err := openstack.AuthOptionsFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	opts.TokenStore = store
+
+	provider, err := openstack.AuthenticatedClient(context.TODO(), opts)
+	if err != nil {
+		panic(err)
+	}
+*/
+package tokenstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+const (
+	// lockRetryInterval is the delay between two attempts to acquire a lock.
+	lockRetryInterval = 10 * time.Millisecond
+
+	// staleLockAge is the age after which a lock file is considered to be
+	// left over by a crashed process, and removed.
+	staleLockAge = 10 * time.Second
+)
+
+// FileStore is a gophercloud.TokenStore that saves each token in its own file.
+// Files are named after the hash of their key, and are only readable by their
+// owner. Writes are serialized with a lock file and replace the token file
+// atomically, so FileStore is safe for concurrent use by several processes.
+type FileStore struct {
+	dir string
+}
+
+var _ gophercloud.TokenStore = (*FileStore)(nil)
+
+// NewFileStore returns a FileStore that saves tokens in the given directory.
+// The directory is created on the first write if it does not exist.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// NewDefaultFileStore returns a FileStore that saves tokens in the
+// "gophercloud/tokens" directory of the user cache directory (see
+// os.UserCacheDir).
+func NewDefaultFileStore() (*FileStore, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewFileStore(filepath.Join(cacheDir, "gophercloud", "tokens")), nil
+}
+
+func (s *FileStore) path(key gophercloud.TokenStoreKey) string {
+	return filepath.Join(s.dir, key.Hash()+".json")
+}
+
+// LoadToken implements gophercloud.TokenStore.
+func (s *FileStore) LoadToken(_ context.Context, key gophercloud.TokenStoreKey) (*gophercloud.StoredToken, error) {
+	b, err := os.ReadFile(s.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var token gophercloud.StoredToken
+	if err := json.Unmarshal(b, &token); err != nil {
+		return nil, fmt.Errorf("invalid token file %s: %w", s.path(key), err)
+	}
+	return &token, nil
+}
+
+// SaveToken implements gophercloud.TokenStore.
+func (s *FileStore) SaveToken(ctx context.Context, key gophercloud.TokenStoreKey, token gophercloud.StoredToken) error {
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+
+	path := s.path(key)
+	unlock, err := lock(ctx, path+".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// write to a temporary file first, so that readers never see a partial
+	// token file
+	f, err := os.CreateTemp(s.dir, ".token-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// lock acquires an exclusive lock by creating the given lock file, waiting
+// for other holders to release it. It returns a function that releases the
+// lock.
+func lock(ctx context.Context, path string) (func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+
+		select {
+		case <-time.After(lockRetryInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,2 @@
+// tokenstore
+package testing
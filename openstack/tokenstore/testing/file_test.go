@@ -0,0 +1,75 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/tokenstore"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+var key = gophercloud.TokenStoreKey{
+	AuthURL:     "https://keystone.example.com/v3/",
+	Username:    "me",
+	ProjectName: "project",
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store := tokenstore.NewFileStore(dir)
+
+	loaded, err := store.LoadToken(context.TODO(), key)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, true, loaded == nil)
+
+	token := gophercloud.StoredToken{
+		ID:        "0123456789",
+		ExpiresAt: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		Body:      json.RawMessage(`{"token":{"catalog":[]}}`),
+	}
+	th.AssertNoErr(t, store.SaveToken(context.TODO(), key, token))
+
+	loaded, err = store.LoadToken(context.TODO(), key)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, token.ID, loaded.ID)
+	th.AssertEquals(t, true, token.ExpiresAt.Equal(loaded.ExpiresAt))
+	th.AssertJSONEquals(t, string(token.Body), loaded.Body)
+
+	info, err := os.Stat(filepath.Join(dir, key.Hash()+".json"))
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, os.FileMode(0600), info.Mode().Perm())
+
+	other := key
+	other.ProjectName = "other"
+	loaded, err = store.LoadToken(context.TODO(), other)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, true, loaded == nil)
+}
+
+func TestFileStoreConcurrentSaves(t *testing.T) {
+	store := tokenstore.NewFileStore(t.TempDir())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := store.SaveToken(context.TODO(), key, gophercloud.StoredToken{
+				ID:   "0123456789",
+				Body: json.RawMessage(`{}`),
+			})
+			th.AssertNoErr(t, err)
+		}()
+	}
+	wg.Wait()
+
+	loaded, err := store.LoadToken(context.TODO(), key)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "0123456789", loaded.ID)
+}
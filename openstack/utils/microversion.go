@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// microversionCache stores the microversions supported by the endpoints that
+// were negotiated with, keyed by endpoint URL.
+var microversionCache sync.Map
+
+// NegotiateMicroversion picks the highest microversion that is both
+// understood by the caller, i.e. between minVersion and maxVersion (inclusive),
+// and supported by the endpoint of the ServiceClient. It returns a
+// ServiceClient with the chosen microversion set.
+//
+// An empty minVersion stands for the minimum microversion supported by the
+// endpoint, and an empty or "latest" maxVersion for the maximum one.
+//
+// The microversions supported by an endpoint are discovered on the first
+// negotiation and cached for the lifetime of the process, so that creating
+// many ServiceClients for the same endpoint only queries it once. Use
+// ResetMicroversionCache to discard the cached results.
+func NegotiateMicroversion(ctx context.Context, client gophercloud.ServiceClient, minVersion, maxVersion string) (gophercloud.ServiceClient, error) {
+	supported, err := cachedSupportedMicroversions(ctx, &client)
+	if err != nil {
+		return client, fmt.Errorf("unable to determine supported microversions: %w", err)
+	}
+
+	lowMajor, lowMinor := supported.MinMajor, supported.MinMinor
+	if minVersion != "" {
+		major, minor, err := ParseMicroversion(minVersion)
+		if err != nil {
+			return client, err
+		}
+		if compareMicroversions(major, minor, lowMajor, lowMinor) > 0 {
+			lowMajor, lowMinor = major, minor
+		}
+	}
+
+	highMajor, highMinor := supported.MaxMajor, supported.MaxMinor
+	if maxVersion != "" && maxVersion != "latest" {
+		major, minor, err := ParseMicroversion(maxVersion)
+		if err != nil {
+			return client, err
+		}
+		if compareMicroversions(major, minor, highMajor, highMinor) < 0 {
+			highMajor, highMinor = major, minor
+		}
+	}
+
+	if compareMicroversions(lowMajor, lowMinor, highMajor, highMinor) > 0 {
+		return client, fmt.Errorf("no microversion between %s and %s is supported. Supported versions: %v", minVersion, maxVersion, supported)
+	}
+
+	client.Microversion = fmt.Sprintf("%d.%d", highMajor, highMinor)
+	return client, nil
+}
+
+// ResetMicroversionCache discards the microversions discovered by
+// NegotiateMicroversion. This is useful after an upgrade of the cloud.
+func ResetMicroversionCache() {
+	microversionCache.Clear()
+}
+
+// cachedSupportedMicroversions is GetSupportedMicroversions, with the result
+// cached per endpoint. Failures are not cached.
+func cachedSupportedMicroversions(ctx context.Context, client *gophercloud.ServiceClient) (SupportedMicroversions, error) {
+	if v, ok := microversionCache.Load(client.Endpoint); ok {
+		return v.(SupportedMicroversions), nil
+	}
+
+	supported, err := GetSupportedMicroversions(ctx, client)
+	if err != nil {
+		return supported, err
+	}
+	microversionCache.Store(client.Endpoint, supported)
+	return supported, nil
+}
+
+// compareMicroversions returns -1, 0 or 1 depending on whether the first
+// microversion is lower than, equal to or higher than the second one.
+func compareMicroversions(major1, minor1, major2, minor2 int) int {
+	if c := cmp.Compare(major1, major2); c != 0 {
+		return c
+	}
+	return cmp.Compare(minor1, minor2)
+}
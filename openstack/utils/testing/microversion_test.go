@@ -0,0 +1,147 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/utils"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+func TestNegotiateMicroversion(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	setupMultiServiceVersionHandler(fakeServer)
+	defer utils.ResetMicroversionCache()
+
+	tests := []struct {
+		name         string
+		endpoint     string
+		minVersion   string
+		maxVersion   string
+		microversion string
+		expectedErr  string
+	}{
+		{
+			name:         "no bounds",
+			endpoint:     fakeServer.Endpoint() + "compute/v2.1/",
+			microversion: "2.90",
+		},
+		{
+			name:         "latest",
+			endpoint:     fakeServer.Endpoint() + "compute/v2.1/",
+			minVersion:   "2.60",
+			maxVersion:   "latest",
+			microversion: "2.90",
+		},
+		{
+			name:         "client maximum lower than server maximum",
+			endpoint:     fakeServer.Endpoint() + "compute/v2.1/",
+			minVersion:   "2.1",
+			maxVersion:   "2.79",
+			microversion: "2.79",
+		},
+		{
+			name:         "client maximum higher than server maximum",
+			endpoint:     fakeServer.Endpoint() + "compute/v2.1/",
+			minVersion:   "2.53",
+			maxVersion:   "2.100",
+			microversion: "2.90",
+		},
+		{
+			name:         "single version",
+			endpoint:     fakeServer.Endpoint() + "baremetal/",
+			minVersion:   "1.1",
+			maxVersion:   "1.1",
+			microversion: "1.1",
+		},
+		{
+			name:        "client minimum higher than server maximum",
+			endpoint:    fakeServer.Endpoint() + "compute/v2.1/",
+			minVersion:  "2.95",
+			maxVersion:  "2.100",
+			expectedErr: "no microversion between 2.95 and 2.100 is supported",
+		},
+		{
+			name:        "other major version",
+			endpoint:    fakeServer.Endpoint() + "baremetal/",
+			minVersion:  "2.0",
+			expectedErr: "no microversion",
+		},
+		{
+			name:        "invalid version",
+			endpoint:    fakeServer.Endpoint() + "compute/v2.1/",
+			maxVersion:  "2",
+			expectedErr: "invalid microversion format",
+		},
+		{
+			name:        "microversions not supported",
+			endpoint:    fakeServer.Endpoint() + "identity/v3/",
+			expectedErr: "unable to determine supported microversions",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := gophercloud.ServiceClient{
+				ProviderClient: &gophercloud.ProviderClient{},
+				Endpoint:       tt.endpoint,
+			}
+
+			client, err := utils.NegotiateMicroversion(context.TODO(), client, tt.minVersion, tt.maxVersion)
+			if tt.expectedErr != "" {
+				th.AssertErr(t, err)
+				if !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error to contain '%s', got '%s'", tt.expectedErr, err)
+				}
+				return
+			}
+			th.AssertNoErr(t, err)
+			th.AssertEquals(t, tt.microversion, client.Microversion)
+		})
+	}
+}
+
+func TestNegotiateMicroversionCache(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	defer utils.ResetMicroversionCache()
+
+	var discoveries int
+	fakeServer.Mux.HandleFunc("/volume/v3/", func(w http.ResponseWriter, r *http.Request) {
+		discoveries++
+		fmt.Fprint(w, `
+			{
+				"version": {
+					"id": "v3.0",
+					"status": "CURRENT",
+					"min_version": "3.0",
+					"version": "3.70"
+				}
+			}
+		`)
+	})
+
+	client := gophercloud.ServiceClient{
+		ProviderClient: &gophercloud.ProviderClient{},
+		Endpoint:       fakeServer.Endpoint() + "volume/v3/",
+	}
+
+	negotiated, err := utils.NegotiateMicroversion(context.TODO(), client, "3.0", "3.67")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "3.67", negotiated.Microversion)
+	th.AssertEquals(t, "", client.Microversion)
+
+	negotiated, err = utils.NegotiateMicroversion(context.TODO(), client, "3.0", "latest")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "3.70", negotiated.Microversion)
+	th.AssertEquals(t, 1, discoveries)
+
+	utils.ResetMicroversionCache()
+	_, err = utils.NegotiateMicroversion(context.TODO(), client, "3.0", "latest")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, discoveries)
+}
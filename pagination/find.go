@@ -0,0 +1,64 @@
+package pagination
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// Find returns the only item of a Pager that matches a function, using the
+// given function to extract the items from each page. All the pages are
+// fetched, so the Pager should filter the items on the server side when the
+// API allows it. name and resourceType describe the searched item in the
+// errors: gophercloud.ErrResourceNotFound when no item matches, and
+// gophercloud.ErrMultipleResourcesFound when several items match.
+//
+//	server, err := pagination.Find(ctx, servers.List(client, servers.ListOpts{Name: "^web$"}), servers.ExtractServers,
+//		"server", "web", func(s servers.Server) bool { return s.Name == "web" })
+func Find[T any](ctx context.Context, p Pager, extract func(Page) ([]T, error), resourceType, name string, match func(T) bool) (T, error) {
+	var found T
+	count := 0
+	for item, err := range Items(ctx, p, extract) {
+		if err != nil {
+			return found, err
+		}
+		if match(item) {
+			found = item
+			count++
+		}
+	}
+
+	switch count {
+	case 0:
+		return found, gophercloud.ErrResourceNotFound{Name: name, ResourceType: resourceType}
+	case 1:
+		return found, nil
+	default:
+		var zero T
+		return zero, gophercloud.ErrMultipleResourcesFound{Name: name, Count: count, ResourceType: resourceType}
+	}
+}
+
+// FindByIDOrName returns the item that get returns. When get fails with a 404
+// error, it returns the only item of a Pager that matches a function, as Find
+// does. It implements the Find functions of the resources, which look up an
+// ID first and a name then.
+//
+//	return pagination.FindByIDOrName(ctx, func(ctx context.Context) (*Network, error) {
+//		return Get(ctx, client, nameOrID).Extract()
+//	}, List(client, ListOpts{Name: nameOrID}), ExtractNetworks, "network", nameOrID, func(n Network) bool {
+//		return n.Name == nameOrID
+//	})
+func FindByIDOrName[T any](ctx context.Context, get func(context.Context) (*T, error), p Pager, extract func(Page) ([]T, error), resourceType, nameOrID string, match func(T) bool) (*T, error) {
+	item, err := get(ctx)
+	if err == nil || !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return item, err
+	}
+
+	found, err := Find(ctx, p, extract, resourceType, nameOrID, match)
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
@@ -0,0 +1,65 @@
+package pagination
+
+import (
+	"context"
+	"iter"
+)
+
+// Pages returns an iterator over the pages returned by a Pager. Pages are
+// fetched lazily: breaking out of the loop stops further requests. If an
+// error occurs, it is yielded with a nil Page and the iteration ends.
+//
+//	for page, err := range pager.Pages(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		allServers, err := servers.ExtractServers(page)
+//		// ...
+//	}
+func (p Pager) Pages(ctx context.Context) iter.Seq2[Page, error] {
+	return func(yield func(Page, error) bool) {
+		err := p.EachPage(ctx, func(_ context.Context, page Page) (bool, error) {
+			return yield(page, nil), nil
+		})
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// Items returns an iterator over the individual items returned by a Pager,
+// using the given function to extract the items from each page. Pages are
+// fetched lazily: breaking out of the loop stops further requests. If an
+// error occurs, it is yielded with the zero value of T and the iteration
+// ends.
+//
+//	for server, err := range pagination.Items(ctx, servers.List(client, nil), servers.ExtractServers) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(server.Name)
+//	}
+func Items[T any](ctx context.Context, p Pager, extract func(Page) ([]T, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page, err := range p.Pages(ctx) {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			items, err := extract(page)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package pagination
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"strings"
@@ -44,10 +46,19 @@ type Pager struct {
 
 	firstPage Page
 
+	// countBody, if set, accounts for the bytes of the page bodies as they are
+	// read, and aborts the read when it returns an error.
+	countBody func(n int) error
+
 	Err error
 
 	// Headers supplies additional HTTP headers to populate on each paged request.
 	Headers map[string]string
+
+	// Prefetch makes EachPage request the next page while the handler is
+	// still processing the current one. The ServiceClient must be safe for
+	// concurrent use (see gophercloud.ProviderClient.UseTokenLock).
+	Prefetch bool
 }
 
 // NewPager constructs a manually-configured pager.
@@ -67,6 +78,7 @@ func (p Pager) WithPageCreator(createPage func(r PageResult) Page) Pager {
 		client:     p.client,
 		initialURL: p.initialURL,
 		createPage: createPage,
+		Prefetch:   p.Prefetch,
 	}
 }
 
@@ -76,6 +88,10 @@ func (p Pager) fetchNextPage(ctx context.Context, url string) (Page, error) {
 		return nil, err
 	}
 
+	if p.countBody != nil {
+		resp.Body = &countingReader{ReadCloser: resp.Body, count: p.countBody}
+	}
+
 	remembered, err := PageResultFrom(resp)
 	if err != nil {
 		return nil, err
@@ -84,6 +100,21 @@ func (p Pager) fetchNextPage(ctx context.Context, url string) (Page, error) {
 	return p.createPage(remembered), nil
 }
 
+// countingReader reports the bytes read from a response body, and fails
+// when count returns an error.
+type countingReader struct {
+	io.ReadCloser
+	count func(n int) error
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	if countErr := r.count(n); countErr != nil {
+		return n, countErr
+	}
+	return n, err
+}
+
 // EachPage iterates over each page returned by a Pager, yielding one at a time
 // to a handler function. Return "false" from the handler to prematurely stop
 // iterating.
@@ -91,6 +122,9 @@ func (p Pager) EachPage(ctx context.Context, handler func(context.Context, Page)
 	if p.Err != nil {
 		return p.Err
 	}
+	if p.Prefetch {
+		return p.eachPagePrefetch(ctx, handler)
+	}
 	currentURL := p.initialURL
 	for {
 		var currentPage Page
@@ -133,12 +167,167 @@ func (p Pager) EachPage(ctx context.Context, handler func(context.Context, Page)
 	}
 }
 
+// fetchResult is the outcome of a page fetched in the background.
+type fetchResult struct {
+	page Page
+	err  error
+}
+
+// eachPagePrefetch implements EachPage when Prefetch is set. The next page is
+// requested in the background as soon as its URL is known, and the request is
+// canceled if the iteration stops early.
+func (p Pager) eachPagePrefetch(ctx context.Context, handler func(context.Context, Page) (bool, error)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var currentPage Page
+	if p.firstPage != nil {
+		currentPage = p.firstPage
+		p.firstPage = nil
+	} else {
+		var err error
+		currentPage, err = p.fetchNextPage(ctx, p.initialURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		empty, err := currentPage.IsEmpty()
+		if err != nil {
+			return err
+		}
+		if empty {
+			return nil
+		}
+
+		nextURL, err := currentPage.NextPageURL()
+		if err != nil {
+			return err
+		}
+
+		var next chan fetchResult
+		if nextURL != "" {
+			// buffered, so that the goroutine never blocks if the iteration stops early
+			next = make(chan fetchResult, 1)
+			go func() {
+				page, err := p.fetchNextPage(ctx, nextURL)
+				next <- fetchResult{page, err}
+			}()
+		}
+
+		ok, err := handler(ctx, currentPage)
+		if err != nil {
+			return err
+		}
+		if !ok || next == nil {
+			return nil
+		}
+
+		result := <-next
+		if result.err != nil {
+			return result.err
+		}
+		currentPage = result.page
+	}
+}
+
 // AllPages returns all the pages from a `List` operation in a single page,
 // allowing the user to retrieve all the pages at once.
 func (p Pager) AllPages(ctx context.Context) (Page, error) {
+	return p.AllPagesWithLimits(ctx, AllPagesLimits{})
+}
+
+// AllPagesLimits bounds the amount of data collected by AllPagesWithLimits.
+// A zero value means no limit.
+type AllPagesLimits struct {
+	// MaxItems is the maximum number of items collected across all pages.
+	// Each line of the pages that are not JSON, such as the text listings of
+	// the object storage, is an item.
+	MaxItems int
+
+	// MaxBytes is the maximum size of the bodies of all the pages, as received
+	// from the server. The read of a page stops as soon as the limit is
+	// exceeded.
+	MaxBytes int
+}
+
+// ErrLimitExceeded is returned by AllPagesWithLimits when the collection is
+// larger than the given AllPagesLimits.
+type ErrLimitExceeded struct {
+	gophercloud.BaseError
+	// Limit is the name of the limit that was exceeded, either "MaxItems" or "MaxBytes".
+	Limit string
+	// Max is the value of the limit that was exceeded.
+	Max int
+}
+
+func (e ErrLimitExceeded) Error() string {
+	return fmt.Sprintf("the collection exceeds the %s limit of %d", e.Limit, e.Max)
+}
+
+// limitCounter keeps track of the data collected by AllPagesWithLimits.
+type limitCounter struct {
+	limits AllPagesLimits
+	items  int
+	bytes  int
+}
+
+func (c *limitCounter) addItems(n int) error {
+	c.items += n
+	if c.limits.MaxItems > 0 && c.items > c.limits.MaxItems {
+		return ErrLimitExceeded{Limit: "MaxItems", Max: c.limits.MaxItems}
+	}
+	return nil
+}
+
+func (c *limitCounter) addBytes(n int) error {
+	c.bytes += n
+	if c.limits.MaxBytes > 0 && c.bytes > c.limits.MaxBytes {
+		return ErrLimitExceeded{Limit: "MaxBytes", Max: c.limits.MaxBytes}
+	}
+	return nil
+}
+
+// addBody accounts for every item of a page body.
+func (c *limitCounter) addBody(body any) error {
+	switch b := body.(type) {
+	case map[string]any:
+		for k, v := range b {
+			if vt, ok := v.([]any); ok && !strings.HasSuffix(k, "links") {
+				if err := c.addItems(len(vt)); err != nil {
+					return err
+				}
+			}
+		}
+	case []byte:
+		return c.addItems(countLines(b))
+	case []any:
+		return c.addItems(len(b))
+	}
+	return nil
+}
+
+// countLines returns the number of lines of a text body.
+func countLines(b []byte) int {
+	n := bytes.Count(b, []byte{'\n'})
+	if len(b) > 0 && b[len(b)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+// AllPagesWithLimits works like AllPages, but stops fetching pages and returns
+// an ErrLimitExceeded as soon as the collected items exceed the given limits.
+// This protects callers from exhausting memory on very large collections.
+func (p Pager) AllPagesWithLimits(ctx context.Context, limits AllPagesLimits) (Page, error) {
 	if p.Err != nil {
 		return nil, p.Err
 	}
+	counter := &limitCounter{limits: limits}
+	if limits.MaxBytes > 0 {
+		p.countBody = counter.addBytes
+	}
 	// pagesSlice holds all the pages until they get converted into as Page Body.
 	var pagesSlice []any
 	// body will contain the final concatenated Page body.
@@ -155,6 +344,9 @@ func (p Pager) AllPages(ctx context.Context) (Page, error) {
 
 	// if it's a single page, just return the firstPage (first page)
 	if _, found := pageType.FieldByName("SinglePageBase"); found {
+		if err := counter.addBody(firstPage.GetBody()); err != nil {
+			return nil, err
+		}
 		return firstPage, nil
 	}
 
@@ -178,6 +370,9 @@ func (p Pager) AllPages(ctx context.Context) (Page, error) {
 					case []any:
 						key = k
 						pagesSlice = append(pagesSlice, vt...)
+						if err := counter.addItems(len(vt)); err != nil {
+							return false, err
+						}
 					}
 				}
 			}
@@ -193,6 +388,9 @@ func (p Pager) AllPages(ctx context.Context) (Page, error) {
 		// Iterate over the pages to concatenate the bodies.
 		err = p.EachPage(ctx, func(_ context.Context, page Page) (bool, error) {
 			b := page.GetBody().([]byte)
+			if err := counter.addItems(countLines(b)); err != nil {
+				return false, err
+			}
 			pagesSlice = append(pagesSlice, b)
 			// seperate pages with a comma
 			pagesSlice = append(pagesSlice, []byte{10})
@@ -217,6 +415,9 @@ func (p Pager) AllPages(ctx context.Context) (Page, error) {
 		// Iterate over the pages to concatenate the bodies.
 		err = p.EachPage(ctx, func(_ context.Context, page Page) (bool, error) {
 			b := page.GetBody().([]any)
+			if err := counter.addItems(len(b)); err != nil {
+				return false, err
+			}
 			pagesSlice = append(pagesSlice, b...)
 			return true, nil
 		})
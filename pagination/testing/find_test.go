@@ -0,0 +1,121 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+func TestFind(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createLinked(fakeServer)
+
+	// the matching item is on the last page
+	actual, err := pagination.Find(context.TODO(), pager, ExtractLinkedInts, "int", "eight", func(i int) bool {
+		return i == 8
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 8, actual)
+}
+
+func TestFindNotFound(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createLinked(fakeServer)
+
+	_, err := pagination.Find(context.TODO(), pager, ExtractLinkedInts, "int", "ten", func(i int) bool {
+		return i == 10
+	})
+	var notFound gophercloud.ErrResourceNotFound
+	th.AssertEquals(t, true, errors.As(err, &notFound))
+	th.AssertEquals(t, "ten", notFound.Name)
+	th.AssertEquals(t, "int", notFound.ResourceType)
+}
+
+func TestFindMultipleResources(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createLinked(fakeServer)
+
+	actual, err := pagination.Find(context.TODO(), pager, ExtractLinkedInts, "int", "even", func(i int) bool {
+		return i%2 == 0
+	})
+	th.AssertEquals(t, 0, actual)
+	var multiple gophercloud.ErrMultipleResourcesFound
+	th.AssertEquals(t, true, errors.As(err, &multiple))
+	th.AssertEquals(t, 4, multiple.Count)
+	th.AssertEquals(t, "Found 4 ints matching even", err.Error())
+}
+
+func TestFindExtractError(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createLinked(fakeServer)
+
+	extractErr := errors.New("cannot extract")
+	_, err := pagination.Find(context.TODO(), pager, func(pagination.Page) ([]int, error) {
+		return nil, extractErr
+	}, "int", "one", func(i int) bool {
+		return i == 1
+	})
+	th.AssertErrIs(t, err, extractErr)
+}
+
+func TestFindByIDOrName(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createLinked(fakeServer)
+	match := func(i int) bool { return i == 8 }
+
+	// the item found by ID is returned without listing the items
+	eight := 8
+	actual, err := pagination.FindByIDOrName(context.TODO(), func(context.Context) (*int, error) {
+		return &eight, nil
+	}, pagination.Pager{}, ExtractLinkedInts, "int", "8", match)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, &eight, actual)
+
+	// a 404 error falls back to the name
+	actual, err = pagination.FindByIDOrName(context.TODO(), func(context.Context) (*int, error) {
+		return nil, gophercloud.ErrUnexpectedResponseCode{Actual: 404}
+	}, pager, ExtractLinkedInts, "int", "eight", match)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 8, *actual)
+
+	// so the name must match a single item
+	_, err = pagination.FindByIDOrName(context.TODO(), func(context.Context) (*int, error) {
+		return nil, gophercloud.ErrUnexpectedResponseCode{Actual: 404}
+	}, pager, ExtractLinkedInts, "int", "ten", func(i int) bool {
+		return i == 10
+	})
+	var notFound gophercloud.ErrResourceNotFound
+	th.AssertEquals(t, true, errors.As(err, &notFound))
+
+	_, err = pagination.FindByIDOrName(context.TODO(), func(context.Context) (*int, error) {
+		return nil, gophercloud.ErrUnexpectedResponseCode{Actual: 404}
+	}, pager, ExtractLinkedInts, "int", "even", func(i int) bool {
+		return i%2 == 0
+	})
+	var multiple gophercloud.ErrMultipleResourcesFound
+	th.AssertEquals(t, true, errors.As(err, &multiple))
+}
+
+func TestFindByIDOrNameGetError(t *testing.T) {
+	// the other errors of get are returned without listing the items
+	_, err := pagination.FindByIDOrName(context.TODO(), func(context.Context) (*int, error) {
+		return nil, gophercloud.ErrUnexpectedResponseCode{Actual: 500}
+	}, pagination.Pager{}, ExtractLinkedInts, "int", "eight", func(i int) bool {
+		return i == 8
+	})
+	th.AssertEquals(t, true, gophercloud.ResponseCodeIs(err, 500))
+}
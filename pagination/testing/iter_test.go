@@ -0,0 +1,94 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/client"
+)
+
+func TestPagesLinked(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createLinked(fakeServer)
+
+	var actual [][]int
+	for page, err := range pager.Pages(context.TODO()) {
+		th.AssertNoErr(t, err)
+		ints, err := ExtractLinkedInts(page)
+		th.AssertNoErr(t, err)
+		actual = append(actual, ints)
+	}
+
+	th.CheckDeepEquals(t, [][]int{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}, actual)
+}
+
+func TestItemsLinked(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createLinked(fakeServer)
+
+	var actual []int
+	for i, err := range pagination.Items(context.TODO(), pager, ExtractLinkedInts) {
+		th.AssertNoErr(t, err)
+		actual = append(actual, i)
+	}
+
+	th.CheckDeepEquals(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, actual)
+}
+
+func TestItemsBreakStopsFetching(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	requested := 0
+	fakeServer.Mux.HandleFunc("/page1", func(w http.ResponseWriter, r *http.Request) {
+		requested++
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintf(w, `{ "ints": [1, 2, 3], "links": { "next": "%s/page2" } }`, fakeServer.Server.URL)
+	})
+	fakeServer.Mux.HandleFunc("/page2", func(w http.ResponseWriter, r *http.Request) {
+		requested++
+		t.Errorf("page 2 must not be requested")
+	})
+
+	pager := pagination.NewPager(client.ServiceClient(fakeServer), fakeServer.Server.URL+"/page1", func(r pagination.PageResult) pagination.Page {
+		return LinkedPageResult{pagination.LinkedPageBase{PageResult: r}}
+	})
+
+	var actual []int
+	for i, err := range pagination.Items(context.TODO(), pager, ExtractLinkedInts) {
+		th.AssertNoErr(t, err)
+		actual = append(actual, i)
+		if i == 2 {
+			break
+		}
+	}
+
+	th.CheckDeepEquals(t, []int{1, 2}, actual)
+	th.AssertEquals(t, 1, requested)
+}
+
+func TestItemsExtractError(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createLinked(fakeServer)
+
+	extractErr := errors.New("cannot extract")
+	count := 0
+	for _, err := range pagination.Items(context.TODO(), pager, func(pagination.Page) ([]int, error) {
+		return nil, extractErr
+	}) {
+		count++
+		th.AssertErrIs(t, err, extractErr)
+	}
+	th.AssertEquals(t, 1, count)
+}
@@ -0,0 +1,179 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/client"
+)
+
+func TestEachPagePrefetch(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	page2Requested := make(chan struct{})
+	fakeServer.Mux.HandleFunc("/page1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintf(w, `{ "ints": [1, 2, 3], "links": { "next": "%s/page2" } }`, fakeServer.Server.URL)
+	})
+	fakeServer.Mux.HandleFunc("/page2", func(w http.ResponseWriter, r *http.Request) {
+		close(page2Requested)
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprint(w, `{ "ints": [4, 5, 6], "links": { "next": null } }`)
+	})
+
+	pager := pagination.NewPager(client.ServiceClient(fakeServer), fakeServer.Server.URL+"/page1", func(r pagination.PageResult) pagination.Page {
+		return LinkedPageResult{pagination.LinkedPageBase{PageResult: r}}
+	})
+	pager.Prefetch = true
+
+	var actual []int
+	err := pager.EachPage(context.TODO(), func(_ context.Context, page pagination.Page) (bool, error) {
+		ints, err := ExtractLinkedInts(page)
+		if err != nil {
+			return false, err
+		}
+		if len(actual) == 0 {
+			// the second page is requested while the first one is being handled
+			select {
+			case <-page2Requested:
+			case <-time.After(5 * time.Second):
+				return false, errors.New("second page was not prefetched")
+			}
+		}
+		actual = append(actual, ints...)
+		return true, nil
+	})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []int{1, 2, 3, 4, 5, 6}, actual)
+}
+
+func TestEachPagePrefetchStop(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createLinked(fakeServer)
+	pager.Prefetch = true
+
+	callCount := 0
+	err := pager.EachPage(context.TODO(), func(_ context.Context, page pagination.Page) (bool, error) {
+		callCount++
+		return false, nil
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, callCount)
+}
+
+func TestAllPagesPrefetch(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createLinked(fakeServer)
+	pager.Prefetch = true
+
+	page, err := pager.AllPages(context.TODO())
+	th.AssertNoErr(t, err)
+
+	actual, err := ExtractLinkedInts(page)
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, actual)
+}
+
+func TestAllPagesWithLimits(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createLinked(fakeServer)
+
+	page, err := pager.AllPagesWithLimits(context.TODO(), pagination.AllPagesLimits{MaxItems: 9})
+	th.AssertNoErr(t, err)
+	actual, err := ExtractLinkedInts(page)
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, actual)
+
+	_, err = pager.AllPagesWithLimits(context.TODO(), pagination.AllPagesLimits{MaxItems: 5})
+	var limitErr pagination.ErrLimitExceeded
+	th.AssertEquals(t, true, errors.As(err, &limitErr))
+	th.AssertEquals(t, "MaxItems", limitErr.Limit)
+	th.AssertEquals(t, 5, limitErr.Max)
+
+	_, err = pager.AllPagesWithLimits(context.TODO(), pagination.AllPagesLimits{MaxBytes: 4})
+	th.AssertEquals(t, true, errors.As(err, &limitErr))
+	th.AssertEquals(t, "MaxBytes", limitErr.Limit)
+}
+
+func TestAllPagesWithLimitsBodySize(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createLinked(fakeServer)
+
+	// the bodies of the pages of createLinked
+	size := len(fmt.Sprintf(`{ "ints": [1, 2, 3], "links": { "next": "%s/page2" } }`, fakeServer.Server.URL)) +
+		len(fmt.Sprintf(`{ "ints": [4, 5, 6], "links": { "next": "%s/page3" } }`, fakeServer.Server.URL)) +
+		len(`{ "ints": [7, 8, 9], "links": { "next": null } }`)
+
+	_, err := pager.AllPagesWithLimits(context.TODO(), pagination.AllPagesLimits{MaxBytes: size})
+	th.AssertNoErr(t, err)
+
+	_, err = pager.AllPagesWithLimits(context.TODO(), pagination.AllPagesLimits{MaxBytes: size - 1})
+	var limitErr pagination.ErrLimitExceeded
+	th.AssertEquals(t, true, errors.As(err, &limitErr))
+	th.AssertEquals(t, "MaxBytes", limitErr.Limit)
+}
+
+func TestAllPagesWithLimitsMarker(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	pager := createMarkerPaged(t, fakeServer)
+
+	_, err := pager.AllPagesWithLimits(context.TODO(), pagination.AllPagesLimits{MaxBytes: 20})
+	var limitErr pagination.ErrLimitExceeded
+	th.AssertEquals(t, true, errors.As(err, &limitErr))
+	th.AssertEquals(t, "MaxBytes", limitErr.Limit)
+	th.AssertEquals(t, 20, limitErr.Max)
+
+	// each line of the text pages is an item
+	_, err = pager.AllPagesWithLimits(context.TODO(), pagination.AllPagesLimits{MaxItems: 9})
+	th.AssertNoErr(t, err)
+
+	_, err = pager.AllPagesWithLimits(context.TODO(), pagination.AllPagesLimits{MaxItems: 5})
+	th.AssertEquals(t, true, errors.As(err, &limitErr))
+	th.AssertEquals(t, "MaxItems", limitErr.Limit)
+}
+
+func TestAllPagesWithLimitsAbortsRead(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	fakeServer.Mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Repeat("item\n", 100))
+		w.(http.Flusher).Flush()
+
+		// the rest of the page is never read
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+			t.Errorf("the page was not abandoned")
+		}
+	})
+
+	pager := pagination.NewPager(client.ServiceClient(fakeServer), fakeServer.Server.URL+"/page", func(r pagination.PageResult) pagination.Page {
+		p := MarkerPageResult{pagination.MarkerPageBase{PageResult: r}}
+		p.Owner = p
+		return p
+	})
+
+	_, err := pager.AllPagesWithLimits(context.TODO(), pagination.AllPagesLimits{MaxBytes: 100})
+	var limitErr pagination.ErrLimitExceeded
+	th.AssertEquals(t, true, errors.As(err, &limitErr))
+	th.AssertEquals(t, "MaxBytes", limitErr.Limit)
+}
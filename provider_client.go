@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 )
 
 // DefaultUserAgent is the default User-Agent string set in the request header.
@@ -78,23 +80,53 @@ type ProviderClient struct {
 	// UserAgent represents the User-Agent header in the HTTP request.
 	UserAgent UserAgent
 
+	// Logger, if set, receives a record for every HTTP request issued by this client, including
+	// retries. Records are emitted at info level and contain the method, URL, status, duration,
+	// microversion and request ID. When the logger is enabled at debug level, the records also
+	// contain the request and response headers and bodies. Tokens, passwords, application
+	// credential secrets, Barbican secret payloads and TempURL signatures are redacted.
+	Logger *slog.Logger
+
+	// Middlewares wrap every HTTP request attempt issued by this client, including retries and
+	// reauthentication requests. The first Middleware is the outermost one.
+	Middlewares []Middleware
+
+	// RateLimiter, if set, limits the rate of all the HTTP request attempts issued by this client.
+	RateLimiter *RateLimiter
+
+	// ServiceRateLimiters limits the rate of the HTTP request attempts issued by the ServiceClients
+	// of a given type (e.g. "compute", "network"), in addition to RateLimiter. A key may also be
+	// an alias of the type, as listed in ServiceTypeAliases (e.g. "volumev3" for "block-storage").
+	// It must not be modified while requests are in progress.
+	ServiceRateLimiters map[string]*RateLimiter
+
 	// ReauthFunc is the function used to re-authenticate the user if the request
 	// fails with a 401 HTTP response code. This a needed because there may be multiple
 	// authentication functions for different Identity service versions.
 	ReauthFunc func(context.Context) error
 
+	// TokenRefreshWindow enables proactive reauthentication. When the current token expires within
+	// this window, requests call ReauthFunc before being sent, instead of waiting for a 401
+	// response. This requires an AuthResult that reports when the token expires, such as the ones
+	// recorded by openstack.Authenticate. A token that is already within the window when it is
+	// obtained is not refreshed again, and a failed refresh is not retried for 30 seconds. See also
+	// KeepTokenFresh.
+	TokenRefreshWindow time.Duration
+
 	// Throwaway determines whether if this client is a throw-away client. It's a copy of user's provider client
 	// with the token and reauth func zeroed. Such client can be used to perform reauthorization.
 	Throwaway bool
 
-	// Retry backoff func is called when rate limited.
+	// Retry backoff func is called when rate limited. When nil, rate-limited requests are not retried.
+	// Set it to the RetryBackoff method of a Backoff to use the built-in exponential backoff policy.
 	RetryBackoffFunc RetryBackoffFunc
 
 	// MaxBackoffRetries set the maximum number of backoffs. When not set, defaults to DefaultMaxBackoffRetries
 	MaxBackoffRetries uint
 
 	// A general failed request handler method - this is always called in the end if a request failed. Leave as nil
-	// to abort when an error is encountered.
+	// to abort when an error is encountered. Set it to the RetryIdempotent method of a Backoff to retry idempotent
+	// requests on transient server and connection errors.
 	RetryFunc RetryFunc
 
 	// mut is a mutex for the client. It protects read and write access to client attributes such as getting
@@ -106,6 +138,18 @@ type ProviderClient struct {
 	reauthmut *reauthlock
 
 	authResult AuthResult
+
+	// tokenExpiresAt is the expiry of the current token, as reported by authResult. It is zero if
+	// unknown.
+	tokenExpiresAt time.Time
+
+	// tokenRefreshSkip is the expiry of a token obtained by a proactive refresh while already
+	// within TokenRefreshWindow. Such a token is not refreshed proactively again.
+	tokenRefreshSkip time.Time
+
+	// tokenRefreshRetryAt is the time before which no proactive refresh is attempted after a
+	// failed one.
+	tokenRefreshRetryAt time.Time
 }
 
 // reauthlock represents a set of attributes used to help in the reauthentication process.
@@ -203,6 +247,7 @@ func (client *ProviderClient) SetToken(t string) {
 	}
 	client.TokenID = t
 	client.authResult = nil
+	client.tokenExpiresAt = time.Time{}
 }
 
 // SetTokenAndAuthResult safely sets the value of the auth token in the
@@ -210,12 +255,19 @@ func (client *ProviderClient) SetToken(t string) {
 // token creation request. Applications may call this in a custom ReauthFunc.
 func (client *ProviderClient) SetTokenAndAuthResult(r AuthResult) error {
 	tokenID := ""
+	var expiresAt time.Time
 	var err error
 	if r != nil {
 		tokenID, err = r.ExtractTokenID()
 		if err != nil {
 			return err
 		}
+		if e, ok := r.(expiringAuthResult); ok {
+			expiresAt, err = e.ExtractExpiresAt()
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	if client.mut != nil {
@@ -224,6 +276,7 @@ func (client *ProviderClient) SetTokenAndAuthResult(r AuthResult) error {
 	}
 	client.TokenID = tokenID
 	client.authResult = r
+	client.tokenExpiresAt = expiresAt
 	return nil
 }
 
@@ -240,6 +293,7 @@ func (client *ProviderClient) CopyTokenFrom(other *ProviderClient) {
 	}
 	client.TokenID = other.TokenID
 	client.authResult = other.authResult
+	client.tokenExpiresAt = other.tokenExpiresAt
 }
 
 // IsThrowaway safely reads the value of the client Throwaway field.
@@ -260,18 +314,38 @@ func (client *ProviderClient) SetThrowaway(v bool) {
 	client.Throwaway = v
 }
 
+// reauthFunc safely reads the value of the client ReauthFunc field.
+func (client *ProviderClient) reauthFunc() func(context.Context) error {
+	if client.reauthmut != nil {
+		client.reauthmut.RLock()
+		defer client.reauthmut.RUnlock()
+	}
+	return client.ReauthFunc
+}
+
+// SetReauthFunc safely replaces the ReauthFunc of a client that may be in
+// use, e.g. to reauthenticate with new credentials.
+func (client *ProviderClient) SetReauthFunc(f func(context.Context) error) {
+	if client.reauthmut != nil {
+		client.reauthmut.Lock()
+		defer client.reauthmut.Unlock()
+	}
+	client.ReauthFunc = f
+}
+
 // Reauthenticate calls client.ReauthFunc in a thread-safe way. If this is
 // called because of a 401 response, the caller may pass the previous token. In
 // this case, the reauthentication can be skipped if another thread has already
 // reauthenticated in the meantime. If no previous token is known, an empty
 // string should be passed instead to force unconditional reauthentication.
 func (client *ProviderClient) Reauthenticate(ctx context.Context, previousToken string) error {
-	if client.ReauthFunc == nil {
+	reauthFunc := client.reauthFunc()
+	if reauthFunc == nil {
 		return nil
 	}
 
 	if client.reauthmut == nil {
-		return client.ReauthFunc(ctx)
+		return reauthFunc(ctx)
 	}
 
 	future := newReauthFuture()
@@ -292,7 +366,7 @@ func (client *ProviderClient) Reauthenticate(ctx context.Context, previousToken
 	// Perform the actual reauthentication.
 	var err error
 	if previousToken == "" || client.TokenID == previousToken {
-		err = client.ReauthFunc(ctx)
+		err = reauthFunc(ctx)
 	} else {
 		err = nil
 	}
@@ -330,6 +404,14 @@ type RequestOpts struct {
 	// KeepResponseBody specifies whether to keep the HTTP response body. Usually used, when the HTTP
 	// response body is considered for further use. Valid when JSONResponse is nil.
 	KeepResponseBody bool
+	// Microversion, if set, overrides the microversion of the ServiceClient for this request only.
+	// It allows single calls to use features of a newer microversion without copying the client.
+	// It is ignored by ProviderClient.Request.
+	Microversion string
+
+	// serviceType and microversion are set by ServiceClient.Request, and reported to middlewares.
+	serviceType  string
+	microversion string
 }
 
 // requestState contains temporary state for a single ProviderClient.Request() call.
@@ -341,6 +423,9 @@ type requestState struct {
 	hasReauthenticated bool
 	// Retry-After backoff counter, increments during each backoff call
 	retries uint
+	// Offset of a seekable RawBody when the request was started. The body is rewound to this
+	// offset before the request is sent again.
+	bodyOffset int64
 }
 
 var applicationJSON = "application/json"
@@ -348,14 +433,34 @@ var applicationJSON = "application/json"
 // Request performs an HTTP request using the ProviderClient's
 // current HTTPClient. An authentication header will automatically be provided.
 func (client *ProviderClient) Request(ctx context.Context, method, url string, options *RequestOpts) (*http.Response, error) {
-	return client.doRequest(ctx, method, url, options, &requestState{
+	state := &requestState{
 		hasReauthenticated: false,
-	})
+	}
+	if seeker, ok := options.RawBody.(io.Seeker); ok {
+		// Some seekers (e.g. pipes) cannot report their offset. They will fail
+		// to rewind as well, which is reported if a retry is attempted.
+		if offset, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			state.bodyOffset = offset
+		}
+	}
+	return client.doRequest(ctx, method, url, options, state)
+}
+
+// retryRequest rewinds a seekable RawBody to where it was when the request
+// was started, and then sends the request again.
+func (client *ProviderClient) retryRequest(ctx context.Context, method, url string, options *RequestOpts, state *requestState) (*http.Response, error) {
+	if seeker, ok := options.RawBody.(io.Seeker); ok {
+		if _, err := seeker.Seek(state.bodyOffset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	return client.doRequest(ctx, method, url, options, state)
 }
 
 func (client *ProviderClient) doRequest(ctx context.Context, method, url string, options *RequestOpts, state *requestState) (*http.Response, error) {
 	var body io.Reader
 	var contentType *string
+	var rendered []byte
 
 	// Derive the content body by either encoding an arbitrary object as JSON, or by taking a provided
 	// io.ReadSeeker as-is. Default the content-type to application/json.
@@ -364,7 +469,8 @@ func (client *ProviderClient) doRequest(ctx context.Context, method, url string,
 			return nil, errors.New("please provide only one of JSONBody or RawBody to gophercloud.Request()")
 		}
 
-		rendered, err := json.Marshal(options.JSONBody)
+		var err error
+		rendered, err = json.Marshal(options.JSONBody)
 		if err != nil {
 			return nil, err
 		}
@@ -398,6 +504,10 @@ func (client *ProviderClient) doRequest(ctx context.Context, method, url string,
 	// Set the User-Agent header
 	req.Header.Set("User-Agent", client.UserAgent.Join())
 
+	if id, ok := GlobalRequestIDFromContext(ctx); ok {
+		req.Header.Set("X-OpenStack-Request-ID", id)
+	}
+
 	if options.MoreHeaders != nil {
 		for k, v := range options.MoreHeaders {
 			req.Header.Set(k, v)
@@ -408,7 +518,14 @@ func (client *ProviderClient) doRequest(ctx context.Context, method, url string,
 		req.Header.Del(v)
 	}
 
-	// get latest token from client
+	// wait for the rate limiters before picking the token, which may change meanwhile
+	rateLimitWait, err := client.waitRateLimiters(ctx, options.serviceType)
+	if err != nil {
+		return nil, err
+	}
+
+	// refresh the token if it is about to expire, then get latest token from client
+	client.refreshExpiringToken(ctx)
 	for k, v := range client.AuthenticatedHeaders() {
 		req.Header.Set(k, v)
 	}
@@ -416,8 +533,17 @@ func (client *ProviderClient) doRequest(ctx context.Context, method, url string,
 	prereqtok := req.Header.Get("X-Auth-Token")
 
 	// Issue the request.
-	resp, err := client.HTTPClient.Do(req)
+	start := time.Now()
+	resp, err := client.roundTrip(req, RequestInfo{
+		ServiceType:      options.serviceType,
+		Microversion:     options.microversion,
+		Retries:          state.retries,
+		Reauthenticated:  state.hasReauthenticated,
+		Reauthentication: client.IsThrowaway(),
+		RateLimitWait:    rateLimitWait,
+	})
 	if err != nil {
+		_ = client.logRequest(ctx, req, rendered, nil, err, time.Since(start), state.retries, false)
 		if client.RetryFunc != nil {
 			var e error
 			state.retries = state.retries + 1
@@ -426,7 +552,7 @@ func (client *ProviderClient) doRequest(ctx context.Context, method, url string,
 				return nil, e
 			}
 
-			return client.doRequest(ctx, method, url, options, state)
+			return client.retryRequest(ctx, method, url, options, state)
 		}
 		return nil, err
 	}
@@ -446,6 +572,10 @@ func (client *ProviderClient) doRequest(ctx context.Context, method, url string,
 		}
 	}
 
+	if err := client.logRequest(ctx, req, rendered, resp, nil, time.Since(start), state.retries, !ok || !options.KeepResponseBody); err != nil {
+		return nil, err
+	}
+
 	if !ok {
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
@@ -460,7 +590,7 @@ func (client *ProviderClient) doRequest(ctx context.Context, method, url string,
 
 		switch resp.StatusCode {
 		case http.StatusUnauthorized:
-			if client.ReauthFunc != nil && !state.hasReauthenticated {
+			if client.reauthFunc() != nil && !state.hasReauthenticated {
 				err = client.Reauthenticate(ctx, prereqtok)
 				if err != nil {
 					e := &ErrUnableToReauthenticate{}
@@ -468,15 +598,8 @@ func (client *ProviderClient) doRequest(ctx context.Context, method, url string,
 					e.ErrReauth = err
 					return nil, e
 				}
-				if options.RawBody != nil {
-					if seeker, ok := options.RawBody.(io.Seeker); ok {
-						if _, err := seeker.Seek(0, 0); err != nil {
-							return nil, err
-						}
-					}
-				}
 				state.hasReauthenticated = true
-				resp, err = client.doRequest(ctx, method, url, options, state)
+				resp, err = client.retryRequest(ctx, method, url, options, state)
 				if err != nil {
 					switch e := err.(type) {
 					case *ErrUnexpectedResponseCode:
@@ -507,7 +630,7 @@ func (client *ProviderClient) doRequest(ctx context.Context, method, url string,
 					return resp, e
 				}
 
-				return client.doRequest(ctx, method, url, options, state)
+				return client.retryRequest(ctx, method, url, options, state)
 			}
 		}
 
@@ -523,7 +646,7 @@ func (client *ProviderClient) doRequest(ctx context.Context, method, url string,
 				return resp, e
 			}
 
-			return client.doRequest(ctx, method, url, options, state)
+			return client.retryRequest(ctx, method, url, options, state)
 		}
 
 		return resp, err
@@ -547,7 +670,7 @@ func (client *ProviderClient) doRequest(ctx context.Context, method, url string,
 					return resp, e
 				}
 
-				return client.doRequest(ctx, method, url, options, state)
+				return client.retryRequest(ctx, method, url, options, state)
 			}
 			return nil, err
 		}
@@ -0,0 +1,184 @@
+package gophercloud
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+/*
+RateLimiter is a token bucket that limits the rate at which a ProviderClient
+sends requests. The bucket holds up to burst tokens and is refilled at a
+constant rate. Each HTTP request attempt (including retries) takes a token, and
+waits for one to become available if the bucket is empty.
+
+Rate limiters are configured on the ProviderClient, either for all its
+requests, or for the requests of the ServiceClients of a given type:
+
+	provider.RateLimiter = gophercloud.NewRateLimiter(50, 100)
+	provider.ServiceRateLimiters = map[string]*gophercloud.RateLimiter{
+		"network":  gophercloud.NewRateLimiter(5, 10),
+		"volumev3": gophercloud.NewRateLimiter(2, 5),
+	}
+
+The keys of ServiceRateLimiters are service types or their aliases, as listed
+in ServiceTypeAliases: the "volumev3" limiter above applies to the
+"block-storage" ServiceClients. A request that matches both limiters waits for
+both. A RateLimiter is safe for
+concurrent use, and can be shared by several ProviderClients.
+*/
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	stats  RateLimiterStats
+}
+
+// RateLimiterStats reports the activity of a RateLimiter.
+type RateLimiterStats struct {
+	// Requests is the number of requests that went through the limiter.
+	Requests uint64
+
+	// Delayed is the number of requests that had to wait for a token.
+	Delayed uint64
+
+	// WaitTime is the total time spent by requests waiting for a token.
+	WaitTime time.Duration
+
+	// MaxWaitTime is the longest time a single request waited for a token.
+	MaxWaitTime time.Duration
+}
+
+// NewRateLimiter returns a RateLimiter that allows rate requests per second
+// on average, and bursts of up to burst requests. A burst lower than 1 is
+// treated as 1.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	burst = max(burst, 1)
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// Wait takes a token from the bucket, waiting until one is available. It
+// returns the time spent waiting. If the context is done before a token is
+// available, or if its deadline would pass before, Wait returns immediately
+// with an error wrapping the context error, and no token is taken.
+func (l *RateLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	if l.rate <= 0 {
+		return 0, nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+
+	// reserve a token; a negative balance is the queue of waiting requests
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && delay > 0 && time.Until(deadline) < delay {
+		l.tokens++
+		l.mu.Unlock()
+		return 0, fmt.Errorf("rate limit wait of %s would exceed the context deadline: %w", delay, context.DeadlineExceeded)
+	}
+	l.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			l.mu.Lock()
+			l.tokens++
+			l.mu.Unlock()
+			return 0, fmt.Errorf("waiting for the rate limiter: %w", ctx.Err())
+		}
+	}
+
+	l.mu.Lock()
+	l.stats.Requests++
+	if delay > 0 {
+		l.stats.Delayed++
+		l.stats.WaitTime += delay
+		l.stats.MaxWaitTime = max(l.stats.MaxWaitTime, delay)
+	}
+	l.mu.Unlock()
+
+	return delay, nil
+}
+
+// Stats returns the activity of the RateLimiter since its creation.
+func (l *RateLimiter) Stats() RateLimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// waitRateLimiters waits for the rate limiters that apply to a request of
+// the given service type, and returns the total time spent waiting.
+func (client *ProviderClient) waitRateLimiters(ctx context.Context, serviceType string) (time.Duration, error) {
+	var waited time.Duration
+	for _, l := range []*RateLimiter{client.RateLimiter, client.serviceRateLimiter(serviceType)} {
+		if l == nil {
+			continue
+		}
+		d, err := l.Wait(ctx)
+		if err != nil {
+			return waited, err
+		}
+		waited += d
+	}
+	return waited, nil
+}
+
+// serviceRateLimiter returns the rate limiter of the given service type. It
+// is looked up by the service type itself, then by its canonical type, then
+// by the aliases of the canonical type, in the order of ServiceTypeAliases.
+func (client *ProviderClient) serviceRateLimiter(serviceType string) *RateLimiter {
+	if len(client.ServiceRateLimiters) == 0 {
+		return nil
+	}
+	if l, ok := client.ServiceRateLimiters[serviceType]; ok {
+		return l
+	}
+
+	canonical := canonicalServiceType(serviceType)
+	if l, ok := client.ServiceRateLimiters[canonical]; ok {
+		return l
+	}
+	for _, alias := range ServiceTypeAliases[canonical] {
+		if l, ok := client.ServiceRateLimiters[alias]; ok {
+			return l
+		}
+	}
+	return nil
+}
+
+// canonicalServiceType returns the service type of which serviceType is an
+// alias, or serviceType itself.
+func canonicalServiceType(serviceType string) string {
+	if _, ok := ServiceTypeAliases[serviceType]; ok {
+		return serviceType
+	}
+	for canonical, aliases := range ServiceTypeAliases {
+		if slices.Contains(aliases, serviceType) {
+			return canonical
+		}
+	}
+	return serviceType
+}
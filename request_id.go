@@ -0,0 +1,74 @@
+package gophercloud
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// requestIDHeaders lists the response headers in which OpenStack services
+// report the ID of a request, in order of preference.
+var requestIDHeaders = []string{
+	"X-Openstack-Request-Id",
+	"X-Compute-Request-Id",
+	"X-Trans-Id",
+}
+
+// requestIDFromHeader returns the request ID that an OpenStack service
+// reported in its response headers, if any.
+func requestIDFromHeader(h http.Header) string {
+	for _, k := range requestIDHeaders {
+		if v := h.Get(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// requestIDsFromHeader returns all the distinct request IDs that an OpenStack
+// service reported in its response headers.
+func requestIDsFromHeader(h http.Header) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, k := range requestIDHeaders {
+		for _, v := range h.Values(k) {
+			if v != "" && !seen[v] {
+				seen[v] = true
+				ids = append(ids, v)
+			}
+		}
+	}
+	return ids
+}
+
+type globalRequestIDKey struct{}
+
+// WithGlobalRequestID returns a copy of ctx that carries a global request ID.
+// Every request issued with this context sends it in the
+// X-OpenStack-Request-ID header, so that the services involved in one logical
+// operation log it next to their own request IDs.
+//
+// OpenStack services only accept IDs in the "req-<UUID>" format, and silently
+// ignore other values. Use NewGlobalRequestID to generate a valid one.
+func WithGlobalRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, globalRequestIDKey{}, id)
+}
+
+// GlobalRequestIDFromContext returns the global request ID carried by ctx, if
+// any.
+func GlobalRequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(globalRequestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// NewGlobalRequestID generates a random global request ID in the "req-<UUID>"
+// format expected by OpenStack services.
+func NewGlobalRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	// version 4, variant RFC 4122
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("req-%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -43,6 +43,19 @@ type Result struct {
 	Err error
 }
 
+// RequestID returns the ID that the service assigned to the request, as reported in the
+// X-OpenStack-Request-ID (or an equivalent) response header. It is empty if the service did not
+// report one, or if no response was received.
+func (r Result) RequestID() string {
+	return requestIDFromHeader(r.Header)
+}
+
+// RequestIDs returns all the distinct request IDs reported in the response headers. Some services
+// report more than one, e.g. Nova sends both X-OpenStack-Request-ID and X-Compute-Request-ID.
+func (r Result) RequestIDs() []string {
+	return requestIDsFromHeader(r.Header)
+}
+
 // ExtractInto allows users to provide an object into which `Extract` will extract
 // the `Result.Body`. This would be useful for OpenStack providers that have
 // different fields in the response object than OpenStack proper.
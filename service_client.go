@@ -114,29 +114,29 @@ func (client *ServiceClient) Head(ctx context.Context, url string, opts *Request
 	return client.Request(ctx, "HEAD", url, opts)
 }
 
-func (client *ServiceClient) setMicroversionHeader(opts *RequestOpts) {
+func (client *ServiceClient) setMicroversionHeader(opts *RequestOpts, microversion string) {
 	serviceType := client.Type
 
 	switch client.Type {
 	case "compute":
-		opts.MoreHeaders["X-OpenStack-Nova-API-Version"] = client.Microversion
+		opts.MoreHeaders["X-OpenStack-Nova-API-Version"] = microversion
 	case "shared-file-system", "sharev2", "share":
-		opts.MoreHeaders["X-OpenStack-Manila-API-Version"] = client.Microversion
+		opts.MoreHeaders["X-OpenStack-Manila-API-Version"] = microversion
 	case "block-storage", "block-store", "volume", "volumev3":
-		opts.MoreHeaders["X-OpenStack-Volume-API-Version"] = client.Microversion
+		opts.MoreHeaders["X-OpenStack-Volume-API-Version"] = microversion
 		// cinder should accept block-storage but (as of Dalmatian) does not
 		serviceType = "volume"
 	case "baremetal":
-		opts.MoreHeaders["X-OpenStack-Ironic-API-Version"] = client.Microversion
+		opts.MoreHeaders["X-OpenStack-Ironic-API-Version"] = microversion
 	case "baremetal-introspection":
-		opts.MoreHeaders["X-OpenStack-Ironic-Inspector-API-Version"] = client.Microversion
+		opts.MoreHeaders["X-OpenStack-Ironic-Inspector-API-Version"] = microversion
 	case "container-infrastructure-management", "container-infrastructure", "container-infra":
 		// magnum should accept container-infrastructure-management but (as of Epoxy) does not
 		serviceType = "container-infra"
 	}
 
 	if client.Type != "" {
-		opts.MoreHeaders["OpenStack-API-Version"] = serviceType + " " + client.Microversion
+		opts.MoreHeaders["OpenStack-API-Version"] = serviceType + " " + microversion
 	}
 }
 
@@ -146,9 +146,15 @@ func (client *ServiceClient) Request(ctx context.Context, method, url string, op
 		options.MoreHeaders = make(map[string]string)
 	}
 
-	if client.Microversion != "" {
-		client.setMicroversionHeader(options)
+	microversion := client.Microversion
+	if options.Microversion != "" {
+		microversion = options.Microversion
 	}
+	if microversion != "" {
+		client.setMicroversionHeader(options, microversion)
+	}
+	options.serviceType = client.Type
+	options.microversion = microversion
 
 	if len(client.MoreHeaders) > 0 {
 		if options == nil {
@@ -0,0 +1,101 @@
+/*
+Package cassette records the HTTP interactions of a Gophercloud client with a
+real cloud, and replays them from a testhelper.FakeServer. It allows writing
+deterministic unit tests from a single acceptance run, without hand-writing
+every JSON fixture.
+
+Tokens, passwords and other secrets are scrubbed from the recorded
+interactions before they are saved.
+
+Example to record a cassette during an acceptance run
+
+	recorder := cassette.NewRecorder(nil)
+	provider.HTTPClient.Transport = recorder
+
+	// ... exercise the cloud ...
+
+	err := recorder.Save("testdata/servers.json")
+
+Example to replay a cassette in a unit test
+
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	c, err := cassette.Load("testdata/servers.json")
+	th.AssertNoErr(t, err)
+	cassette.Replay(t, fakeServer, c)
+
+	client := &gophercloud.ServiceClient{
+		ProviderClient: &gophercloud.ProviderClient{TokenID: "token"},
+		Endpoint:       fakeServer.Endpoint() + "compute/v2.1/",
+	}
+
+During replay, every URL of the recorded servers that appears in a response
+(e.g. in a service catalog or in pagination links) is rewritten to point to the
+FakeServer, so that all services are served from it.
+*/
+package cassette
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Cassette is a sequence of recorded HTTP interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded HTTP request.
+type Request struct {
+	Method string `json:"method"`
+
+	// URL is the full URL of the request. Its scheme and host identify the
+	// recorded server.
+	URL string `json:"url"`
+
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Response is a recorded HTTP response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Load reads a cassette from a file written by Recorder.Save.
+func Load(path string) (*Cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to a file, creating its directory if needed.
+func (c *Cassette) Save(path string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
@@ -0,0 +1,169 @@
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+// Replay serves the interactions of the cassette from the FakeServer. A
+// request is answered with the response of the first recorded interaction
+// that has the same method, path, query parameters and body, and that was
+// not replayed yet. JSON bodies are compared after normalization, and secrets
+// are ignored since they were scrubbed from the cassette. When every
+// matching interaction was already replayed, the last one is replayed again,
+// so that a client polling a resource eventually sees its final state.
+//
+// A request that does not match any interaction fails the test.
+func Replay(t *testing.T, fakeServer th.FakeServer, c *Cassette) {
+	p := &player{
+		t:         t,
+		cassette:  c,
+		replayed:  make([]bool, len(c.Interactions)),
+		serverURL: fakeServer.Server.URL,
+		origins:   recordedOrigins(c),
+	}
+	fakeServer.Mux.Handle("/", p)
+}
+
+// ReplayFile loads a cassette and replays it from the FakeServer.
+func ReplayFile(t *testing.T, fakeServer th.FakeServer, path string) {
+	c, err := Load(path)
+	th.AssertNoErr(t, err)
+	Replay(t, fakeServer, c)
+}
+
+type player struct {
+	t        *testing.T
+	cassette *Cassette
+
+	mu       sync.Mutex
+	replayed []bool
+
+	serverURL string
+	// origins are the origins (scheme and host) of the recorded servers,
+	// longest first.
+	origins []string
+}
+
+func (p *player) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	interaction, ok := p.match(r, body)
+	if !ok {
+		p.t.Errorf("cassette: no recorded interaction matches %s %s", r.Method, r.URL)
+		http.Error(w, "no recorded interaction matches the request", http.StatusNotImplemented)
+		return
+	}
+
+	for k, values := range interaction.Response.Headers {
+		for _, v := range values {
+			w.Header().Add(k, p.rewrite(v))
+		}
+	}
+	// the length of the body changes when URLs are rewritten
+	w.Header().Del("Content-Length")
+	w.WriteHeader(interaction.Response.StatusCode)
+	fmt.Fprint(w, p.rewrite(interaction.Response.Body))
+}
+
+func (p *player) match(r *http.Request, body []byte) (Interaction, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	last := -1
+	for i, interaction := range p.cassette.Interactions {
+		if !matches(interaction.Request, r, body) {
+			continue
+		}
+		if !p.replayed[i] {
+			p.replayed[i] = true
+			return interaction, true
+		}
+		last = i
+	}
+
+	if last < 0 {
+		return Interaction{}, false
+	}
+	return p.cassette.Interactions[last], true
+}
+
+// rewrite replaces the URLs of the recorded servers with the URL of the
+// FakeServer.
+func (p *player) rewrite(s string) string {
+	for _, origin := range p.origins {
+		s = strings.ReplaceAll(s, origin, p.serverURL)
+	}
+	return s
+}
+
+func matches(recorded Request, r *http.Request, body []byte) bool {
+	if recorded.Method != r.Method {
+		return false
+	}
+
+	u, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	if strings.TrimSuffix(u.Path, "/") != strings.TrimSuffix(r.URL.Path, "/") {
+		return false
+	}
+
+	actualQuery, err := url.Parse(scrubURL(r.URL))
+	if err != nil || !reflect.DeepEqual(u.Query(), actualQuery.Query()) {
+		return false
+	}
+
+	return equalBodies(recorded.Body, scrubBody(r.URL, body))
+}
+
+// equalBodies compares two bodies, ignoring the formatting and key order of
+// JSON bodies.
+func equalBodies(recorded, actual string) bool {
+	if recorded == actual {
+		return true
+	}
+
+	var recordedJSON, actualJSON any
+	if json.Unmarshal([]byte(recorded), &recordedJSON) != nil || json.Unmarshal([]byte(actual), &actualJSON) != nil {
+		return false
+	}
+	return reflect.DeepEqual(recordedJSON, actualJSON)
+}
+
+func recordedOrigins(c *Cassette) []string {
+	seen := make(map[string]bool)
+	var origins []string
+	for _, interaction := range c.Interactions {
+		u, err := url.Parse(interaction.Request.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		origin := u.Scheme + "://" + u.Host
+		if !seen[origin] {
+			seen[origin] = true
+			origins = append(origins, origin)
+		}
+	}
+
+	// replace "http://host:5000" before "http://host:50"
+	sort.Slice(origins, func(i, j int) bool {
+		return len(origins[i]) > len(origins[j])
+	})
+	return origins
+}
@@ -0,0 +1,31 @@
+package cassette
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gophercloud/gophercloud/v2/internal/redact"
+)
+
+// Scrubbed replaces the secrets in recorded interactions.
+const Scrubbed = "SCRUBBED"
+
+func scrubHeaders(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	return redact.Header(h, Scrubbed)
+}
+
+func scrubURL(u *url.URL) string {
+	return redact.URL(u, Scrubbed).String()
+}
+
+// scrubBody scrubs the secrets of a JSON body. Barbican secret payloads are
+// scrubbed entirely. Other bodies are returned unchanged.
+func scrubBody(u *url.URL, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	return string(redact.Body(u, body, Scrubbed))
+}
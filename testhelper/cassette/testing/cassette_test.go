@@ -0,0 +1,95 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/cassette"
+)
+
+const serverID = "9e5476bd-a4ec-4653-93d6-72c93aa682ba"
+
+// setupCloud simulates the cloud the cassette is recorded from.
+func setupCloud(t *testing.T, fakeServer th.FakeServer) {
+	fakeServer.Mux.HandleFunc("/servers", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "POST")
+		w.Header().Set("Location", fakeServer.Endpoint()+"servers/"+serverID)
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, `{"server": {"id": "%s", "adminPass": "s3cr3t", "links": [{"rel": "self", "href": "%sservers/%s"}]}}`,
+			serverID, fakeServer.Endpoint(), serverID)
+	})
+
+	status := []string{"BUILD", "ACTIVE"}
+	fakeServer.Mux.HandleFunc("/servers/"+serverID, func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		fmt.Fprintf(w, `{"server": {"id": "%s", "status": "%s"}}`, serverID, status[0])
+		if len(status) > 1 {
+			status = status[1:]
+		}
+	})
+}
+
+func exercise(t *testing.T, client *gophercloud.ServiceClient, password string) *servers.Server {
+	server, err := servers.Create(context.TODO(), client, servers.CreateOpts{
+		Name:      "test",
+		FlavorRef: "1",
+		ImageRef:  "2",
+		AdminPass: password,
+	}, nil).Extract()
+	th.AssertNoErr(t, err)
+
+	for _, status := range []string{"BUILD", "ACTIVE", "ACTIVE"} {
+		s, err := servers.Get(context.TODO(), client, server.ID).Extract()
+		th.AssertNoErr(t, err)
+		th.AssertEquals(t, status, s.Status)
+	}
+
+	return server
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	cloud := th.SetupHTTP()
+	defer cloud.Teardown()
+	setupCloud(t, cloud)
+
+	recorder := cassette.NewRecorder(nil)
+	provider := &gophercloud.ProviderClient{
+		TokenID:    "recorded-token",
+		HTTPClient: http.Client{Transport: recorder},
+	}
+	client := &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: cloud.Endpoint()}
+	server := exercise(t, client, "s3cr3t")
+	th.AssertEquals(t, "s3cr3t", server.AdminPass)
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	th.AssertNoErr(t, recorder.Save(path))
+
+	c, err := cassette.Load(path)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 4, len(c.Interactions))
+
+	create := c.Interactions[0]
+	th.AssertEquals(t, cassette.Scrubbed, create.Request.Headers.Get("X-Auth-Token"))
+	th.AssertEquals(t, false, strings.Contains(create.Request.Body, "s3cr3t"))
+	th.AssertEquals(t, false, strings.Contains(create.Response.Body, "s3cr3t"))
+	th.AssertEquals(t, http.StatusAccepted, create.Response.StatusCode)
+
+	// replay the cassette from another server, with other secrets
+	cloud.Teardown()
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	cassette.Replay(t, fakeServer, c)
+
+	provider = &gophercloud.ProviderClient{TokenID: "another-token"}
+	client = &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: fakeServer.Endpoint()}
+	server = exercise(t, client, "another-password")
+	th.AssertEquals(t, serverID, server.ID)
+	th.AssertEquals(t, fakeServer.Endpoint()+"servers/"+serverID, server.Links[0].(map[string]any)["href"])
+}
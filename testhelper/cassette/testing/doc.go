@@ -0,0 +1,2 @@
+// cassette
+package testing
@@ -0,0 +1,208 @@
+package keystone
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// appCredTimeFormat is the format of the expiration time of the application
+// credentials, which Keystone renders without time zone.
+const appCredTimeFormat = "2006-01-02T15:04:05.000000"
+
+// appCredTimeFormats are the formats accepted for the expiration time of the
+// application credentials.
+var appCredTimeFormats = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999"}
+
+// appCredOwner returns the token of a request that manages the application
+// credentials of the user of its path. It writes the error response and
+// returns false otherwise.
+func (s *Server) appCredOwner(w http.ResponseWriter, r *http.Request) (*token, bool) {
+	t, ok := s.validToken(r.Header.Get("X-Auth-Token"))
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+		return nil, false
+	}
+	if t.userID != r.PathValue("user") {
+		writeError(w, http.StatusForbidden, "You are not authorized to perform the requested action.")
+		return nil, false
+	}
+	if ac, ok := s.appCreds[t.appCredID]; ok && !ac.Unrestricted {
+		writeError(w, http.StatusForbidden, "Using method 'application_credential' is not allowed for managing additional application credentials.")
+		return nil, false
+	}
+	return t, true
+}
+
+func (s *Server) handleCreateApplicationCredential(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ApplicationCredential struct {
+			Name         string `json:"name"`
+			Description  string `json:"description"`
+			Secret       string `json:"secret"`
+			Unrestricted bool   `json:"unrestricted"`
+			Roles        []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"roles"`
+			AccessRules []AccessRule `json:"access_rules"`
+			ExpiresAt   string       `json:"expires_at"`
+		} `json:"application_credential"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Malformed request body.")
+		return
+	}
+	opts := req.ApplicationCredential
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.appCredOwner(w, r)
+	if !ok {
+		return
+	}
+	if t.projectID == "" {
+		writeError(w, http.StatusBadRequest, "Application credentials require a project-scoped token.")
+		return
+	}
+	if opts.Name == "" {
+		writeError(w, http.StatusBadRequest, "Invalid input for field 'name'.")
+		return
+	}
+	for _, ac := range s.appCreds {
+		if ac.UserID == t.userID && ac.Name == opts.Name {
+			writeError(w, http.StatusConflict, "Conflict occurred attempting to store application_credential - Duplicate entry.")
+			return
+		}
+	}
+
+	ac := &ApplicationCredential{
+		ID:           newID(),
+		Name:         opts.Name,
+		Description:  opts.Description,
+		Secret:       opts.Secret,
+		UserID:       t.userID,
+		ProjectID:    t.projectID,
+		Unrestricted: opts.Unrestricted,
+	}
+	if ac.Secret == "" {
+		ac.Secret = newID()
+	}
+
+	// only the roles of the token can be delegated
+	roles := s.rolesOf(t)
+	for _, role := range opts.Roles {
+		name := role.Name
+		for n, id := range s.roles {
+			if role.ID != "" && id == role.ID {
+				name = n
+			}
+		}
+		if !slices.ContainsFunc(roles, func(r string) bool { return strings.EqualFold(r, name) }) {
+			writeError(w, http.StatusForbidden, "You may not delegate a role you do not have.")
+			return
+		}
+		ac.Roles = append(ac.Roles, name)
+	}
+	if len(ac.Roles) == 0 {
+		ac.Roles = roles
+	}
+
+	for _, rule := range opts.AccessRules {
+		if rule.ID == "" {
+			rule.ID = newID()
+		}
+		ac.AccessRules = append(ac.AccessRules, rule)
+	}
+
+	if opts.ExpiresAt != "" {
+		var err error
+		for _, format := range appCredTimeFormats {
+			if ac.ExpiresAt, err = time.Parse(format, opts.ExpiresAt); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid input for field 'expires_at'.")
+			return
+		}
+	}
+
+	s.appCreds[ac.ID] = ac
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"application_credential": s.renderApplicationCredential(ac, true),
+	})
+}
+
+func (s *Server) handleGetApplicationCredential(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.appCredOwner(w, r); !ok {
+		return
+	}
+	ac, ok := s.appCreds[r.PathValue("id")]
+	if !ok || ac.UserID != r.PathValue("user") {
+		writeError(w, http.StatusNotFound, "Could not find Application Credential.")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"application_credential": s.renderApplicationCredential(ac, false),
+	})
+}
+
+func (s *Server) handleDeleteApplicationCredential(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.appCredOwner(w, r); !ok {
+		return
+	}
+	ac, ok := s.appCreds[r.PathValue("id")]
+	if !ok || ac.UserID != r.PathValue("user") {
+		writeError(w, http.StatusNotFound, "Could not find Application Credential.")
+		return
+	}
+
+	s.deleteApplicationCredential(ac.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// renderApplicationCredential renders an application credential. Its secret
+// is only rendered when it is created.
+func (s *Server) renderApplicationCredential(ac *ApplicationCredential, withSecret bool) map[string]any {
+	roles := []any{}
+	for _, name := range ac.Roles {
+		roles = append(roles, map[string]any{"id": s.roles[name], "name": name, "domain_id": nil})
+	}
+	accessRules := []any{}
+	for _, rule := range ac.AccessRules {
+		accessRules = append(accessRules, rule)
+	}
+
+	body := map[string]any{
+		"id":           ac.ID,
+		"name":         ac.Name,
+		"description":  ac.Description,
+		"user_id":      ac.UserID,
+		"project_id":   ac.ProjectID,
+		"unrestricted": ac.Unrestricted,
+		"roles":        roles,
+		"access_rules": accessRules,
+		"expires_at":   nil,
+		"links": map[string]any{
+			"self": s.AuthURL() + "users/" + ac.UserID + "/application_credentials/" + ac.ID,
+		},
+	}
+	if !ac.ExpiresAt.IsZero() {
+		body["expires_at"] = ac.ExpiresAt.UTC().Format(appCredTimeFormat)
+	}
+	if withSecret {
+		body["secret"] = ac.Secret
+	}
+	return body
+}
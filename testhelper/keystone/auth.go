@@ -0,0 +1,570 @@
+package keystone
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// timeFormat is the format of the timestamps in Keystone responses.
+const timeFormat = "2006-01-02T15:04:05.000000Z"
+
+type ref struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Domain *ref   `json:"domain"`
+}
+
+type userRef struct {
+	ref
+	Password string `json:"password"`
+	Passcode string `json:"passcode"`
+}
+
+type authRequest struct {
+	Auth struct {
+		Identity struct {
+			Methods  []string `json:"methods"`
+			Password *struct {
+				User userRef `json:"user"`
+			} `json:"password"`
+			Token *struct {
+				ID string `json:"id"`
+			} `json:"token"`
+			ApplicationCredential *struct {
+				ID     string   `json:"id"`
+				Name   string   `json:"name"`
+				Secret string   `json:"secret"`
+				User   *userRef `json:"user"`
+			} `json:"application_credential"`
+			TOTP *struct {
+				User userRef `json:"user"`
+			} `json:"totp"`
+		} `json:"identity"`
+		Scope json.RawMessage `json:"scope"`
+	} `json:"auth"`
+}
+
+type scopeRequest struct {
+	Project *ref `json:"project"`
+	Domain  *ref `json:"domain"`
+	System  *struct {
+		All bool `json:"all"`
+	} `json:"system"`
+}
+
+// receiptTTL is the lifetime of the auth receipts, as configured by default
+// in Keystone.
+const receiptTTL = 5 * time.Minute
+
+// authError is an authentication failure, reported with its HTTP status. A
+// failure with a receipt is reported as an auth receipt.
+type authError struct {
+	status  int
+	message string
+	receipt *receipt
+}
+
+func unauthorized(format string, args ...any) *authError {
+	return &authError{status: http.StatusUnauthorized, message: fmt.Sprintf(format, args...)}
+}
+
+func badRequest(format string, args ...any) *authError {
+	return &authError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) handleCreateToken(w http.ResponseWriter, r *http.Request) {
+	var req authRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Malformed request body: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	t, aerr := s.authenticate(&req, r.Header.Get("Openstack-Auth-Receipt"))
+	var body map[string]any
+	switch {
+	case aerr == nil:
+		body = s.renderToken(t, r.URL.Query().Has("nocatalog"))
+	case aerr.receipt != nil:
+		body = s.renderReceipt(aerr.receipt)
+	}
+	s.mu.Unlock()
+
+	if aerr != nil && aerr.receipt != nil {
+		w.Header().Set("Openstack-Auth-Receipt", aerr.receipt.id)
+		writeJSON(w, http.StatusUnauthorized, body)
+		return
+	}
+	if aerr != nil {
+		writeError(w, aerr.status, aerr.message)
+		return
+	}
+
+	w.Header().Set("X-Subject-Token", t.id)
+	writeJSON(w, http.StatusCreated, body)
+}
+
+// authenticate checks the identity of an authentication request and issues
+// a token. It must be called with the lock held.
+func (s *Server) authenticate(req *authRequest, receiptID string) (*token, *authError) {
+	identity := req.Auth.Identity
+	if len(identity.Methods) == 0 {
+		return nil, badRequest("Expecting to find methods in identity.")
+	}
+
+	now := s.now()
+	t := &token{
+		id:        newID(),
+		methods:   identity.Methods,
+		issuedAt:  now,
+		expiresAt: now.Add(s.tokenTTL()),
+		auditIDs:  []string{newID()[:22]},
+	}
+
+	var source *token
+	var appCred *ApplicationCredential
+	for _, method := range identity.Methods {
+		var userID string
+		switch method {
+		case "password":
+			if identity.Password == nil {
+				return nil, badRequest("Expecting to find password in identity.")
+			}
+			u, ok := s.findUserRef(identity.Password.User)
+			if !ok || u.Password == "" || u.Password != identity.Password.User.Password {
+				return nil, unauthorized("The password is incorrect.")
+			}
+			userID = u.ID
+		case "token":
+			if identity.Token == nil {
+				return nil, badRequest("Expecting to find token in identity.")
+			}
+			var ok bool
+			source, ok = s.validToken(identity.Token.ID)
+			if !ok {
+				return nil, unauthorized("The token is invalid, expired or revoked.")
+			}
+			userID = source.userID
+		case "application_credential":
+			ac := identity.ApplicationCredential
+			if ac == nil {
+				return nil, badRequest("Expecting to find application_credential in identity.")
+			}
+			var ok bool
+			appCred, ok = s.findApplicationCredential(ac.ID, ac.Name, ac.User)
+			if !ok || appCred.Secret != ac.Secret {
+				return nil, unauthorized("Invalid application credential.")
+			}
+			if !appCred.ExpiresAt.IsZero() && !now.Before(appCred.ExpiresAt) {
+				return nil, unauthorized("The application credential has expired.")
+			}
+			userID = appCred.UserID
+		case "totp":
+			if identity.TOTP == nil {
+				return nil, badRequest("Expecting to find totp in identity.")
+			}
+			u, ok := s.findUserRef(identity.TOTP.User)
+			if !ok || !validTOTP(u.TOTPSecret, identity.TOTP.User.Passcode, now) {
+				return nil, unauthorized("The TOTP passcode is invalid.")
+			}
+			userID = u.ID
+		default:
+			return nil, unauthorized("Attempted to authenticate with an unsupported method.")
+		}
+
+		if t.userID != "" && t.userID != userID {
+			return nil, unauthorized("The authentication methods identify different users.")
+		}
+		t.userID = userID
+	}
+
+	if receiptID != "" || source == nil && appCred == nil {
+		if err := s.checkMFARules(t, receiptID); err != nil {
+			return nil, err
+		}
+	}
+
+	if source != nil {
+		// a token obtained from another one does not outlive it
+		t.expiresAt = source.expiresAt
+		t.auditIDs = append(t.auditIDs, source.auditIDs[0])
+		if source.appCredID != "" {
+			appCred = s.appCreds[source.appCredID]
+			if appCred == nil {
+				return nil, unauthorized("The application credential was deleted.")
+			}
+		}
+	}
+	if appCred != nil && !appCred.ExpiresAt.IsZero() && appCred.ExpiresAt.Before(t.expiresAt) {
+		t.expiresAt = appCred.ExpiresAt
+	}
+
+	if err := s.scope(t, req.Auth.Scope, appCred); err != nil {
+		return nil, err
+	}
+
+	s.tokens[t.id] = t
+	s.issued++
+	return t, nil
+}
+
+// checkMFARules adds the methods of the auth receipt, if any, to the methods
+// of the token, and issues a new receipt if they do not satisfy the
+// multi-factor authentication rules of the user.
+func (s *Server) checkMFARules(t *token, receiptID string) *authError {
+	now := s.now()
+	if receiptID != "" {
+		r, ok := s.receipts[receiptID]
+		if !ok || !now.Before(r.expiresAt) {
+			return unauthorized("The auth receipt is invalid or expired.")
+		}
+		if r.userID != t.userID {
+			return unauthorized("The auth receipt was issued to another user.")
+		}
+		for _, method := range r.methods {
+			if !slices.Contains(t.methods, method) {
+				t.methods = append(t.methods, method)
+			}
+		}
+	}
+
+	rules := s.users[t.userID].MFARules
+	if len(rules) == 0 {
+		return nil
+	}
+	for _, rule := range rules {
+		if !slices.ContainsFunc(rule, func(method string) bool { return !slices.Contains(t.methods, method) }) {
+			return nil
+		}
+	}
+
+	r := &receipt{
+		id:        newID(),
+		userID:    t.userID,
+		methods:   t.methods,
+		issuedAt:  now,
+		expiresAt: now.Add(receiptTTL),
+	}
+	s.receipts[r.id] = r
+	return &authError{status: http.StatusUnauthorized, receipt: r}
+}
+
+// scope applies the requested scope to a token.
+func (s *Server) scope(t *token, raw json.RawMessage, appCred *ApplicationCredential) *authError {
+	var req scopeRequest
+	if len(raw) > 0 && string(raw) != "null" && string(raw) != `"unscoped"` {
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return badRequest("Malformed scope: %s", err)
+		}
+	}
+
+	if appCred != nil {
+		// application credentials are bound to their project
+		if req.Domain != nil || req.System != nil {
+			return unauthorized("Application credentials cannot request a scope.")
+		}
+		if req.Project != nil {
+			p, ok := s.findProject(req.Project.ID, req.Project.Name, domainID(req.Project.Domain), domainName(req.Project.Domain))
+			if !ok || p.ID != appCred.ProjectID {
+				return unauthorized("Application credentials cannot request a scope.")
+			}
+		}
+		t.projectID = appCred.ProjectID
+		t.appCredID = appCred.ID
+	} else {
+		switch {
+		case req.Project != nil:
+			p, ok := s.findProject(req.Project.ID, req.Project.Name, domainID(req.Project.Domain), domainName(req.Project.Domain))
+			if !ok {
+				return unauthorized("Could not find project.")
+			}
+			t.projectID = p.ID
+		case req.Domain != nil:
+			d, ok := s.findDomain(req.Domain.ID, req.Domain.Name)
+			if !ok {
+				return unauthorized("Could not find domain.")
+			}
+			t.domainID = d.ID
+		case req.System != nil && req.System.All:
+			t.system = true
+		}
+	}
+
+	scoped := t.projectID != "" || t.domainID != "" || t.system
+	if scoped && len(s.rolesOf(t)) == 0 {
+		return unauthorized("The user has no role on the requested scope.")
+	}
+	return nil
+}
+
+func (s *Server) tokenTTL() time.Duration {
+	if s.TokenTTL > 0 {
+		return s.TokenTTL
+	}
+	return DefaultTokenTTL
+}
+
+func (s *Server) findUserRef(u userRef) (*User, bool) {
+	return s.findUser(u.ID, u.Name, domainID(u.Domain), domainName(u.Domain))
+}
+
+func (s *Server) findApplicationCredential(id, name string, user *userRef) (*ApplicationCredential, bool) {
+	if id != "" {
+		ac, ok := s.appCreds[id]
+		return ac, ok
+	}
+	if user == nil {
+		return nil, false
+	}
+	u, ok := s.findUserRef(*user)
+	if !ok {
+		return nil, false
+	}
+	for _, ac := range s.appCreds {
+		if ac.Name == name && ac.UserID == u.ID {
+			return ac, true
+		}
+	}
+	return nil, false
+}
+
+func domainID(r *ref) string {
+	if r == nil {
+		return ""
+	}
+	return r.ID
+}
+
+func domainName(r *ref) string {
+	if r == nil {
+		return ""
+	}
+	return r.Name
+}
+
+// subjectToken returns the token designated by the X-Subject-Token header of
+// a request authenticated with a valid X-Auth-Token. It writes the error
+// response and returns false otherwise.
+func (s *Server) subjectToken(w http.ResponseWriter, r *http.Request) (*token, bool) {
+	if _, ok := s.validToken(r.Header.Get("X-Auth-Token")); !ok {
+		writeError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+		return nil, false
+	}
+	t, ok := s.validToken(r.Header.Get("X-Subject-Token"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Could not find token.")
+		return nil, false
+	}
+	return t, true
+}
+
+func (s *Server) handleValidateToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	t, ok := s.subjectToken(w, r)
+	var body map[string]any
+	if ok {
+		body = s.renderToken(t, r.URL.Query().Has("nocatalog"))
+	}
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	w.Header().Set("X-Subject-Token", t.id)
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	t, ok := s.subjectToken(w, r)
+	if ok {
+		t.revoked = true
+	}
+	s.mu.Unlock()
+
+	if ok {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleCatalog(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	t, ok := s.validToken(r.Header.Get("X-Auth-Token"))
+	var catalog []any
+	if ok {
+		catalog = s.renderCatalog(t)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"catalog": catalog})
+}
+
+func (s *Server) handleVersions(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusMultipleChoices, map[string]any{
+		"versions": map[string]any{
+			"values": []any{s.version()},
+		},
+	})
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"version": s.version()})
+}
+
+func (s *Server) version() map[string]any {
+	return map[string]any{
+		"id":      "v3.14",
+		"status":  "stable",
+		"updated": "2020-04-07T00:00:00Z",
+		"links": []any{
+			map[string]any{"rel": "self", "href": s.AuthURL()},
+		},
+	}
+}
+
+// renderToken renders the body of a token response. It must be called with
+// the lock held.
+func (s *Server) renderToken(t *token, noCatalog bool) map[string]any {
+	user := s.users[t.userID]
+	body := map[string]any{
+		"methods":    t.methods,
+		"audit_ids":  t.auditIDs,
+		"issued_at":  t.issuedAt.Format(timeFormat),
+		"expires_at": t.expiresAt.Format(timeFormat),
+		"user": map[string]any{
+			"id":                  user.ID,
+			"name":                user.Name,
+			"domain":              s.renderDomain(user.DomainID),
+			"password_expires_at": nil,
+		},
+	}
+
+	switch {
+	case t.projectID != "":
+		p := s.projects[t.projectID]
+		body["project"] = map[string]any{
+			"id":     p.ID,
+			"name":   p.Name,
+			"domain": s.renderDomain(p.DomainID),
+		}
+		body["is_domain"] = false
+	case t.domainID != "":
+		body["domain"] = s.renderDomain(t.domainID)
+	case t.system:
+		body["system"] = map[string]any{"all": true}
+	}
+
+	if t.projectID != "" || t.domainID != "" || t.system {
+		var roles []any
+		for _, name := range s.rolesOf(t) {
+			roles = append(roles, map[string]any{"id": s.roles[name], "name": name})
+		}
+		body["roles"] = roles
+
+		if !noCatalog {
+			body["catalog"] = s.renderCatalog(t)
+		}
+	}
+
+	if ac, ok := s.appCreds[t.appCredID]; ok {
+		body["application_credential"] = map[string]any{
+			"id":         ac.ID,
+			"name":       ac.Name,
+			"restricted": !ac.Unrestricted,
+		}
+	}
+
+	return map[string]any{"token": body}
+}
+
+func (s *Server) renderReceipt(r *receipt) map[string]any {
+	user := s.users[r.userID]
+	return map[string]any{
+		"receipt": map[string]any{
+			"methods":    r.methods,
+			"issued_at":  r.issuedAt.Format(timeFormat),
+			"expires_at": r.expiresAt.Format(timeFormat),
+			"user": map[string]any{
+				"id":     user.ID,
+				"name":   user.Name,
+				"domain": s.renderDomain(user.DomainID),
+			},
+		},
+		"required_auth_methods": user.MFARules,
+	}
+}
+
+func (s *Server) renderDomain(id string) map[string]any {
+	d, ok := s.domains[id]
+	if !ok {
+		return map[string]any{"id": id, "name": id}
+	}
+	return map[string]any{"id": d.ID, "name": d.Name}
+}
+
+// renderCatalog renders the service catalog of a scoped token. It always
+// contains the identity service itself.
+func (s *Server) renderCatalog(t *token) []any {
+	if t.projectID == "" && t.domainID == "" && !t.system {
+		return []any{}
+	}
+
+	identity := &Service{ID: "identity", Type: "identity", Name: "keystone"}
+	for _, iface := range []string{"public", "internal", "admin"} {
+		identity.Endpoints = append(identity.Endpoints, Endpoint{
+			ID:        "identity-" + iface,
+			Interface: iface,
+			Region:    DefaultRegion,
+			URL:       s.AuthURL(),
+		})
+	}
+
+	catalog := []any{}
+	for _, svc := range append([]*Service{identity}, s.services...) {
+		var endpoints []any
+		for _, e := range svc.Endpoints {
+			endpoints = append(endpoints, map[string]any{
+				"id":        e.ID,
+				"interface": e.Interface,
+				"region":    e.Region,
+				"region_id": e.Region,
+				"url":       e.URL,
+			})
+		}
+		catalog = append(catalog, map[string]any{
+			"id":        svc.ID,
+			"type":      svc.Type,
+			"name":      svc.Name,
+			"endpoints": endpoints,
+		})
+	}
+	return catalog
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError writes an error in the format of Keystone.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{
+			"code":    status,
+			"title":   http.StatusText(status),
+			"message": message,
+		},
+	})
+}
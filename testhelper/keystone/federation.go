@@ -0,0 +1,73 @@
+package keystone
+
+import (
+	"net/http"
+	"strings"
+)
+
+// BearerTokenValidator validates a bearer token presented at the federated
+// authentication endpoint of a protocol, and returns the ID of the Keystone
+// user it maps to.
+type BearerTokenValidator func(token string) (userID string, ok bool)
+
+// AddFederatedProtocol enables the federated authentication endpoint
+// /v3/OS-FEDERATION/identity_providers/{idp}/protocols/{protocol}/auth. The
+// endpoint issues unscoped tokens to the bearers of the tokens accepted by
+// validate.
+func (s *Server) AddFederatedProtocol(idp, protocol string, validate BearerTokenValidator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocols[idp+"/"+protocol] = validate
+}
+
+func (s *Server) handleFederatedAuth(w http.ResponseWriter, r *http.Request) {
+	protocol := r.PathValue("protocol")
+
+	s.mu.Lock()
+	validate, ok := s.protocols[r.PathValue("idp")+"/"+protocol]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "Could not find the federated protocol.")
+		return
+	}
+
+	credential, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		// the session established by the SAML2 ECP endpoint
+		if cookie, err := r.Cookie(sessionCookie); err == nil {
+			credential, ok = cookie.Value, true
+		}
+	}
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+		return
+	}
+	userID, ok := validate(credential)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "The federated credential is invalid.")
+		return
+	}
+
+	s.mu.Lock()
+	if _, ok := s.users[userID]; !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusUnauthorized, "Could not map the federated user.")
+		return
+	}
+	now := s.now()
+	t := &token{
+		id:        newID(),
+		userID:    userID,
+		methods:   []string{protocol},
+		issuedAt:  now,
+		expiresAt: now.Add(s.tokenTTL()),
+		auditIDs:  []string{newID()[:22]},
+	}
+	s.tokens[t.id] = t
+	s.issued++
+	body := s.renderToken(t, false)
+	s.mu.Unlock()
+
+	w.Header().Set("X-Subject-Token", t.id)
+	writeJSON(w, http.StatusCreated, body)
+}
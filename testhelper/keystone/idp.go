@@ -0,0 +1,197 @@
+package keystone
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+// IdP is a simulated OpenID Connect identity provider. It serves a discovery
+// document and a token endpoint supporting the password, client credentials
+// and authorization code grants, and issues opaque tokens that can be
+// validated with Subject.
+type IdP struct {
+	th.FakeServer
+
+	// ClientID and ClientSecret are the credentials of the only client of
+	// the identity provider. A client without secret is a public client.
+	ClientID     string
+	ClientSecret string
+
+	mu            sync.Mutex
+	users         map[string]string
+	codes         map[string]authorizationCode
+	tokens        map[string]string
+	tokenRequests int
+}
+
+type authorizationCode struct {
+	subject     string
+	redirectURI string
+}
+
+// SetupIdP starts a simulated OpenID Connect identity provider with a single
+// client. The caller must call Teardown when done.
+func SetupIdP(clientID, clientSecret string) *IdP {
+	p := &IdP{
+		FakeServer:   th.SetupHTTP(),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		users:        make(map[string]string),
+		codes:        make(map[string]authorizationCode),
+		tokens:       make(map[string]string),
+	}
+
+	p.Mux.HandleFunc("GET /.well-known/openid-configuration", p.handleDiscovery)
+	p.Mux.HandleFunc("POST /token", p.handleToken)
+
+	return p
+}
+
+// DiscoveryURL returns the URL of the discovery document.
+func (p *IdP) DiscoveryURL() string {
+	return p.Endpoint() + ".well-known/openid-configuration"
+}
+
+// TokenURL returns the URL of the token endpoint.
+func (p *IdP) TokenURL() string {
+	return p.Endpoint() + "token"
+}
+
+// AddUser adds a user, that is identified by its username in the tokens.
+func (p *IdP) AddUser(username, password string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.users[username] = password
+}
+
+// AuthorizationCode returns a single-use authorization code for the user, as
+// if the user had logged in with a browser and been redirected to
+// redirectURI.
+func (p *IdP) AuthorizationCode(username, redirectURI string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	code := newID()
+	p.codes[code] = authorizationCode{subject: username, redirectURI: redirectURI}
+	return code
+}
+
+// IssueToken returns a new access token for the subject.
+func (p *IdP) IssueToken(subject string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.issueToken(subject)
+}
+
+func (p *IdP) issueToken(subject string) string {
+	t := newID()
+	p.tokens[t] = subject
+	return t
+}
+
+// Subject returns the subject of an access token or ID token issued by the
+// identity provider: the username of a user, or the client ID for the client
+// credentials grant.
+func (p *IdP) Subject(token string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	subject, ok := p.tokens[token]
+	return subject, ok
+}
+
+// TokenRequests returns the number of successful requests to the token
+// endpoint.
+func (p *IdP) TokenRequests() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tokenRequests
+}
+
+// Validator returns a BearerTokenValidator accepting the tokens of the
+// identity provider, that maps their subjects to Keystone user IDs.
+func (p *IdP) Validator(userIDs map[string]string) BearerTokenValidator {
+	return func(token string) (string, bool) {
+		subject, ok := p.Subject(token)
+		if !ok {
+			return "", false
+		}
+		userID, ok := userIDs[subject]
+		return userID, ok
+	}
+}
+
+func (p *IdP) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":         p.Endpoint(),
+		"token_endpoint": p.TokenURL(),
+		"grant_types_supported": []string{
+			"password", "client_credentials", "authorization_code",
+		},
+	})
+}
+
+func (p *IdP) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		// the credentials are form-encoded before being sent as basic auth
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+	}
+	if clientID != p.ClientID || clientSecret != p.ClientSecret {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "Invalid client credentials.")
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var subject string
+	switch grant := r.PostForm.Get("grant_type"); grant {
+	case "password":
+		username := r.PostForm.Get("username")
+		password, ok := p.users[username]
+		if !ok || password != r.PostForm.Get("password") {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "Invalid user credentials.")
+			return
+		}
+		subject = username
+	case "client_credentials":
+		subject = clientID
+	case "authorization_code":
+		code, ok := p.codes[r.PostForm.Get("code")]
+		if !ok || code.redirectURI != r.PostForm.Get("redirect_uri") {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "Invalid authorization code.")
+			return
+		}
+		delete(p.codes, r.PostForm.Get("code"))
+		subject = code.subject
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "Unsupported grant type "+grant+".")
+		return
+	}
+
+	p.tokenRequests++
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": p.issueToken(subject),
+		"id_token":     p.issueToken(subject),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+	})
+}
+
+// writeOAuthError writes an error in the format of RFC 6749.
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
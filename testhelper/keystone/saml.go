@@ -0,0 +1,291 @@
+package keystone
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// assertionTTL is the lifetime of the SAML2 assertions, as configured by
+// default in Keystone.
+const assertionTTL = time.Hour
+
+// sessionCookie is the name of the cookie of the sessions established by
+// the SAML2 ECP endpoint.
+const sessionCookie = "_shibsession"
+
+// ServiceProvider is a remote Keystone registered as a service provider, for
+// Keystone to Keystone federation.
+type ServiceProvider struct {
+	ID      string
+	AuthURL string
+	SPURL   string
+}
+
+// AssertionValidator validates a SAML2 ECP assertion presented at the
+// service provider endpoint, and returns the ID of the Keystone user it maps
+// to.
+type AssertionValidator func(assertion []byte) (userID string, ok bool)
+
+// assertion is an issued SAML2 assertion.
+type assertion struct {
+	userID    string
+	expiresAt time.Time
+}
+
+// session is a session established by the SAML2 ECP endpoint.
+type session struct {
+	idp    string
+	userID string
+}
+
+// ecpEnvelope is the SOAP envelope of an ECP assertion.
+type ecpEnvelope struct {
+	Body struct {
+		Assertion struct {
+			ID string `xml:"ID,attr"`
+		} `xml:"Assertion"`
+	} `xml:"Body"`
+}
+
+// AddServiceProvider registers a service provider, for which the simulated
+// Keystone issues assertions at /v3/auth/OS-FEDERATION/saml2/ecp. Use the
+// FederatedAuthURL and ECPURL of the simulated remote Keystone.
+func (s *Server) AddServiceProvider(id, authURL, spURL string) ServiceProvider {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sp := &ServiceProvider{ID: id, AuthURL: authURL, SPURL: spURL}
+	s.serviceProviders[id] = sp
+	return *sp
+}
+
+// AddSAML2Protocol enables the saml2 protocol of an identity provider: the
+// assertions accepted by validate at the SAML2 ECP endpoint establish a
+// session, that the federated authentication endpoint of the protocol
+// exchanges for an unscoped token.
+func (s *Server) AddSAML2Protocol(idp string, validate AssertionValidator) {
+	s.mu.Lock()
+	s.samlIdPs[idp] = validate
+	s.mu.Unlock()
+
+	s.AddFederatedProtocol(idp, "saml2", func(id string) (string, bool) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		sess, ok := s.sessions[id]
+		if !ok || sess.idp != idp {
+			return "", false
+		}
+		return sess.userID, true
+	})
+}
+
+// ECPURL returns the SAML2 ECP endpoint of the simulated Keystone, where the
+// assertions of the identity providers of AddSAML2Protocol are presented.
+func (s *Server) ECPURL() string {
+	return s.Endpoint() + "Shibboleth.sso/SAML2/ECP"
+}
+
+// FederatedAuthURL returns the federated authentication endpoint of a
+// protocol of an identity provider.
+func (s *Server) FederatedAuthURL(idp, protocol string) string {
+	return s.AuthURL() + "OS-FEDERATION/identity_providers/" + idp + "/protocols/" + protocol + "/auth"
+}
+
+// AssertionValidator returns a validator of the assertions issued by the
+// simulated Keystone, that maps its users to the given users of the remote
+// Keystone. An assertion can only be used once.
+func (s *Server) AssertionValidator(users map[string]string) AssertionValidator {
+	return func(raw []byte) (string, bool) {
+		var envelope ecpEnvelope
+		if err := xml.Unmarshal(raw, &envelope); err != nil {
+			return "", false
+		}
+		id := envelope.Body.Assertion.ID
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		a, ok := s.assertions[id]
+		if !ok || !s.now().Before(a.expiresAt) {
+			return "", false
+		}
+		delete(s.assertions, id)
+
+		userID, ok := users[a.userID]
+		return userID, ok
+	}
+}
+
+func (s *Server) handleGetServiceProvider(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	_, authenticated := s.validToken(r.Header.Get("X-Auth-Token"))
+	sp, ok := s.serviceProviders[r.PathValue("id")]
+	s.mu.Unlock()
+
+	if !authenticated {
+		writeError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "Could not find service provider.")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"service_provider": map[string]any{
+			"id":                 sp.ID,
+			"auth_url":           sp.AuthURL,
+			"sp_url":             sp.SPURL,
+			"description":        "",
+			"enabled":            true,
+			"relay_state_prefix": "ss:mem:",
+		},
+	})
+}
+
+func (s *Server) handleECPAssertion(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Auth struct {
+			Identity struct {
+				Methods []string `json:"methods"`
+				Token   *struct {
+					ID string `json:"id"`
+				} `json:"token"`
+			} `json:"identity"`
+			Scope struct {
+				ServiceProvider *struct {
+					ID string `json:"id"`
+				} `json:"service_provider"`
+			} `json:"scope"`
+		} `json:"auth"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Malformed request body: "+err.Error())
+		return
+	}
+	identity := req.Auth.Identity
+	if !slices.Equal(identity.Methods, []string{"token"}) || identity.Token == nil {
+		writeError(w, http.StatusBadRequest, "Expecting to find token in identity.")
+		return
+	}
+	if req.Auth.Scope.ServiceProvider == nil {
+		writeError(w, http.StatusBadRequest, "Expecting to find service_provider in scope.")
+		return
+	}
+
+	s.mu.Lock()
+	body, status, message := s.issueAssertion(identity.Token.ID, req.Auth.Scope.ServiceProvider.ID)
+	s.mu.Unlock()
+
+	if status != http.StatusOK {
+		writeError(w, status, message)
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.paos+xml")
+	_, _ = w.Write(body)
+}
+
+// issueAssertion issues an ECP assertion for a service provider in exchange
+// for a project-scoped token. It must be called with the lock held.
+func (s *Server) issueAssertion(tokenID, spID string) ([]byte, int, string) {
+	t, ok := s.validToken(tokenID)
+	if !ok {
+		return nil, http.StatusUnauthorized, "The token is invalid, expired or revoked."
+	}
+	sp, ok := s.serviceProviders[spID]
+	if !ok {
+		return nil, http.StatusNotFound, "Could not find service provider."
+	}
+	if t.projectID == "" {
+		return nil, http.StatusForbidden, "A project-scoped token is required to issue an assertion."
+	}
+
+	now := s.now()
+	id := "_" + newID()
+	s.assertions[id] = &assertion{userID: t.userID, expiresAt: now.Add(assertionTTL)}
+
+	user := s.users[t.userID]
+	project := s.projects[t.projectID]
+	attributes := []struct {
+		name   string
+		values []string
+	}{
+		{"openstack_user", []string{user.Name}},
+		{"openstack_user_domain", []string{s.renderDomain(user.DomainID)["name"].(string)}},
+		{"openstack_roles", s.rolesOf(t)},
+		{"openstack_project", []string{project.Name}},
+		{"openstack_project_domain", []string{s.renderDomain(project.DomainID)["name"].(string)}},
+	}
+
+	var b bytes.Buffer
+	b.WriteString(`<soap11:Envelope xmlns:soap11="http://schemas.xmlsoap.org/soap/envelope/">`)
+	b.WriteString(`<soap11:Header><ecp:RelayState xmlns:ecp="urn:oasis:names:tc:SAML:2.0:profiles:SSO:ecp" soap11:actor="http://schemas.xmlsoap.org/soap/actor/next" soap11:mustUnderstand="1">ss:mem:`)
+	b.WriteString(newID())
+	b.WriteString(`</ecp:RelayState></soap11:Header><soap11:Body>`)
+	fmt.Fprintf(&b, `<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion" ID="%s" IssueInstant="%s" Version="2.0">`, id, now.Format(timeFormat))
+	fmt.Fprintf(&b, `<saml2:Issuer>%sOS-FEDERATION/saml2/idp</saml2:Issuer>`, s.AuthURL())
+	b.WriteString(`<saml2:Subject><saml2:NameID>`)
+	_ = xml.EscapeText(&b, []byte(user.Name))
+	fmt.Fprintf(&b, `</saml2:NameID><saml2:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer"><saml2:SubjectConfirmationData NotOnOrAfter="%s" Recipient="%s"/></saml2:SubjectConfirmation></saml2:Subject>`,
+		now.Add(assertionTTL).Format(timeFormat), sp.SPURL)
+	b.WriteString(`<saml2:AttributeStatement>`)
+	for _, attr := range attributes {
+		fmt.Fprintf(&b, `<saml2:Attribute Name="%s" NameFormat="urn:oasis:names:tc:SAML:2.0:attrname-format:uri">`, attr.name)
+		for _, v := range attr.values {
+			b.WriteString(`<saml2:AttributeValue>`)
+			_ = xml.EscapeText(&b, []byte(v))
+			b.WriteString(`</saml2:AttributeValue>`)
+		}
+		b.WriteString(`</saml2:Attribute>`)
+	}
+	b.WriteString(`</saml2:AttributeStatement></saml2:Assertion></soap11:Body></soap11:Envelope>`)
+
+	return b.Bytes(), http.StatusOK, ""
+}
+
+// handleECP establishes a session for the assertions accepted by the
+// validator of an identity provider, and redirects to the federated
+// authentication endpoint of its saml2 protocol.
+func (s *Server) handleECP(w http.ResponseWriter, r *http.Request) {
+	if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/vnd.paos+xml") {
+		writeError(w, http.StatusUnsupportedMediaType, "Expecting an ECP message.")
+		return
+	}
+
+	var raw bytes.Buffer
+	if _, err := raw.ReadFrom(r.Body); err != nil {
+		writeError(w, http.StatusBadRequest, "Malformed request body: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	idps := slices.Sorted(maps.Keys(s.samlIdPs))
+	validators := maps.Clone(s.samlIdPs)
+	s.mu.Unlock()
+
+	for _, idp := range idps {
+		userID, ok := validators[idp](raw.Bytes())
+		if !ok {
+			continue
+		}
+
+		id := newID()
+		s.mu.Lock()
+		s.sessions[id] = &session{idp: idp, userID: userID}
+		s.mu.Unlock()
+
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: id, Path: "/", HttpOnly: true})
+		http.Redirect(w, r, s.FederatedAuthURL(idp, "saml2"), http.StatusFound)
+		return
+	}
+
+	writeError(w, http.StatusUnauthorized, "The assertion is invalid.")
+}
@@ -0,0 +1,575 @@
+/*
+Package keystone runs a stateful, in-memory simulation of the Keystone v3
+token API, so that authentication flows, reauthentication and endpoint
+selection can be tested end-to-end without a cloud.
+
+The simulated Keystone supports:
+
+  - the password, token, application credential and TOTP authentication
+    methods;
+  - project, domain and system scopes;
+  - token validation and revocation, and token expiry driven by a fake clock;
+  - the service catalog of the services registered with RegisterService;
+  - multi-factor authentication rules with auth receipts;
+  - the management of the application credentials of the users;
+  - OpenID Connect federation with the simulated identity provider of
+    SetupIdP;
+  - Keystone to Keystone federation between two simulated Keystones, with
+    AddServiceProvider and AddSAML2Protocol.
+
+Example to authenticate against the simulated Keystone
+
+	ks := keystone.Setup()
+	defer ks.Teardown()
+
+	domain := ks.AddDomain("default")
+	project := ks.AddProject("demo", domain.ID)
+	user := ks.AddUser("alice", "secret", domain.ID)
+	ks.AssignProjectRole(user.ID, project.ID, "member")
+
+	compute := th.SetupHTTP()
+	defer compute.Teardown()
+	compute.Mux.Handle("/servers", ks.RequireToken(handler))
+	ks.RegisterService("compute", "nova", keystone.PublicEndpoint(compute.Endpoint()))
+
+	provider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: ks.AuthURL(),
+		Username:         "alice",
+		Password:         "secret",
+		DomainName:       "default",
+		TenantName:       "demo",
+	})
+*/
+package keystone
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+const (
+	// DefaultRegion is the region of the endpoints created by
+	// PublicEndpoint, and of the identity endpoints of the catalog.
+	DefaultRegion = "RegionOne"
+
+	// DefaultTokenTTL is the lifetime of the tokens when Server.TokenTTL is
+	// not set.
+	DefaultTokenTTL = time.Hour
+)
+
+// Domain is a Keystone domain.
+type Domain struct {
+	ID   string
+	Name string
+}
+
+// Project is a Keystone project.
+type Project struct {
+	ID       string
+	Name     string
+	DomainID string
+}
+
+// User is a Keystone user.
+type User struct {
+	ID       string
+	Name     string
+	DomainID string
+	Password string
+
+	// TOTPSecret is the base32-encoded secret of the TOTP credential of the
+	// user, if any. See SetTOTPSecret.
+	TOTPSecret string
+
+	// MFARules are the multi-factor authentication rules of the user, if
+	// any. See SetMFARules.
+	MFARules [][]string
+}
+
+// ApplicationCredential is a Keystone application credential. It is scoped
+// to the project it was created for.
+type ApplicationCredential struct {
+	ID        string
+	Name      string
+	Secret    string
+	UserID    string
+	ProjectID string
+
+	// Roles are the names of the roles delegated by the application
+	// credential. All the roles of the user on the project are delegated if
+	// it is empty.
+	Roles []string
+
+	// ExpiresAt is the expiration time of the application credential. It
+	// never expires if it is zero.
+	ExpiresAt time.Time
+
+	// Description is the description of the application credential.
+	Description string
+
+	// Unrestricted reports whether the tokens of the application credential
+	// may manage application credentials.
+	Unrestricted bool
+
+	// AccessRules are the access rules of the application credential. They
+	// are reported, but not enforced.
+	AccessRules []AccessRule
+}
+
+// AccessRule is an access rule of an application credential.
+type AccessRule struct {
+	ID      string `json:"id"`
+	Service string `json:"service"`
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+}
+
+// Endpoint is an endpoint of a service in the catalog.
+type Endpoint struct {
+	ID        string
+	Interface string
+	Region    string
+	URL       string
+}
+
+// Service is a service of the catalog.
+type Service struct {
+	ID        string
+	Type      string
+	Name      string
+	Endpoints []Endpoint
+}
+
+// PublicEndpoint returns a public endpoint in DefaultRegion.
+func PublicEndpoint(url string) Endpoint {
+	return Endpoint{Interface: "public", Region: DefaultRegion, URL: url}
+}
+
+type assignment struct {
+	userID    string
+	projectID string
+	domainID  string
+	system    bool
+	role      string
+}
+
+// token is an issued token.
+type token struct {
+	id        string
+	userID    string
+	methods   []string
+	projectID string
+	domainID  string
+	system    bool
+	appCredID string
+	issuedAt  time.Time
+	expiresAt time.Time
+	auditIDs  []string
+	revoked   bool
+}
+
+// receipt is an issued auth receipt.
+type receipt struct {
+	id        string
+	userID    string
+	methods   []string
+	issuedAt  time.Time
+	expiresAt time.Time
+}
+
+// Server is a simulated Keystone. Its FakeServer serves the Identity v3 API;
+// other handlers can be added to its Mux.
+type Server struct {
+	th.FakeServer
+
+	// TokenTTL is the lifetime of the issued tokens. Defaults to
+	// DefaultTokenTTL.
+	TokenTTL time.Duration
+
+	mu          sync.Mutex
+	clockOffset time.Duration
+	domains     map[string]*Domain
+	projects    map[string]*Project
+	users       map[string]*User
+	appCreds    map[string]*ApplicationCredential
+	roles       map[string]string
+	assignments []assignment
+	services    []*Service
+	tokens      map[string]*token
+	receipts    map[string]*receipt
+	issued      int
+	protocols   map[string]BearerTokenValidator
+
+	serviceProviders map[string]*ServiceProvider
+	assertions       map[string]*assertion
+	samlIdPs         map[string]AssertionValidator
+	sessions         map[string]*session
+}
+
+// Setup starts a simulated Keystone. The caller must call Teardown when
+// done.
+func Setup() *Server {
+	s := &Server{
+		FakeServer: th.SetupHTTP(),
+		domains:    make(map[string]*Domain),
+		projects:   make(map[string]*Project),
+		users:      make(map[string]*User),
+		appCreds:   make(map[string]*ApplicationCredential),
+		roles:      make(map[string]string),
+		tokens:     make(map[string]*token),
+		receipts:   make(map[string]*receipt),
+		protocols:  make(map[string]BearerTokenValidator),
+
+		serviceProviders: make(map[string]*ServiceProvider),
+		assertions:       make(map[string]*assertion),
+		samlIdPs:         make(map[string]AssertionValidator),
+		sessions:         make(map[string]*session),
+	}
+
+	s.Mux.HandleFunc("GET /{$}", s.handleVersions)
+	s.Mux.HandleFunc("GET /v3/{$}", s.handleVersion)
+	s.Mux.HandleFunc("POST /v3/auth/tokens", s.handleCreateToken)
+	s.Mux.HandleFunc("GET /v3/auth/tokens", s.handleValidateToken)
+	s.Mux.HandleFunc("HEAD /v3/auth/tokens", s.handleValidateToken)
+	s.Mux.HandleFunc("DELETE /v3/auth/tokens", s.handleRevokeToken)
+	s.Mux.HandleFunc("GET /v3/auth/catalog", s.handleCatalog)
+	s.Mux.HandleFunc("POST /v3/users/{user}/application_credentials", s.handleCreateApplicationCredential)
+	s.Mux.HandleFunc("GET /v3/users/{user}/application_credentials/{id}", s.handleGetApplicationCredential)
+	s.Mux.HandleFunc("DELETE /v3/users/{user}/application_credentials/{id}", s.handleDeleteApplicationCredential)
+	s.Mux.HandleFunc("POST /v3/OS-FEDERATION/identity_providers/{idp}/protocols/{protocol}/auth", s.handleFederatedAuth)
+	s.Mux.HandleFunc("GET /v3/OS-FEDERATION/identity_providers/{idp}/protocols/{protocol}/auth", s.handleFederatedAuth)
+	s.Mux.HandleFunc("GET /v3/OS-FEDERATION/service_providers/{id}", s.handleGetServiceProvider)
+	s.Mux.HandleFunc("POST /v3/auth/OS-FEDERATION/saml2/ecp", s.handleECPAssertion)
+	s.Mux.HandleFunc("POST /Shibboleth.sso/SAML2/ECP", s.handleECP)
+
+	return s
+}
+
+// AuthURL returns the Identity v3 endpoint of the simulated Keystone.
+func (s *Server) AuthURL() string {
+	return s.Endpoint() + "v3/"
+}
+
+// Now returns the current time of the clock of the simulated Keystone.
+func (s *Server) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now()
+}
+
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.clockOffset).UTC()
+}
+
+// Advance moves the clock of the simulated Keystone forward, e.g. to expire
+// the issued tokens.
+func (s *Server) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clockOffset += d
+}
+
+// AddDomain creates a domain.
+func (s *Server) AddDomain(name string) Domain {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := &Domain{ID: newID(), Name: name}
+	if name == "default" && s.domains["default"] == nil {
+		// like the domain created by keystone-manage bootstrap
+		d.ID = "default"
+	}
+	s.domains[d.ID] = d
+	return *d
+}
+
+// AddProject creates a project in a domain.
+func (s *Server) AddProject(name, domainID string) Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := &Project{ID: newID(), Name: name, DomainID: domainID}
+	s.projects[p.ID] = p
+	return *p
+}
+
+// AddUser creates a user in a domain.
+func (s *Server) AddUser(name, password, domainID string) User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := &User{ID: newID(), Name: name, DomainID: domainID, Password: password}
+	s.users[u.ID] = u
+	return *u
+}
+
+// SetTOTPSecret creates a TOTP credential for the user, with the given
+// base32-encoded secret. Use TOTPPasscode to compute the passcodes accepted
+// by the totp authentication method.
+func (s *Server) SetTOTPSecret(userID, secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.users[userID]; ok {
+		u.TOTPSecret = secret
+	}
+}
+
+// SetMFARules sets the multi-factor authentication rules of a user: each
+// rule is a set of methods that are sufficient together to authenticate. An
+// authentication that satisfies none of the rules is answered with an auth
+// receipt, that can be completed with the remaining methods.
+func (s *Server) SetMFARules(userID string, rules [][]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.users[userID]; ok {
+		u.MFARules = rules
+	}
+}
+
+// AssignProjectRole grants a role to a user on a project. The role is
+// created if needed.
+func (s *Server) AssignProjectRole(userID, projectID, role string) {
+	s.assign(assignment{userID: userID, projectID: projectID, role: role})
+}
+
+// AssignDomainRole grants a role to a user on a domain. The role is created
+// if needed.
+func (s *Server) AssignDomainRole(userID, domainID, role string) {
+	s.assign(assignment{userID: userID, domainID: domainID, role: role})
+}
+
+// AssignSystemRole grants a role to a user on the system. The role is
+// created if needed.
+func (s *Server) AssignSystemRole(userID, role string) {
+	s.assign(assignment{userID: userID, system: true, role: role})
+}
+
+func (s *Server) assign(a assignment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.roles[a.role]; !ok {
+		s.roles[a.role] = newID()
+	}
+	s.assignments = append(s.assignments, a)
+}
+
+// AddApplicationCredential creates an application credential. Its ID and
+// secret are generated if they are empty.
+func (s *Server) AddApplicationCredential(ac ApplicationCredential) ApplicationCredential {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ac.ID == "" {
+		ac.ID = newID()
+	}
+	if ac.Secret == "" {
+		ac.Secret = newID()
+	}
+	s.appCreds[ac.ID] = &ac
+	return ac
+}
+
+// DeleteApplicationCredential deletes an application credential, and
+// revokes the tokens it issued.
+func (s *Server) DeleteApplicationCredential(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteApplicationCredential(id)
+}
+
+func (s *Server) deleteApplicationCredential(id string) {
+	delete(s.appCreds, id)
+	for _, t := range s.tokens {
+		if t.appCredID == id {
+			t.revoked = true
+		}
+	}
+}
+
+// ApplicationCredentials returns the application credentials of a user.
+func (s *Server) ApplicationCredentials(userID string) []ApplicationCredential {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var appCreds []ApplicationCredential
+	for _, ac := range s.appCreds {
+		if ac.UserID == userID {
+			appCreds = append(appCreds, *ac)
+		}
+	}
+	return appCreds
+}
+
+// RegisterService adds a service to the catalog of the issued tokens.
+func (s *Server) RegisterService(serviceType, name string, endpoints ...Endpoint) Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc := &Service{ID: newID(), Type: serviceType, Name: name}
+	for _, e := range endpoints {
+		if e.ID == "" {
+			e.ID = newID()
+		}
+		svc.Endpoints = append(svc.Endpoints, e)
+	}
+	s.services = append(s.services, svc)
+	return *svc
+}
+
+// RevokeToken revokes a token.
+func (s *Server) RevokeToken(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.tokens[id]; ok {
+		t.revoked = true
+	}
+}
+
+// RevokeUserTokens revokes all the tokens of a user.
+func (s *Server) RevokeUserTokens(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tokens {
+		if t.userID == userID {
+			t.revoked = true
+		}
+	}
+}
+
+// ValidToken reports whether the token was issued by the simulated Keystone,
+// and is neither expired nor revoked.
+func (s *Server) ValidToken(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.validToken(id)
+	return ok
+}
+
+func (s *Server) validToken(id string) (*token, bool) {
+	t, ok := s.tokens[id]
+	if !ok || t.revoked || !s.now().Before(t.expiresAt) {
+		return nil, false
+	}
+	return t, true
+}
+
+// IssuedTokens returns the number of tokens issued so far.
+func (s *Server) IssuedTokens() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issued
+}
+
+// RequireToken wraps the handler of a fake service, so that it responds
+// with a 401 error to the requests that do not carry a valid token in their
+// X-Auth-Token header.
+func (s *Server) RequireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.ValidToken(r.Header.Get("X-Auth-Token")) {
+			writeError(w, http.StatusUnauthorized, "The request you have made requires authentication.")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	// crypto/rand.Read never fails
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (s *Server) findUser(id, name, domainID, domainName string) (*User, bool) {
+	if id != "" {
+		u, ok := s.users[id]
+		return u, ok
+	}
+
+	domain, ok := s.findDomain(domainID, domainName)
+	if !ok {
+		return nil, false
+	}
+	for _, u := range s.users {
+		if u.Name == name && u.DomainID == domain.ID {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Server) findDomain(id, name string) (*Domain, bool) {
+	if id != "" {
+		d, ok := s.domains[id]
+		return d, ok
+	}
+	for _, d := range s.domains {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Server) findProject(id, name, domainID, domainName string) (*Project, bool) {
+	if id != "" {
+		p, ok := s.projects[id]
+		return p, ok
+	}
+
+	domain, ok := s.findDomain(domainID, domainName)
+	if !ok {
+		return nil, false
+	}
+	for _, p := range s.projects {
+		if p.Name == name && p.DomainID == domain.ID {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// rolesOf returns the names of the roles of a user on the scope of a token.
+func (s *Server) rolesOf(t *token) []string {
+	var roles []string
+	for _, a := range s.assignments {
+		if a.userID != t.userID {
+			continue
+		}
+		if (t.projectID != "" && a.projectID == t.projectID) ||
+			(t.domainID != "" && a.domainID == t.domainID) ||
+			(t.system && a.system) {
+			if !slices.Contains(roles, a.role) {
+				roles = append(roles, a.role)
+			}
+		}
+	}
+
+	if ac, ok := s.appCreds[t.appCredID]; ok && len(ac.Roles) > 0 {
+		var delegated []string
+		for _, r := range roles {
+			for _, acr := range ac.Roles {
+				if strings.EqualFold(r, acr) {
+					delegated = append(delegated, r)
+				}
+			}
+		}
+		roles = delegated
+	}
+
+	return roles
+}
@@ -0,0 +1,2 @@
+// keystone
+package testing
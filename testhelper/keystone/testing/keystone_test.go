@@ -0,0 +1,247 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/keystone"
+)
+
+const totpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+type cloud struct {
+	ks      *keystone.Server
+	compute th.FakeServer
+	domain  keystone.Domain
+	project keystone.Project
+	user    keystone.User
+}
+
+func setupCloud(t *testing.T) *cloud {
+	ks := keystone.Setup()
+	t.Cleanup(ks.Teardown)
+
+	c := &cloud{ks: ks}
+	c.domain = ks.AddDomain("default")
+	c.project = ks.AddProject("demo", c.domain.ID)
+	c.user = ks.AddUser("alice", "secret", c.domain.ID)
+	ks.AssignProjectRole(c.user.ID, c.project.ID, "member")
+
+	c.compute = th.SetupHTTP()
+	t.Cleanup(c.compute.Teardown)
+	c.compute.Mux.Handle("/servers", ks.RequireToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"servers": []}`)
+	})))
+	ks.RegisterService("compute", "nova", keystone.PublicEndpoint(c.compute.Endpoint()))
+
+	return c
+}
+
+func (c *cloud) listServers(t *testing.T, provider *gophercloud.ProviderClient) {
+	t.Helper()
+
+	endpoint, err := provider.EndpointLocator(context.TODO(), gophercloud.EndpointOpts{
+		Type:         "compute",
+		Availability: gophercloud.AvailabilityPublic,
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, c.compute.Endpoint(), endpoint)
+
+	_, err = provider.Request(context.TODO(), "GET", endpoint+"servers", &gophercloud.RequestOpts{OkCodes: []int{200}})
+	th.AssertNoErr(t, err)
+}
+
+func TestPasswordProjectScope(t *testing.T) {
+	c := setupCloud(t)
+
+	provider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.Endpoint(),
+		Username:         "alice",
+		Password:         "secret",
+		DomainName:       "default",
+		TenantName:       "demo",
+	})
+	th.AssertNoErr(t, err)
+	c.listServers(t, provider)
+
+	project, err := provider.GetAuthResult().(tokens.CreateResult).ExtractProject()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, c.project.ID, project.ID)
+
+	_, err = openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.AuthURL(),
+		Username:         "alice",
+		Password:         "wrong",
+		DomainName:       "default",
+		TenantName:       "demo",
+	})
+	th.AssertEquals(t, true, gophercloud.ResponseCodeIs(err, http.StatusUnauthorized))
+}
+
+func TestReauthentication(t *testing.T) {
+	c := setupCloud(t)
+
+	provider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.AuthURL(),
+		UserID:           c.user.ID,
+		Password:         "secret",
+		TenantID:         c.project.ID,
+		AllowReauth:      true,
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, c.ks.IssuedTokens())
+
+	c.ks.RevokeToken(provider.Token())
+	c.listServers(t, provider)
+	th.AssertEquals(t, 2, c.ks.IssuedTokens())
+
+	c.ks.Advance(2 * keystone.DefaultTokenTTL)
+	th.AssertEquals(t, false, c.ks.ValidToken(provider.Token()))
+	c.listServers(t, provider)
+	th.AssertEquals(t, 3, c.ks.IssuedTokens())
+}
+
+func TestTokenValidationAndRevocation(t *testing.T) {
+	c := setupCloud(t)
+
+	provider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.AuthURL(),
+		UserID:           c.user.ID,
+		Password:         "secret",
+		TenantID:         c.project.ID,
+	})
+	th.AssertNoErr(t, err)
+	client, err := openstack.NewIdentityV3(context.TODO(), provider, gophercloud.EndpointOpts{})
+	th.AssertNoErr(t, err)
+
+	// rescope an unscoped token
+	unscoped := tokens.Create(context.TODO(), client, &tokens.AuthOptions{UserID: c.user.ID, Password: "secret"})
+	th.AssertNoErr(t, unscoped.Err)
+	catalog, err := unscoped.ExtractServiceCatalog()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, len(catalog.Entries))
+	unscopedToken, err := unscoped.ExtractToken()
+	th.AssertNoErr(t, err)
+
+	rescoped := tokens.Create(context.TODO(), client, &tokens.AuthOptions{
+		TokenID: unscopedToken.ID,
+		Scope:   tokens.Scope{ProjectID: c.project.ID},
+	})
+	th.AssertNoErr(t, rescoped.Err)
+	rescopedToken, err := rescoped.ExtractToken()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, true, rescopedToken.ExpiresAt.Equal(unscopedToken.ExpiresAt))
+
+	roles, err := tokens.Get(context.TODO(), client, rescopedToken.ID).ExtractRoles()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, len(roles))
+	th.AssertEquals(t, "member", roles[0].Name)
+
+	th.AssertNoErr(t, tokens.Revoke(context.TODO(), client, rescopedToken.ID).Err)
+	ok, err := tokens.Validate(context.TODO(), client, rescopedToken.ID)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, false, ok)
+}
+
+func TestApplicationCredential(t *testing.T) {
+	c := setupCloud(t)
+	ac := c.ks.AddApplicationCredential(keystone.ApplicationCredential{
+		Name:      "ci",
+		UserID:    c.user.ID,
+		ProjectID: c.project.ID,
+	})
+
+	provider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint:            c.ks.AuthURL(),
+		ApplicationCredentialID:     ac.ID,
+		ApplicationCredentialSecret: ac.Secret,
+	})
+	th.AssertNoErr(t, err)
+	c.listServers(t, provider)
+
+	provider, err = openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint:            c.ks.AuthURL(),
+		ApplicationCredentialName:   "ci",
+		ApplicationCredentialSecret: ac.Secret,
+		Username:                    "alice",
+		DomainName:                  "default",
+	})
+	th.AssertNoErr(t, err)
+
+	c.ks.DeleteApplicationCredential(ac.ID)
+	th.AssertEquals(t, false, c.ks.ValidToken(provider.Token()))
+}
+
+func TestTOTP(t *testing.T) {
+	c := setupCloud(t)
+	c.ks.SetTOTPSecret(c.user.ID, totpSecret)
+
+	passcode, err := keystone.TOTPPasscode(totpSecret, c.ks.Now())
+	th.AssertNoErr(t, err)
+
+	provider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.AuthURL(),
+		UserID:           c.user.ID,
+		Password:         "secret",
+		Passcode:         passcode,
+		TenantID:         c.project.ID,
+	})
+	th.AssertNoErr(t, err)
+	c.listServers(t, provider)
+
+	_, err = openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.AuthURL(),
+		UserID:           c.user.ID,
+		Password:         "secret",
+		Passcode:         "000000",
+		TenantID:         c.project.ID,
+	})
+	th.AssertEquals(t, true, gophercloud.ResponseCodeIs(err, http.StatusUnauthorized))
+}
+
+func TestTOTPPasscode(t *testing.T) {
+	// RFC 6238 test vector for the ASCII secret "12345678901234567890"
+	passcode, err := keystone.TOTPPasscode(totpSecret, time.Unix(59, 0))
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "287082", passcode)
+}
+
+func TestDomainAndSystemScopes(t *testing.T) {
+	c := setupCloud(t)
+
+	_, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.AuthURL(),
+		UserID:           c.user.ID,
+		Password:         "secret",
+		Scope:            &gophercloud.AuthScope{DomainID: c.domain.ID},
+	})
+	th.AssertEquals(t, true, gophercloud.ResponseCodeIs(err, http.StatusUnauthorized))
+
+	c.ks.AssignDomainRole(c.user.ID, c.domain.ID, "admin")
+	provider, err := openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.AuthURL(),
+		UserID:           c.user.ID,
+		Password:         "secret",
+		Scope:            &gophercloud.AuthScope{DomainID: c.domain.ID},
+	})
+	th.AssertNoErr(t, err)
+	domain, err := provider.GetAuthResult().(tokens.CreateResult).ExtractDomain()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, c.domain.ID, domain.ID)
+
+	c.ks.AssignSystemRole(c.user.ID, "reader")
+	_, err = openstack.AuthenticatedClient(context.TODO(), gophercloud.AuthOptions{
+		IdentityEndpoint: c.ks.AuthURL(),
+		UserID:           c.user.ID,
+		Password:         "secret",
+		Scope:            &gophercloud.AuthScope{System: true},
+	})
+	th.AssertNoErr(t, err)
+}
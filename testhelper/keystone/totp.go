@@ -0,0 +1,63 @@
+package keystone
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// totpStep is the validity period of a TOTP passcode, as configured by
+// default in Keystone.
+const totpStep = 30 * time.Second
+
+// TOTPPasscode computes the 6-digit TOTP passcode (RFC 6238, HMAC-SHA1) of a
+// base32-encoded secret at the given time. Use Server.Now to compute a
+// passcode accepted by the simulated Keystone.
+func TOTPPasscode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return totp(key, uint64(t.Unix()/int64(totpStep/time.Second))), nil
+}
+
+// validTOTP checks a passcode, allowing for a clock drift of one period.
+func validTOTP(secret, passcode string, now time.Time) bool {
+	if secret == "" || passcode == "" {
+		return false
+	}
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return false
+	}
+
+	counter := uint64(now.Unix() / int64(totpStep/time.Second))
+	for _, c := range []uint64{counter - 1, counter, counter + 1} {
+		if hmac.Equal([]byte(totp(key, c)), []byte(passcode)) {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.TrimRight(secret, "="))
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+}
+
+func totp(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1000000)
+}
@@ -0,0 +1,171 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/client"
+)
+
+func TestBackoffRetryAfter(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	count := 0
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if count < 3 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "retry later", http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	})
+
+	p := &gophercloud.ProviderClient{}
+	p.SetToken(client.TokenID)
+	p.RetryBackoffFunc = (&gophercloud.Backoff{}).RetryBackoff
+
+	_, err := p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 3, count)
+}
+
+func TestBackoffMaxRetries(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	count := 0
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		count++
+		http.Error(w, "retry later", http.StatusTooManyRequests)
+	})
+
+	p := &gophercloud.ProviderClient{}
+	p.SetToken(client.TokenID)
+	p.MaxBackoffRetries = 2
+	p.RetryBackoffFunc = (&gophercloud.Backoff{InitialInterval: time.Millisecond}).RetryBackoff
+
+	_, err := p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+	th.AssertEquals(t, true, gophercloud.ResponseCodeIs(err, http.StatusTooManyRequests))
+	th.AssertEquals(t, 3, count)
+}
+
+func TestBackoffRespectsDeadline(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	count := 0
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.Header().Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+		http.Error(w, "retry later", http.StatusTooManyRequests)
+	})
+
+	p := &gophercloud.ProviderClient{}
+	p.SetToken(client.TokenID)
+	p.RetryBackoffFunc = (&gophercloud.Backoff{}).RetryBackoff
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := p.Request(ctx, "GET", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+	th.AssertEquals(t, true, gophercloud.ResponseCodeIs(err, http.StatusTooManyRequests))
+	th.AssertEquals(t, 1, count)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the backoff to give up immediately, but it took %s", elapsed)
+	}
+}
+
+func TestBackoffRewindsRawBody(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	count := 0
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		count++
+		body, err := io.ReadAll(r.Body)
+		th.AssertNoErr(t, err)
+		th.AssertEquals(t, "payload", string(body))
+		if count < 2 {
+			http.Error(w, "retry later", http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	p := &gophercloud.ProviderClient{}
+	p.SetToken(client.TokenID)
+	p.RetryBackoffFunc = (&gophercloud.Backoff{InitialInterval: time.Millisecond}).RetryBackoff
+
+	body := strings.NewReader("skipped:payload")
+	_, err := body.Seek(int64(len("skipped:")), io.SeekStart)
+	th.AssertNoErr(t, err)
+
+	_, err = p.Request(context.TODO(), "PUT", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{
+		RawBody: body,
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, count)
+}
+
+func TestBackoffRetryIdempotent(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	count := 0
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if count < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	p := &gophercloud.ProviderClient{}
+	p.SetToken(client.TokenID)
+	p.RetryFunc = (&gophercloud.Backoff{InitialInterval: time.Millisecond}).RetryIdempotent
+
+	_, err := p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 3, count)
+
+	// non-idempotent requests are not retried
+	count = 0
+	_, err = p.Request(context.TODO(), "POST", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+	th.AssertEquals(t, true, gophercloud.ResponseCodeIs(err, http.StatusServiceUnavailable))
+	th.AssertEquals(t, 1, count)
+}
+
+func TestBackoffRetryIdempotentMaxRetries(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	count := 0
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		count++
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	})
+
+	p := &gophercloud.ProviderClient{}
+	p.SetToken(client.TokenID)
+	p.RetryFunc = (&gophercloud.Backoff{InitialInterval: time.Millisecond, MaxRetries: 2}).RetryIdempotent
+
+	_, err := p.Request(context.TODO(), "DELETE", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+	th.AssertEquals(t, true, gophercloud.ResponseCodeIs(err, http.StatusBadGateway))
+	th.AssertEquals(t, 3, count)
+}
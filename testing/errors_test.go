@@ -1,6 +1,7 @@
 package testing
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
@@ -28,3 +29,82 @@ func TestErrUnexpectedResponseCode(t *testing.T) {
 	th.AssertEquals(t, gophercloud.ResponseCodeIs(errWrapped, http.StatusNotFound), true)
 	th.AssertEquals(t, gophercloud.ResponseCodeIs(errWrapped, http.StatusInternalServerError), false)
 }
+
+func TestParseFault(t *testing.T) {
+	for name, tc := range map[string]struct {
+		body     string
+		expected gophercloud.Fault
+	}{
+		"nova": {
+			body:     `{"itemNotFound": {"message": "Instance foo could not be found.", "code": 404}}`,
+			expected: gophercloud.Fault{Type: "itemNotFound", Code: "404", Message: "Instance foo could not be found."},
+		},
+		"cinder": {
+			body:     `{"overLimit": {"message": "VolumeSizeExceedsAvailableQuota", "code": 413, "details": "quota"}}`,
+			expected: gophercloud.Fault{Type: "overLimit", Code: "413", Message: "VolumeSizeExceedsAvailableQuota", Details: "quota"},
+		},
+		"neutron": {
+			body:     `{"NeutronError": {"type": "PortNotFound", "message": "Port foo could not be found.", "detail": ""}}`,
+			expected: gophercloud.Fault{Type: "PortNotFound", Message: "Port foo could not be found."},
+		},
+		"ironic": {
+			body:     `{"error_message": "{\"faultstring\": \"Node foo could not be found.\", \"faultcode\": \"Client\", \"debuginfo\": null}"}`,
+			expected: gophercloud.Fault{Type: "Client", Message: "Node foo could not be found."},
+		},
+		"keystone": {
+			body:     `{"error": {"code": 404, "title": "Not Found", "message": "Could not find project: foo."}}`,
+			expected: gophercloud.Fault{Type: "Not Found", Code: "404", Message: "Could not find project: foo."},
+		},
+		"heat": {
+			body:     `{"code": 404, "error": {"message": "The Stack (foo) could not be found.", "traceback": null, "type": "EntityNotFound"}, "title": "Not Found"}`,
+			expected: gophercloud.Fault{Type: "EntityNotFound", Code: "404", Message: "The Stack (foo) could not be found."},
+		},
+		"octavia": {
+			body:     `{"faultcode": "Client", "faultstring": "Quota has been met for resources: LoadBalancer", "debuginfo": null}`,
+			expected: gophercloud.Fault{Type: "Client", Message: "Quota has been met for resources: LoadBalancer"},
+		},
+		"placement": {
+			body:     `{"errors": [{"status": 409, "title": "Conflict", "detail": "resource provider generation conflict", "code": "placement.concurrent_update"}]}`,
+			expected: gophercloud.Fault{Type: "Conflict", Code: "placement.concurrent_update", Message: "resource provider generation conflict"},
+		},
+		"designate": {
+			body:     `{"code": 404, "type": "zone_not_found", "message": "Could not find Zone", "request_id": "req-1"}`,
+			expected: gophercloud.Fault{Type: "zone_not_found", Code: "404", Message: "Could not find Zone"},
+		},
+		"barbican": {
+			body:     `{"code": 404, "title": "Not Found", "description": "Secret not found."}`,
+			expected: gophercloud.Fault{Type: "Not Found", Code: "404", Message: "Secret not found."},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual, ok := gophercloud.ParseFault([]byte(tc.body))
+			th.AssertEquals(t, true, ok)
+			th.CheckDeepEquals(t, tc.expected, actual)
+		})
+	}
+
+	_, ok := gophercloud.ParseFault([]byte("404 Not Found\n\nThe resource could not be found."))
+	th.AssertEquals(t, false, ok)
+}
+
+func TestErrUnexpectedResponseCodeIs(t *testing.T) {
+	newErr := func(code int, body string) error {
+		return gophercloud.ErrUnexpectedResponseCode{Actual: code, Body: []byte(body)}
+	}
+
+	th.AssertEquals(t, true, errors.Is(newErr(404, ""), gophercloud.ErrNotFound))
+	th.AssertEquals(t, false, errors.Is(newErr(404, ""), gophercloud.ErrConflict))
+	th.AssertEquals(t, true, errors.Is(newErr(409, ""), gophercloud.ErrConflict))
+	th.AssertEquals(t, true, errors.Is(newErr(403, ""), gophercloud.ErrForbidden))
+	th.AssertEquals(t, true, errors.Is(newErr(429, ""), gophercloud.ErrOverLimit))
+	th.AssertEquals(t, false, errors.Is(newErr(403, `{"forbidden": {"message": "Policy doesn't allow it.", "code": 403}}`), gophercloud.ErrQuotaExceeded))
+	th.AssertEquals(t, true, errors.Is(newErr(403, `{"forbidden": {"message": "Quota exceeded for cores", "code": 403}}`), gophercloud.ErrQuotaExceeded))
+	th.AssertEquals(t, true, errors.Is(newErr(409, `{"NeutronError": {"type": "OverQuota", "message": "Quota exceeded for resources: ['port'].", "detail": ""}}`), gophercloud.ErrQuotaExceeded))
+	th.AssertEquals(t, true, errors.Is(newErr(413, `{"overLimit": {"message": "VolumeLimitExceeded", "code": 413}}`), gophercloud.ErrQuotaExceeded))
+
+	// wrapped errors are recognized as well
+	errWrapped := fmt.Errorf("could not frobnicate the foobar: %w", newErr(404, ""))
+	th.AssertEquals(t, true, errors.Is(errWrapped, gophercloud.ErrNotFound))
+	errReauth := &gophercloud.ErrErrorAfterReauthentication{ErrOriginal: newErr(404, "")}
+	th.AssertEquals(t, true, errors.Is(errReauth, gophercloud.ErrNotFound))
+}
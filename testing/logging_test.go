@@ -0,0 +1,122 @@
+package testing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/client"
+)
+
+func setupLoggingServer(t *testing.T) th.FakeServer {
+	fakeServer := th.SetupHTTP()
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Subject-Token", "subject-token")
+		w.Header().Set("X-Openstack-Request-Id", "req-1234")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"application_credential": {"name": "foo", "secret": "app-cred-secret"}}`)
+	})
+	return fakeServer
+}
+
+func TestLoggerInfo(t *testing.T) {
+	fakeServer := setupLoggingServer(t)
+	defer fakeServer.Teardown()
+
+	var buf bytes.Buffer
+	p := &gophercloud.ProviderClient{
+		Logger: slog.New(slog.NewJSONHandler(&buf, nil)),
+	}
+	p.SetToken(client.TokenID)
+
+	var actual map[string]any
+	_, err := p.Request(context.TODO(), "POST", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{
+		JSONBody:     map[string]any{"password": "hunter2"},
+		JSONResponse: &actual,
+		MoreHeaders:  map[string]string{"OpenStack-API-Version": "identity 3.14"},
+	})
+	th.AssertNoErr(t, err)
+
+	var record map[string]any
+	th.AssertNoErr(t, json.Unmarshal(buf.Bytes(), &record))
+	th.AssertEquals(t, "INFO", record["level"])
+	th.AssertEquals(t, "POST", record["method"])
+	th.AssertEquals(t, fakeServer.Endpoint()+"/route", record["url"])
+	th.AssertEquals(t, float64(http.StatusCreated), record["status"])
+	th.AssertEquals(t, "identity 3.14", record["microversion"])
+	th.AssertEquals(t, "req-1234", record["request_id"])
+	if _, ok := record["response_body"]; ok {
+		t.Errorf("response body must only be logged at debug level")
+	}
+}
+
+func TestLoggerDebugRedactsSecrets(t *testing.T) {
+	fakeServer := setupLoggingServer(t)
+	defer fakeServer.Teardown()
+
+	var buf bytes.Buffer
+	p := &gophercloud.ProviderClient{
+		Logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+	p.SetToken(client.TokenID)
+
+	var actual struct {
+		ApplicationCredential struct {
+			Secret string `json:"secret"`
+		} `json:"application_credential"`
+	}
+	_, err := p.Request(context.TODO(), "POST", fakeServer.Endpoint()+"/route?temp_url_sig=abcdef&temp_url_expires=1", &gophercloud.RequestOpts{
+		JSONBody: map[string]any{
+			"auth": map[string]any{
+				"identity": map[string]any{
+					"password": map[string]any{"user": map[string]any{"name": "admin", "password": "hunter2"}},
+					"token":    map[string]any{"id": "other-token"},
+				},
+			},
+		},
+		JSONResponse: &actual,
+	})
+	th.AssertNoErr(t, err)
+
+	// the response body is still available to the caller
+	th.AssertEquals(t, "app-cred-secret", actual.ApplicationCredential.Secret)
+
+	logged := buf.String()
+	for _, secret := range []string{client.TokenID, "subject-token", "hunter2", "other-token", "app-cred-secret", "abcdef"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("secret %q was logged: %s", secret, logged)
+		}
+	}
+
+	var record map[string]any
+	th.AssertNoErr(t, json.Unmarshal(buf.Bytes(), &record))
+	th.AssertEquals(t, true, strings.Contains(record["request_body"].(string), `"name":"admin"`))
+	th.AssertEquals(t, true, strings.Contains(record["response_body"].(string), `"name":"foo"`))
+}
+
+func TestLoggerRedactsBarbicanPayload(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	fakeServer.Mux.HandleFunc("/secrets/1/payload", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "top-secret")
+	})
+
+	var buf bytes.Buffer
+	p := &gophercloud.ProviderClient{
+		Logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+
+	_, err := p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"/secrets/1/payload", &gophercloud.RequestOpts{})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, false, strings.Contains(buf.String(), "top-secret"))
+}
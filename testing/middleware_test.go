@@ -0,0 +1,143 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/client"
+)
+
+type recordingMiddleware struct {
+	mut   sync.Mutex
+	infos []gophercloud.RequestInfo
+	codes []int
+}
+
+func (m *recordingMiddleware) Middleware(next gophercloud.RoundTripFunc) gophercloud.RoundTripFunc {
+	return func(req *http.Request, info gophercloud.RequestInfo) (*http.Response, error) {
+		resp, err := next(req, info)
+		m.mut.Lock()
+		defer m.mut.Unlock()
+		m.infos = append(m.infos, info)
+		if resp != nil {
+			m.codes = append(m.codes, resp.StatusCode)
+		}
+		return resp, err
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		th.CheckEquals(t, "outer,inner", r.Header.Get("X-Trace"))
+		fmt.Fprint(w, `{}`)
+	})
+
+	tag := func(name string) gophercloud.Middleware {
+		return func(next gophercloud.RoundTripFunc) gophercloud.RoundTripFunc {
+			return func(req *http.Request, info gophercloud.RequestInfo) (*http.Response, error) {
+				if v := req.Header.Get("X-Trace"); v != "" {
+					name = v + "," + name
+				}
+				req.Header.Set("X-Trace", name)
+				return next(req, info)
+			}
+		}
+	}
+
+	p := &gophercloud.ProviderClient{
+		Middlewares: []gophercloud.Middleware{tag("outer"), tag("inner")},
+	}
+	_, err := p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+	th.AssertNoErr(t, err)
+}
+
+func TestMiddlewareServiceClient(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	count := 0
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if count < 2 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "retry later", http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	})
+
+	m := &recordingMiddleware{}
+	p := &gophercloud.ProviderClient{
+		Middlewares:      []gophercloud.Middleware{m.Middleware},
+		RetryBackoffFunc: (&gophercloud.Backoff{InitialInterval: time.Millisecond}).RetryBackoff,
+	}
+	p.SetToken(client.TokenID)
+	sc := &gophercloud.ServiceClient{
+		ProviderClient: p,
+		Endpoint:       fakeServer.Endpoint(),
+		Type:           "compute",
+		Microversion:   "2.79",
+	}
+
+	_, err := sc.Get(context.TODO(), sc.ServiceURL("route"), nil, nil)
+	th.AssertNoErr(t, err)
+
+	th.AssertDeepEquals(t, []int{http.StatusTooManyRequests, http.StatusOK}, m.codes)
+	th.AssertDeepEquals(t, []gophercloud.RequestInfo{
+		{ServiceType: "compute", Microversion: "2.79", Retries: 0},
+		{ServiceType: "compute", Microversion: "2.79", Retries: 1},
+	}, m.infos)
+}
+
+func TestMiddlewareReauth(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "new-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	})
+	fakeServer.Mux.HandleFunc("/auth", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	m := &recordingMiddleware{}
+	p := &gophercloud.ProviderClient{
+		Middlewares: []gophercloud.Middleware{m.Middleware},
+	}
+	p.UseTokenLock()
+	p.SetToken(client.TokenID)
+	p.ReauthFunc = func(ctx context.Context) error {
+		tac := *p
+		tac.SetThrowaway(true)
+		tac.ReauthFunc = nil
+		_, err := tac.Request(ctx, "POST", fakeServer.Endpoint()+"/auth", &gophercloud.RequestOpts{})
+		if err != nil {
+			return err
+		}
+		p.SetToken("new-token")
+		return nil
+	}
+
+	_, err := p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+	th.AssertNoErr(t, err)
+
+	th.AssertDeepEquals(t, []int{http.StatusUnauthorized, http.StatusCreated, http.StatusOK}, m.codes)
+	th.AssertDeepEquals(t, []gophercloud.RequestInfo{
+		{},
+		{Reauthentication: true},
+		{Reauthenticated: true},
+	}, m.infos)
+}
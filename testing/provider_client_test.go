@@ -370,6 +370,47 @@ func TestRequestReauthsAtMostOnce(t *testing.T) {
 	}
 }
 
+func TestSetReauthFunc(t *testing.T) {
+	p := new(gophercloud.ProviderClient)
+	p.UseTokenLock()
+	p.SetToken("expired")
+	p.ReauthFunc = func(_ context.Context) error {
+		p.SetToken("previous")
+		return nil
+	}
+
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "current" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// the ReauthFunc can be replaced while the client is in use
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"route", &gophercloud.RequestOpts{})
+		}()
+	}
+	p.SetReauthFunc(func(_ context.Context) error {
+		p.SetToken("current")
+		return nil
+	})
+	wg.Wait()
+
+	p.SetToken("expired")
+	_, err := p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"route", &gophercloud.RequestOpts{})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "current", p.Token())
+}
+
 func TestRequestWithContext(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintln(w, "OK")
@@ -0,0 +1,147 @@
+package testing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+func TestRateLimiterBurst(t *testing.T) {
+	l := gophercloud.NewRateLimiter(50, 2)
+
+	for i := 0; i < 2; i++ {
+		waited, err := l.Wait(context.TODO())
+		th.AssertNoErr(t, err)
+		th.AssertEquals(t, time.Duration(0), waited)
+	}
+
+	start := time.Now()
+	waited, err := l.Wait(context.TODO())
+	th.AssertNoErr(t, err)
+	if waited <= 0 || waited > 20*time.Millisecond {
+		t.Errorf("unexpected wait time %s", waited)
+	}
+	if elapsed := time.Since(start); elapsed < waited {
+		t.Errorf("waited %s instead of %s", elapsed, waited)
+	}
+
+	stats := l.Stats()
+	th.AssertEquals(t, uint64(3), stats.Requests)
+	th.AssertEquals(t, uint64(1), stats.Delayed)
+	th.AssertEquals(t, waited, stats.WaitTime)
+	th.AssertEquals(t, waited, stats.MaxWaitTime)
+}
+
+func TestRateLimiterContext(t *testing.T) {
+	l := gophercloud.NewRateLimiter(1, 1)
+	_, err := l.Wait(context.TODO())
+	th.AssertNoErr(t, err)
+
+	// the next token is available in a second
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = l.Wait(ctx)
+	th.AssertErrIs(t, err, context.DeadlineExceeded)
+	if time.Since(start) > 5*time.Millisecond {
+		t.Errorf("Wait did not return immediately")
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err = l.Wait(ctx)
+	th.AssertErrIs(t, err, context.Canceled)
+
+	stats := l.Stats()
+	th.AssertEquals(t, uint64(1), stats.Requests)
+	th.AssertEquals(t, time.Duration(0), stats.WaitTime)
+}
+
+func TestServiceRateLimiters(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	var waits []time.Duration
+	provider := &gophercloud.ProviderClient{
+		RateLimiter: gophercloud.NewRateLimiter(1000, 100),
+		ServiceRateLimiters: map[string]*gophercloud.RateLimiter{
+			"network": gophercloud.NewRateLimiter(100, 1),
+		},
+		Middlewares: []gophercloud.Middleware{
+			func(next gophercloud.RoundTripFunc) gophercloud.RoundTripFunc {
+				return func(req *http.Request, info gophercloud.RequestInfo) (*http.Response, error) {
+					waits = append(waits, info.RateLimitWait)
+					return next(req, info)
+				}
+			},
+		},
+	}
+	network := &gophercloud.ServiceClient{ProviderClient: provider, Type: "network"}
+	compute := &gophercloud.ServiceClient{ProviderClient: provider, Type: "compute"}
+
+	for _, client := range []*gophercloud.ServiceClient{network, compute, compute, network} {
+		_, err := client.Get(context.TODO(), fakeServer.Endpoint()+"route", nil, nil)
+		th.AssertNoErr(t, err)
+	}
+
+	th.AssertEquals(t, 4, len(waits))
+	th.AssertEquals(t, time.Duration(0), waits[0])
+	th.AssertEquals(t, time.Duration(0), waits[1])
+	th.AssertEquals(t, time.Duration(0), waits[2])
+	if waits[3] == 0 {
+		t.Errorf("expected the second network request to wait")
+	}
+
+	th.AssertEquals(t, uint64(4), provider.RateLimiter.Stats().Requests)
+	th.AssertEquals(t, uint64(2), provider.ServiceRateLimiters["network"].Stats().Requests)
+
+	// a canceled context stops the wait
+	provider.ServiceRateLimiters["network"] = gophercloud.NewRateLimiter(0.001, 1)
+	_, err := network.Get(context.TODO(), fakeServer.Endpoint()+"route", nil, nil)
+	th.AssertNoErr(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = network.Get(ctx, fakeServer.Endpoint()+"route", nil, nil)
+	th.AssertErrIs(t, err, context.Canceled)
+}
+
+func TestServiceRateLimitersAlias(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	provider := &gophercloud.ProviderClient{
+		ServiceRateLimiters: map[string]*gophercloud.RateLimiter{
+			"volumev3": gophercloud.NewRateLimiter(100, 1),
+		},
+	}
+
+	// both the v3 and the v1 block storage clients are limited by the
+	// alias
+	for _, serviceType := range []string{"block-storage", "volume"} {
+		client := &gophercloud.ServiceClient{ProviderClient: provider, Type: serviceType}
+		_, err := client.Get(context.TODO(), fakeServer.Endpoint()+"route", nil, nil)
+		th.AssertNoErr(t, err)
+	}
+	th.AssertEquals(t, uint64(2), provider.ServiceRateLimiters["volumev3"].Stats().Requests)
+
+	// an exact match takes precedence over an alias
+	provider.ServiceRateLimiters["block-storage"] = gophercloud.NewRateLimiter(100, 1)
+	client := &gophercloud.ServiceClient{ProviderClient: provider, Type: "block-storage"}
+	_, err := client.Get(context.TODO(), fakeServer.Endpoint()+"route", nil, nil)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, uint64(1), provider.ServiceRateLimiters["block-storage"].Stats().Requests)
+	th.AssertEquals(t, uint64(2), provider.ServiceRateLimiters["volumev3"].Stats().Requests)
+}
@@ -0,0 +1,77 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/gophercloud/gophercloud/v2/testhelper/client"
+)
+
+func TestResultRequestID(t *testing.T) {
+	r := gophercloud.Result{
+		Header: http.Header{
+			"X-Openstack-Request-Id": []string{"req-1"},
+			"X-Compute-Request-Id":   []string{"req-1"},
+		},
+	}
+	th.AssertEquals(t, "req-1", r.RequestID())
+	th.AssertDeepEquals(t, []string{"req-1"}, r.RequestIDs())
+
+	th.AssertEquals(t, "", gophercloud.Result{}.RequestID())
+	th.AssertEquals(t, 0, len(gophercloud.Result{}.RequestIDs()))
+}
+
+func TestErrUnexpectedResponseCodeRequestID(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Openstack-Request-Id", "req-1")
+		w.Header().Set("X-Compute-Request-Id", "req-2")
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+
+	p := &gophercloud.ProviderClient{}
+	p.SetToken(client.TokenID)
+
+	_, err := p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+	var respErr gophercloud.ErrUnexpectedResponseCode
+	th.AssertEquals(t, true, errors.As(err, &respErr))
+	th.AssertEquals(t, "req-1", respErr.RequestID())
+	th.AssertDeepEquals(t, []string{"req-1", "req-2"}, respErr.RequestIDs())
+	th.AssertEquals(t, true, strings.Contains(err.Error(), "(request ID req-1)"))
+}
+
+func TestGlobalRequestID(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	id := gophercloud.NewGlobalRequestID()
+	th.AssertEquals(t, true, regexp.MustCompile(`^req-[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id))
+
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		th.CheckEquals(t, id, r.Header.Get("X-OpenStack-Request-ID"))
+		fmt.Fprint(w, `{}`)
+	})
+
+	p := &gophercloud.ProviderClient{}
+	p.SetToken(client.TokenID)
+
+	ctx := gophercloud.WithGlobalRequestID(context.TODO(), id)
+	_, err := p.Request(ctx, "GET", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+	th.AssertNoErr(t, err)
+
+	actual, ok := gophercloud.GlobalRequestIDFromContext(ctx)
+	th.AssertEquals(t, true, ok)
+	th.AssertEquals(t, id, actual)
+
+	_, ok = gophercloud.GlobalRequestIDFromContext(context.TODO())
+	th.AssertEquals(t, false, ok)
+}
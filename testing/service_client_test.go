@@ -33,3 +33,27 @@ func TestMoreHeaders(t *testing.T) {
 	th.AssertNoErr(t, err)
 	th.AssertEquals(t, resp.Request.Header.Get("custom"), "header")
 }
+
+func TestRequestMicroversionOverride(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	c := &gophercloud.ServiceClient{
+		ProviderClient: new(gophercloud.ProviderClient),
+		Type:           "compute",
+		Microversion:   "2.1",
+	}
+
+	resp, err := c.Get(context.TODO(), fakeServer.Endpoint()+"route", nil, &gophercloud.RequestOpts{Microversion: "2.79"})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "2.79", resp.Request.Header.Get("X-OpenStack-Nova-API-Version"))
+	th.AssertEquals(t, "compute 2.79", resp.Request.Header.Get("OpenStack-API-Version"))
+	th.AssertEquals(t, "2.1", c.Microversion)
+
+	resp, err = c.Get(context.TODO(), fakeServer.Endpoint()+"route", nil, nil)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "2.1", resp.Request.Header.Get("X-OpenStack-Nova-API-Version"))
+}
@@ -0,0 +1,160 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+type expiringAuthResult struct {
+	tokenID   string
+	expiresAt time.Time
+}
+
+func (r expiringAuthResult) ExtractTokenID() (string, error) {
+	return r.tokenID, nil
+}
+
+func (r expiringAuthResult) ExtractExpiresAt() (time.Time, error) {
+	return r.expiresAt, nil
+}
+
+func newRefreshingClient(t *testing.T, expiresIn time.Duration, reauths *int32) *gophercloud.ProviderClient {
+	p := new(gophercloud.ProviderClient)
+	p.UseTokenLock()
+	th.AssertNoErr(t, p.SetTokenAndAuthResult(expiringAuthResult{"old-token", time.Now().Add(expiresIn)}))
+	p.ReauthFunc = func(_ context.Context) error {
+		atomic.AddInt32(reauths, 1)
+		time.Sleep(100 * time.Millisecond)
+		return p.SetTokenAndAuthResult(expiringAuthResult{"new-token", time.Now().Add(time.Hour)})
+	}
+	return p
+}
+
+func TestTokenRefreshJustInTime(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "new-token" {
+			t.Errorf("request sent with an expiring token")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	})
+
+	var reauths int32
+	p := newRefreshingClient(t, time.Minute, &reauths)
+	p.TokenRefreshWindow = 5 * time.Minute
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+			th.CheckNoErr(t, err)
+		}()
+	}
+	wg.Wait()
+
+	th.AssertEquals(t, int32(1), atomic.LoadInt32(&reauths))
+}
+
+func TestTokenRefreshOutsideWindow(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		th.CheckEquals(t, "old-token", r.Header.Get("X-Auth-Token"))
+		fmt.Fprint(w, `{}`)
+	})
+
+	var reauths int32
+	p := newRefreshingClient(t, time.Hour, &reauths)
+	p.TokenRefreshWindow = 5 * time.Minute
+
+	_, err := p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, int32(0), atomic.LoadInt32(&reauths))
+}
+
+func TestTokenRefreshShortLivedToken(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	})
+
+	var reauths int32
+	p := newRefreshingClient(t, time.Minute, &reauths)
+	p.TokenRefreshWindow = 5 * time.Minute
+	// the new tokens are within the window too
+	p.ReauthFunc = func(_ context.Context) error {
+		n := atomic.AddInt32(&reauths, 1)
+		return p.SetTokenAndAuthResult(expiringAuthResult{fmt.Sprintf("new-token-%d", n), time.Now().Add(time.Minute)})
+	}
+
+	for i := 0; i < 5; i++ {
+		_, err := p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+		th.AssertNoErr(t, err)
+	}
+	th.AssertEquals(t, int32(1), atomic.LoadInt32(&reauths))
+	th.AssertEquals(t, "new-token-1", p.Token())
+}
+
+func TestTokenRefreshFailure(t *testing.T) {
+	fakeServer := th.SetupHTTP()
+	defer fakeServer.Teardown()
+
+	fakeServer.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		th.CheckEquals(t, "old-token", r.Header.Get("X-Auth-Token"))
+		fmt.Fprint(w, `{}`)
+	})
+
+	var reauths int32
+	p := newRefreshingClient(t, time.Minute, &reauths)
+	p.TokenRefreshWindow = 5 * time.Minute
+	p.ReauthFunc = func(_ context.Context) error {
+		atomic.AddInt32(&reauths, 1)
+		return fmt.Errorf("identity service unavailable")
+	}
+
+	// the failed refresh is not retried by the next requests
+	for i := 0; i < 5; i++ {
+		_, err := p.Request(context.TODO(), "GET", fakeServer.Endpoint()+"/route", &gophercloud.RequestOpts{})
+		th.AssertNoErr(t, err)
+	}
+	th.AssertEquals(t, int32(1), atomic.LoadInt32(&reauths))
+}
+
+func TestKeepTokenFresh(t *testing.T) {
+	var reauths int32
+	p := newRefreshingClient(t, 5*time.Minute+500*time.Millisecond, &reauths)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err := p.KeepTokenFresh(ctx)
+	th.AssertErrIs(t, err, context.DeadlineExceeded)
+	th.AssertEquals(t, int32(1), atomic.LoadInt32(&reauths))
+	th.AssertEquals(t, "new-token", p.Token())
+}
+
+func TestKeepTokenFreshUnknownExpiry(t *testing.T) {
+	p := new(gophercloud.ProviderClient)
+	p.SetToken("token")
+	p.ReauthFunc = func(_ context.Context) error { return nil }
+
+	err := p.KeepTokenFresh(context.TODO())
+	th.AssertErr(t, err)
+}
@@ -0,0 +1,152 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+func refreshStates(states ...string) gophercloud.StateRefreshFunc {
+	return func(context.Context) (string, error) {
+		state := states[0]
+		if len(states) > 1 {
+			states = states[1:]
+		}
+		return state, nil
+	}
+}
+
+func TestWaiterWaitForState(t *testing.T) {
+	var progress []gophercloud.WaitProgress
+	waiter := gophercloud.Waiter{
+		InitialInterval: time.Millisecond,
+		Multiplier:      2,
+		Jitter:          -1,
+		Target:          []string{"ACTIVE"},
+		Failure:         []string{"ERROR"},
+		Progress: func(p gophercloud.WaitProgress) {
+			progress = append(progress, p)
+		},
+	}
+
+	err := waiter.WaitForState(context.TODO(), refreshStates("BUILD", "BUILD", "BUILD", "active"))
+	th.AssertNoErr(t, err)
+
+	th.AssertEquals(t, 4, len(progress))
+	for i, p := range progress {
+		th.AssertEquals(t, uint(i+1), p.Attempt)
+	}
+	th.AssertEquals(t, "BUILD", progress[0].State)
+	th.AssertEquals(t, time.Millisecond, progress[0].NextPoll)
+	th.AssertEquals(t, 2*time.Millisecond, progress[1].NextPoll)
+	th.AssertEquals(t, 4*time.Millisecond, progress[2].NextPoll)
+	th.AssertEquals(t, "active", progress[3].State)
+	th.AssertEquals(t, time.Duration(0), progress[3].NextPoll)
+}
+
+func TestWaiterMaxInterval(t *testing.T) {
+	var next []time.Duration
+	waiter := gophercloud.Waiter{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     3 * time.Millisecond,
+		Multiplier:      2,
+		Jitter:          -1,
+		Target:          []string{"done"},
+		Progress: func(p gophercloud.WaitProgress) {
+			next = append(next, p.NextPoll)
+		},
+	}
+
+	err := waiter.WaitForState(context.TODO(), refreshStates("a", "b", "c", "d", "done"))
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, []time.Duration{
+		time.Millisecond,
+		2 * time.Millisecond,
+		3 * time.Millisecond,
+		3 * time.Millisecond,
+		0,
+	}, next)
+}
+
+func TestWaiterFailureState(t *testing.T) {
+	waiter := gophercloud.Waiter{
+		InitialInterval: time.Millisecond,
+		Target:          []string{"available"},
+		Failure:         []string{"error", "error_deleting"},
+	}
+
+	err := waiter.WaitForState(context.TODO(), refreshStates("creating", "error"))
+	var failed gophercloud.ErrWaitFailed
+	th.AssertEquals(t, true, errors.As(err, &failed))
+	th.AssertEquals(t, "error", failed.State)
+	th.AssertEquals(t, `the resource reached the failure state "error"`, err.Error())
+}
+
+func TestWaiterDeleted(t *testing.T) {
+	notFound := gophercloud.ErrUnexpectedResponseCode{Actual: http.StatusNotFound}
+	calls := 0
+	refresh := func(context.Context) (string, error) {
+		calls++
+		if calls < 3 {
+			return "deleting", nil
+		}
+		return "", notFound
+	}
+
+	waiter := gophercloud.Waiter{
+		InitialInterval: time.Millisecond,
+		Target:          []string{"deleted"},
+	}
+	err := waiter.WaitForState(context.TODO(), refresh)
+	th.AssertErrIs(t, err, gophercloud.ErrNotFound)
+
+	calls = 0
+	waiter.Deleted = true
+	err = waiter.WaitForState(context.TODO(), refresh)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 3, calls)
+}
+
+func TestWaiterTimeout(t *testing.T) {
+	waiter := gophercloud.Waiter{
+		InitialInterval: time.Millisecond,
+		Timeout:         20 * time.Millisecond,
+		Target:          []string{"ACTIVE"},
+	}
+
+	err := waiter.WaitForState(context.TODO(), refreshStates("BUILD"))
+	var timeout gophercloud.ErrWaitTimeout
+	th.AssertEquals(t, true, errors.As(err, &timeout))
+	th.AssertEquals(t, "BUILD", timeout.State)
+	th.AssertErrIs(t, err, context.DeadlineExceeded)
+
+	// the deadline of the context is reported as is
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	waiter.Timeout = 0
+	err = waiter.WaitForState(ctx, refreshStates("BUILD"))
+	th.AssertEquals(t, false, errors.As(err, &timeout))
+	th.AssertErrIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWaiterWaitFor(t *testing.T) {
+	waiter := gophercloud.Waiter{InitialInterval: time.Millisecond}
+
+	calls := 0
+	err := waiter.WaitFor(context.TODO(), func(context.Context) (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 3, calls)
+
+	err = waiter.WaitFor(context.TODO(), func(context.Context) (bool, error) {
+		return false, errors.New("error has occurred")
+	})
+	th.AssertEquals(t, "error has occurred", err.Error())
+}
@@ -0,0 +1,143 @@
+package gophercloud
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// DefaultTokenRefreshWindow is the window used by KeepTokenFresh when
+// ProviderClient.TokenRefreshWindow is not set.
+const DefaultTokenRefreshWindow = 5 * time.Minute
+
+// expiringAuthResult is implemented by AuthResult types that know when their
+// token expires, such as tokens.CreateResult in the identity v2 and v3
+// packages.
+type expiringAuthResult interface {
+	AuthResult
+	ExtractExpiresAt() (time.Time, error)
+}
+
+// tokenExpiry safely reads the expiry of the current token. It is zero if
+// unknown.
+func (client *ProviderClient) tokenExpiry() time.Time {
+	if client.mut != nil {
+		client.mut.RLock()
+		defer client.mut.RUnlock()
+	}
+	return client.tokenExpiresAt
+}
+
+// tokenRefreshRetryInterval is the time during which no proactive token
+// refresh is attempted after a failed one.
+const tokenRefreshRetryInterval = 30 * time.Second
+
+// refreshExpiringToken reauthenticates if the current token expires within
+// TokenRefreshWindow. Concurrent callers share a single reauthentication. A
+// failed refresh is not fatal: the request is sent with the current token,
+// and the regular reauthentication on a 401 response takes over.
+func (client *ProviderClient) refreshExpiringToken(ctx context.Context) {
+	if client.TokenRefreshWindow <= 0 || client.reauthFunc() == nil || client.IsThrowaway() {
+		return
+	}
+
+	expiresAt := client.tokenExpiry()
+	if expiresAt.IsZero() || time.Until(expiresAt) > client.TokenRefreshWindow {
+		return
+	}
+	if !client.tokenRefreshAllowed(expiresAt) {
+		return
+	}
+
+	err := client.Reauthenticate(ctx, client.Token())
+	client.tokenRefreshed(err)
+	if err != nil && client.Logger != nil {
+		client.Logger.LogAttrs(ctx, slog.LevelWarn, "Proactive token refresh failed",
+			slog.Time("expires_at", expiresAt), slog.Any("error", err))
+	}
+}
+
+// tokenRefreshAllowed reports whether the token expiring at expiresAt may be
+// refreshed proactively: it was not obtained by a refresh within the window,
+// and the last refresh did not fail recently.
+func (client *ProviderClient) tokenRefreshAllowed(expiresAt time.Time) bool {
+	if client.mut != nil {
+		client.mut.RLock()
+		defer client.mut.RUnlock()
+	}
+	return !expiresAt.Equal(client.tokenRefreshSkip) && !time.Now().Before(client.tokenRefreshRetryAt)
+}
+
+// tokenRefreshed records the outcome of a proactive token refresh.
+func (client *ProviderClient) tokenRefreshed(err error) {
+	if client.mut != nil {
+		client.mut.Lock()
+		defer client.mut.Unlock()
+	}
+	if err != nil {
+		client.tokenRefreshRetryAt = time.Now().Add(tokenRefreshRetryInterval)
+		return
+	}
+	client.tokenRefreshRetryAt = time.Time{}
+	if time.Until(client.tokenExpiresAt) <= client.TokenRefreshWindow {
+		// the token lifetime is shorter than the window
+		client.tokenRefreshSkip = client.tokenExpiresAt
+	}
+}
+
+// KeepTokenFresh refreshes the token in the background, TokenRefreshWindow
+// (or DefaultTokenRefreshWindow if unset) before it expires, until ctx is
+// done. It is meant to be run in its own goroutine:
+//
+//	go func() {
+//		if err := provider.KeepTokenFresh(ctx); err != nil && !errors.Is(err, context.Canceled) {
+//			log.Printf("token refresh stopped: %v", err)
+//		}
+//	}()
+//
+// The refresh goes through Reauthenticate, so requests issued concurrently
+// wait for the new token instead of failing. KeepTokenFresh returns the
+// context error once ctx is done, or the error of a failed reauthentication.
+// It requires a ReauthFunc and an AuthResult that reports when the token
+// expires.
+func (client *ProviderClient) KeepTokenFresh(ctx context.Context) error {
+	if client.reauthFunc() == nil {
+		return errors.New("cannot keep the token fresh without a ReauthFunc")
+	}
+
+	window := client.TokenRefreshWindow
+	if window <= 0 {
+		window = DefaultTokenRefreshWindow
+	}
+
+	refreshed := false
+	for {
+		expiresAt := client.tokenExpiry()
+		if expiresAt.IsZero() {
+			return errors.New("cannot keep the token fresh: the token expiry is unknown")
+		}
+
+		wait := time.Until(expiresAt) - window
+		if refreshed && wait <= 0 {
+			// The token lifetime is shorter than the window. Do not refresh
+			// in a tight loop.
+			wait = max(time.Until(expiresAt)/2, time.Second)
+		}
+
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+
+		if err := client.Reauthenticate(ctx, client.Token()); err != nil {
+			return err
+		}
+		refreshed = true
+	}
+}
@@ -0,0 +1,79 @@
+package gophercloud
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+/*
+TokenStore persists Keystone tokens, so that several processes authenticating
+with the same credentials can share a token instead of each creating a new one.
+
+To use a TokenStore, set it in the AuthOptions passed to
+openstack.AuthenticatedClient or openstack.Authenticate. Before creating a
+token, the identity v3 authentication looks up a stored one under a key derived
+from the AuthOptions. A stored token is only reused if it does not expire soon
+and if it carries a service catalog. Every token created by the authentication
+(including the ones created when reauthenticating) is saved to the store.
+
+A file-based implementation is available in the openstack/tokenstore package.
+*/
+type TokenStore interface {
+	// LoadToken returns the token stored under the given key. It returns nil
+	// and no error if there is none.
+	LoadToken(ctx context.Context, key TokenStoreKey) (*StoredToken, error)
+
+	// SaveToken stores the token under the given key, replacing any
+	// previously stored token.
+	SaveToken(ctx context.Context, key TokenStoreKey, token StoredToken) error
+}
+
+// TokenStoreKey identifies the tokens in a TokenStore. Two authentications
+// with the same key can share a token.
+//
+// CredentialHash is a SHA-256 digest of the secrets of the authentication (the
+// password, passcode, application credential secret or token ID), so that
+// the secrets are not part of the key, and that authentications with other
+// secrets do not share a token.
+type TokenStoreKey struct {
+	AuthURL                   string `json:"auth_url"`
+	UserID                    string `json:"user_id,omitempty"`
+	Username                  string `json:"username,omitempty"`
+	UserDomainID              string `json:"user_domain_id,omitempty"`
+	UserDomainName            string `json:"user_domain_name,omitempty"`
+	ApplicationCredentialID   string `json:"application_credential_id,omitempty"`
+	ApplicationCredentialName string `json:"application_credential_name,omitempty"`
+	CredentialHash            string `json:"credential_hash,omitempty"`
+	ProjectID                 string `json:"project_id,omitempty"`
+	ProjectName               string `json:"project_name,omitempty"`
+	ScopeDomainID             string `json:"scope_domain_id,omitempty"`
+	ScopeDomainName           string `json:"scope_domain_name,omitempty"`
+	System                    bool   `json:"system,omitempty"`
+	TrustID                   string `json:"trust_id,omitempty"`
+	Region                    string `json:"region,omitempty"`
+}
+
+// Hash returns a stable digest of the key, which is suitable as a file name or
+// a cache key.
+func (k TokenStoreKey) Hash() string {
+	// marshaling a struct of strings and bools cannot fail
+	b, _ := json.Marshal(k)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// StoredToken is a token saved in a TokenStore.
+type StoredToken struct {
+	// ID is the token ID, as returned in the X-Subject-Token header.
+	ID string `json:"id"`
+
+	// ExpiresAt is the time at which the token expires.
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Body is the JSON body of the token creation response. It contains the
+	// service catalog and the token scope.
+	Body json.RawMessage `json:"body"`
+}
@@ -87,6 +87,7 @@ func RemainingKeys(s any, m map[string]any) (extras map[string]any) {
 // This is useful to wait for a resource to transition to a certain state.
 // Resource packages will wrap this in a more convenient function that's
 // specific to a certain resource, but it can also be useful on its own.
+// Use a Waiter to back off between polls or to detect failure states.
 func WaitFor(ctx context.Context, predicate func(context.Context) (bool, error)) error {
 	if done, err := predicate(ctx); done || err != nil {
 		return err
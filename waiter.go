@@ -0,0 +1,257 @@
+package gophercloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultWaiterInitialInterval is the delay between the first two polls
+	// when Waiter.InitialInterval is not set.
+	DefaultWaiterInitialInterval = 1 * time.Second
+
+	// DefaultWaiterMaxInterval is the upper bound for the delay between two
+	// polls when Waiter.MaxInterval is not set.
+	DefaultWaiterMaxInterval = 15 * time.Second
+
+	// DefaultWaiterMultiplier is the factor by which the delay between two
+	// polls grows when Waiter.Multiplier is not set.
+	DefaultWaiterMultiplier = 1.5
+)
+
+// StateRefreshFunc returns the current state of a resource, typically its
+// status field.
+type StateRefreshFunc func(ctx context.Context) (state string, err error)
+
+// WaitProgress describes a poll performed by a Waiter. It is passed to
+// Waiter.Progress.
+type WaitProgress struct {
+	// Attempt is the number of the poll, starting at 1.
+	Attempt uint
+
+	// State is the state returned by the poll. It is empty when polling a
+	// predicate with Waiter.WaitFor.
+	State string
+
+	// Elapsed is the time elapsed since the waiter started.
+	Elapsed time.Duration
+
+	// NextPoll is the delay before the next poll. It is zero if the wait is
+	// over.
+	NextPoll time.Duration
+}
+
+/*
+Waiter polls a resource until it reaches one of its target states, waiting
+longer and longer between two polls. It is the configurable counterpart of
+WaitFor.
+
+Resource packages wrap it in WaitForStatus and WaitForDeleted functions, but it
+can also be used on its own:
+
+	waiter := gophercloud.Waiter{
+		Target:  []string{"available"},
+		Failure: []string{"error"},
+		Timeout: 10 * time.Minute,
+	}
+	err := waiter.WaitForState(ctx, func(ctx context.Context) (string, error) {
+		volume, err := volumes.Get(ctx, client, id).Extract()
+		if err != nil {
+			return "", err
+		}
+		return volume.Status, nil
+	})
+
+States are compared case-insensitively. The zero value polls every second,
+then backs off up to DefaultWaiterMaxInterval.
+
+The WaitForStatus, WaitForDeleted and WaitForProvisionState helpers of the
+resource packages set Multiplier to 1 and disable the Jitter, so that they
+keep polling every second as they did when they were built on WaitFor. They
+now compare the states case-insensitively, and return an ErrWaitFailed when
+the resource reaches a failure state (e.g. ERROR) instead of polling until the
+context is done.
+*/
+type Waiter struct {
+	// InitialInterval is the delay between the first two polls. Defaults to
+	// DefaultWaiterInitialInterval.
+	InitialInterval time.Duration
+
+	// MaxInterval caps the delay between two polls. Defaults to
+	// DefaultWaiterMaxInterval.
+	MaxInterval time.Duration
+
+	// Multiplier is the factor by which the delay grows after each poll. Set
+	// it to 1 to poll at a fixed interval. Defaults to
+	// DefaultWaiterMultiplier.
+	Multiplier float64
+
+	// Jitter is the fraction (between 0 and 1) of each delay that is
+	// randomized. Defaults to DefaultBackoffJitter. Set it to a negative value
+	// to disable jitter.
+	Jitter float64
+
+	// Timeout, if set, bounds the duration of the wait. When it is exceeded,
+	// an ErrWaitTimeout is returned.
+	Timeout time.Duration
+
+	// Target lists the states that end the wait successfully.
+	Target []string
+
+	// Failure lists the terminal states that end the wait with an
+	// ErrWaitFailed, e.g. "ERROR" or "error_deleting".
+	Failure []string
+
+	// Deleted makes a 404 response to a poll (see ErrNotFound) end the wait
+	// successfully. Without it, the error is returned.
+	Deleted bool
+
+	// Progress, if set, is called after each poll.
+	Progress func(WaitProgress)
+}
+
+// WaitForState polls the refresh function until it returns one of the target
+// states. It returns an ErrWaitFailed if a failure state is reached, and the
+// error of the refresh function if it fails. The context cancellation stops
+// the wait.
+func (w *Waiter) WaitForState(ctx context.Context, refresh StateRefreshFunc) error {
+	return w.poll(ctx, func(ctx context.Context) (bool, string, error) {
+		state, err := refresh(ctx)
+		if err != nil {
+			if w.Deleted && errors.Is(err, ErrNotFound) {
+				return true, "", nil
+			}
+			return false, "", err
+		}
+
+		if containsState(w.Target, state) {
+			return true, state, nil
+		}
+		if containsState(w.Failure, state) {
+			return false, state, ErrWaitFailed{State: state}
+		}
+		return false, state, nil
+	})
+}
+
+// WaitFor polls a predicate function like the WaitFor function does, with the
+// intervals and timeout of the Waiter. Target, Failure and Deleted are
+// ignored.
+func (w *Waiter) WaitFor(ctx context.Context, predicate func(context.Context) (bool, error)) error {
+	return w.poll(ctx, func(ctx context.Context) (bool, string, error) {
+		done, err := predicate(ctx)
+		return done, "", err
+	})
+}
+
+func (w *Waiter) poll(ctx context.Context, check func(context.Context) (bool, string, error)) error {
+	parent := ctx
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+
+	backoff := Backoff{
+		InitialInterval: w.InitialInterval,
+		MaxInterval:     w.MaxInterval,
+		Multiplier:      w.Multiplier,
+		Jitter:          w.Jitter,
+	}
+	if backoff.InitialInterval <= 0 {
+		backoff.InitialInterval = DefaultWaiterInitialInterval
+	}
+	if backoff.MaxInterval <= 0 {
+		backoff.MaxInterval = DefaultWaiterMaxInterval
+	}
+	if backoff.Multiplier == 0 {
+		backoff.Multiplier = DefaultWaiterMultiplier
+	}
+
+	start := time.Now()
+	var state string
+	for attempt := uint(1); ; attempt++ {
+		done, current, err := check(ctx)
+		if current != "" {
+			state = current
+		}
+
+		var next time.Duration
+		if !done && err == nil {
+			next = backoff.delay(attempt)
+		}
+		if w.Progress != nil {
+			w.Progress(WaitProgress{
+				Attempt:  attempt,
+				State:    state,
+				Elapsed:  time.Since(start),
+				NextPoll: next,
+			})
+		}
+
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		if err == nil {
+			timer := time.NewTimer(next)
+			select {
+			case <-timer.C:
+				continue
+			case <-ctx.Done():
+				timer.Stop()
+			}
+		}
+
+		// the context is done
+		if parent.Err() == nil {
+			return ErrWaitTimeout{State: state, Timeout: w.Timeout}
+		}
+		return parent.Err()
+	}
+}
+
+func containsState(states []string, state string) bool {
+	return slices.ContainsFunc(states, func(s string) bool {
+		return strings.EqualFold(s, state)
+	})
+}
+
+// ErrWaitFailed is returned by a Waiter when the resource reaches one of its
+// failure states.
+type ErrWaitFailed struct {
+	BaseError
+	State string
+}
+
+func (e ErrWaitFailed) Error() string {
+	e.DefaultErrString = fmt.Sprintf("the resource reached the failure state %q", e.State)
+	return e.choseErrString()
+}
+
+// ErrWaitTimeout is returned by a Waiter when its Timeout is exceeded. It
+// matches context.DeadlineExceeded with errors.Is.
+type ErrWaitTimeout struct {
+	BaseError
+	// State is the last state returned by the resource.
+	State   string
+	Timeout time.Duration
+}
+
+func (e ErrWaitTimeout) Error() string {
+	e.DefaultErrString = fmt.Sprintf("timeout after %s while waiting for the resource", e.Timeout)
+	if e.State != "" {
+		e.DefaultErrString += fmt.Sprintf(" (last state %q)", e.State)
+	}
+	return e.choseErrString()
+}
+
+func (e ErrWaitTimeout) Unwrap() error {
+	return context.DeadlineExceeded
+}